@@ -1,13 +1,18 @@
 package main
 
 import (
+	ws "backend/internal/controllers/v1/ws"
+	"backend/internal/mail"
 	"backend/internal/redis"
 	"backend/internal/repositories"
 	"backend/internal/routes"
+	"backend/internal/services"
+	"backend/internal/storage"
 	"backend/jobs"
 	"context"
 	"log"
 	"net/http"
+	"os"
 	"os/signal"
 	"sync"
 	"syscall"
@@ -24,7 +29,7 @@ func init() {
 	}
 }
 
-func gracefulShutdown(apiServer *http.Server, asynqServer *asynq.Server, done chan bool) {
+func gracefulShutdown(apiServer *http.Server, asynqServer *asynq.Server, scheduler *asynq.Scheduler, smtpServer *mail.SMTPServer, done chan bool) {
 	// Create context that listens for the interrupt signal from the OS.
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
@@ -34,9 +39,15 @@ func gracefulShutdown(apiServer *http.Server, asynqServer *asynq.Server, done ch
 	log.Println("shutting down gracefully, press Ctrl+C again to force")
 	stop() // Allow Ctrl+C to force shutdown
 
-	// Shutdown asynq server first
+	// Shutdown asynq scheduler and server first
+	log.Println("Shutting down asynq scheduler...")
+	scheduler.Shutdown()
 	log.Println("Shutting down asynq server...")
 	asynqServer.Shutdown()
+	if smtpServer != nil {
+		log.Println("Shutting down inbound SMTP server...")
+		smtpServer.Close()
+	}
 
 	// The context is used to inform the server it has 5 seconds to finish
 	// the request it is currently handling
@@ -57,6 +68,12 @@ func main() {
 	redis.InitRedis()
 	defer repositories.Close()
 	defer redis.Close()
+	if err := storage.InitStorage(); err != nil {
+		log.Fatalf("Failed to initialize attachment storage: %v", err)
+	}
+	if err := mail.InitMailer(); err != nil {
+		log.Fatalf("Failed to initialize mailer: %v", err)
+	}
 
 	// Initialize job logger
 	if err := jobs.InitLogger("logs/jobs.log"); err != nil {
@@ -81,39 +98,86 @@ func main() {
 		},
 	})
 
+	// WebSocket hub, shared by the ticket routes and jobs that push events
+	hub := ws.NewHub()
+	go hub.Run()
+	// User notifications are also stored in the notifications inbox and emailed
+	services.SetEmailQueue(jobs.NewEmailQueue(client))
+	services.SetInvoicePDFQueue(jobs.NewInvoicePDFQueue(client))
+	notifier := services.NewInboxNotifier(hub)
+	services.SetNotifier(notifier)
+	services.SetPresenceTracker(hub)
+
 	// Register task handlers
 	mux := asynq.NewServeMux()
 	mux.HandleFunc(jobs.TypePDFInvoice, jobs.HandlePDFTask)
+	mux.HandleFunc(jobs.TypePDFRefund, jobs.HandleRefundPDFTask)
+	mux.HandleFunc(jobs.TypePDFCreditNote, jobs.HandleCreditNotePDFTask)
+	mux.Handle(jobs.TypeSendEmail, jobs.NewEmailHandler(mail.Mailer))
+	mux.Handle(jobs.TypeSLACheck, jobs.NewSLACheckHandler(notifier))
+	mux.Handle(jobs.TypeEscalationCheck, jobs.NewEscalationHandler(services.NewEscalationService(notifier)))
+
+	// Periodic tasks. Every replica runs a scheduler, so each check is
+	// unique for its interval and only one copy of it is queued at a time.
+	scheduler := asynq.NewScheduler(redisOpt, nil)
+	if _, err := scheduler.Register("@every 1m", asynq.NewTask(jobs.TypeSLACheck, nil), asynq.Unique(time.Minute)); err != nil {
+		log.Fatalf("Failed to register SLA check: %v", err)
+	}
+	if _, err := scheduler.Register("@every 1m", asynq.NewTask(jobs.TypeEscalationCheck, nil), asynq.Unique(time.Minute)); err != nil {
+		log.Fatalf("Failed to register escalation check: %v", err)
+	}
 
 	// Create HTTP server with routes (pass asynq client to routes)
 	server := &http.Server{
 		Addr:           ":8080",
-		Handler:        routes.RegisterRoutes(), // Pass client to routes
+		Handler:        routes.RegisterRoutes(hub), // Pass hub to routes
 		ReadTimeout:    5 * time.Second,
 		WriteTimeout:   10 * time.Second,
 		IdleTimeout:    15 * time.Second,
 		MaxHeaderBytes: 1 << 20,
 	}
 
+	// Optional SMTP listener feeding the inbound email gateway
+	var smtpServer *mail.SMTPServer
+	if addr := os.Getenv("INBOUND_SMTP_ADDR"); addr != "" {
+		smtpServer = &mail.SMTPServer{
+			Addr:     addr,
+			Domain:   os.Getenv("INBOUND_SMTP_DOMAIN"),
+			MaxBytes: services.InboundEmailMaxBytes,
+			Handler:  services.NewInboundEmailService().SMTPHandler(),
+		}
+	}
+
 	// Use WaitGroup to manage both HTTP server and asynq worker
 	var wg sync.WaitGroup
 	done := make(chan bool, 1)
 
 	// Start graceful shutdown handler
-	go gracefulShutdown(server, asynqServer, done)
+	go gracefulShutdown(server, asynqServer, scheduler, smtpServer, done)
 
 	// Start the asynq worker in a goroutine
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
 		log.Println("🔄 Starting asynq worker...")
-		log.Printf("📋 Worker ready to process tasks: %s", jobs.TypePDFInvoice)
+		log.Printf("📋 Worker ready to process tasks: %s, %s, %s, %s", jobs.TypePDFInvoice, jobs.TypePDFRefund, jobs.TypePDFCreditNote, jobs.TypeSendEmail)
 		if err := asynqServer.Run(mux); err != nil {
 			log.Printf("❌ Asynq worker error: %v", err)
 		}
 		log.Println("🔄 Asynq worker stopped")
 	}()
 
+	// Start the asynq scheduler in a goroutine
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		log.Printf("⏰ Starting asynq scheduler: %s, %s", jobs.TypeSLACheck, jobs.TypeEscalationCheck)
+		if err := scheduler.Run(); err != nil {
+			log.Printf("❌ Asynq scheduler error: %v", err)
+		}
+		log.Println("⏰ Asynq scheduler stopped")
+	}()
+
 	// Start the HTTP server in a goroutine
 	wg.Add(1)
 	go func() {
@@ -125,6 +189,19 @@ func main() {
 		log.Println("🚀 HTTP server stopped")
 	}()
 
+	// Start the inbound SMTP listener in a goroutine
+	if smtpServer != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			log.Printf("📨 Starting inbound SMTP server on %s", smtpServer.Addr)
+			if err := smtpServer.ListenAndServe(); err != nil {
+				log.Printf("❌ Inbound SMTP server error: %v", err)
+			}
+			log.Println("📨 Inbound SMTP server stopped")
+		}()
+	}
+
 	// Wait for shutdown signal
 	<-done
 
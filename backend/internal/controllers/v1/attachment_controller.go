@@ -0,0 +1,138 @@
+package v1_controllers
+
+import (
+	"backend/internal/services"
+	"backend/internal/storage"
+	"backend/utils"
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type AttachmentControllerV1 interface {
+	UploadAttachment(c *gin.Context)
+	ListAttachments(c *gin.Context)
+	GetAttachment(c *gin.Context)
+	DeleteAttachment(c *gin.Context)
+	DownloadAttachment(c *gin.Context)
+}
+
+type attachmentControllerV1 struct {
+	attachmentService *services.AttachmentService
+}
+
+func (a *attachmentControllerV1) UploadAttachment(c *gin.Context) {
+	ctx := context.Background()
+	var ticketID pgtype.UUID
+	if err := ticketID.Scan(c.Param("id")); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	// Leave room for the multipart framing around the file itself.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, services.AttachmentMaxBytes+1<<20)
+	file, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.Error(utils.NewHTTPError(http.StatusRequestEntityTooLarge, "file is too large")).SetType(gin.ErrorTypeAny)
+			return
+		}
+		c.Error(utils.NewHTTPError(http.StatusBadRequest, "file is required")).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	attachment, err := a.attachmentService.UploadAttachment(ctx, ticketID, actorFromContext(c), c.PostForm("comment_id"), file)
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.JSON(200, utils.SuccessResponse("success", gin.H{"attachment": attachment}))
+}
+
+func (a *attachmentControllerV1) ListAttachments(c *gin.Context) {
+	ctx := context.Background()
+	var ticketID pgtype.UUID
+	if err := ticketID.Scan(c.Param("id")); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	attachments, err := a.attachmentService.ListAttachments(ctx, ticketID, actorFromContext(c))
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.JSON(200, utils.SuccessResponse("success", gin.H{"attachments": attachments}))
+}
+
+func (a *attachmentControllerV1) GetAttachment(c *gin.Context) {
+	ctx := context.Background()
+	var ticketID, attachmentID pgtype.UUID
+	if err := ticketID.Scan(c.Param("id")); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+	if err := attachmentID.Scan(c.Param("attachmentId")); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	download, err := a.attachmentService.GetAttachmentDownload(ctx, ticketID, attachmentID, actorFromContext(c))
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.JSON(200, utils.SuccessResponse("success", download))
+}
+
+func (a *attachmentControllerV1) DeleteAttachment(c *gin.Context) {
+	ctx := context.Background()
+	var ticketID, attachmentID pgtype.UUID
+	if err := ticketID.Scan(c.Param("id")); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+	if err := attachmentID.Scan(c.Param("attachmentId")); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	if err := a.attachmentService.DeleteAttachment(ctx, ticketID, attachmentID, actorFromContext(c)); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.JSON(200, utils.SuccessResponse("success", "Attachment Deleted"))
+}
+
+// DownloadAttachment serves files of the local storage behind the signed URLs
+// it hands out. S3 URLs point at the bucket and never reach this handler.
+func (a *attachmentControllerV1) DownloadAttachment(c *gin.Context) {
+	local, ok := storage.Store.(*storage.LocalStorage)
+	if !ok {
+		c.Error(utils.NewHTTPError(http.StatusNotFound, "not found")).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	name := c.Query("name")
+	path, err := local.Verify(c.Query("key"), name, c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		c.Error(utils.NewHTTPError(http.StatusForbidden, err.Error())).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.FileAttachment(path, name)
+}
+
+func NewAttachmentControllerV1() AttachmentControllerV1 {
+	return &attachmentControllerV1{
+		attachmentService: services.NewAttachmentService(),
+	}
+}
@@ -0,0 +1,33 @@
+package v1_controllers
+
+import (
+	"backend/internal/services"
+	"backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// actorFromContext builds the service actor from the claims AuthMiddleware
+// stored on the request context.
+func actorFromContext(c *gin.Context) services.Actor {
+	return services.Actor{
+		ID:       c.GetString("userID"),
+		Username: c.GetString("username"),
+		Role:     c.GetString("role"),
+	}
+}
+
+// pageRequestFromContext reads the keyset pagination parameters set by
+// PaginationMiddleware.
+func pageRequestFromContext(c *gin.Context) services.PageRequest {
+	cursor, _ := c.Get("cursor")
+	size, _ := c.Get("size")
+	page := services.PageRequest{
+		Size:         size.(int32),
+		IncludeTotal: c.GetBool("include_total"),
+	}
+	if cursor != nil {
+		page.Cursor = cursor.(utils.Cursor)
+	}
+	return page
+}
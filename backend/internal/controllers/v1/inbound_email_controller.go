@@ -0,0 +1,57 @@
+package v1_controllers
+
+import (
+	"backend/internal/services"
+	"backend/utils"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InboundEmailControllerV1 interface {
+	ReceiveEmail(c *gin.Context)
+}
+
+type inboundEmailControllerV1 struct {
+	inboundEmailService *services.InboundEmailService
+}
+
+// ReceiveEmail accepts a raw MIME message as the request body. The envelope
+// recipients may be given in the recipient query parameter, repeated.
+func (i *inboundEmailControllerV1) ReceiveEmail(c *gin.Context) {
+	ctx := context.Background()
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, services.InboundEmailMaxBytes)
+	raw, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.Error(utils.NewHTTPError(http.StatusRequestEntityTooLarge, "email is too large")).SetType(gin.ErrorTypeAny)
+			return
+		}
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+	if len(strings.TrimSpace(string(raw))) == 0 {
+		c.Error(utils.NewHTTPError(http.StatusBadRequest, "email is required")).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	inbound, err := i.inboundEmailService.HandleEmail(ctx, raw, c.QueryArray("recipient"))
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.JSON(200, utils.SuccessResponse("success", gin.H{"inbound_email": inbound}))
+}
+
+func NewInboundEmailControllerV1() InboundEmailControllerV1 {
+	return &inboundEmailControllerV1{
+		inboundEmailService: services.NewInboundEmailService(),
+	}
+}
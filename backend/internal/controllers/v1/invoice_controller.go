@@ -1,50 +1,339 @@
 package v1_controllers
 
 import (
+	"context"
 	"net/http"
 
 	"backend/internal/dto"
+	"backend/internal/money"
 	"backend/internal/services"
-	"backend/jobs"
 	"backend/utils"
 
 	"github.com/gin-gonic/gin"
-	"github.com/hibiken/asynq"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type InvoiceControllerV1 interface {
 	CreateInvoice(c *gin.Context)
+	GetInvoice(c *gin.Context)
+	GetInvoicePDF(c *gin.Context)
+	ListInvoices(c *gin.Context)
+	CreatePayment(c *gin.Context)
+	GetPayment(c *gin.Context)
+	UpdatePaymentStatus(c *gin.Context)
+	ListPayments(c *gin.Context)
+	CreateRefund(c *gin.Context)
+	GetRefund(c *gin.Context)
+	GetRefundPDF(c *gin.Context)
+	ListRefunds(c *gin.Context)
+	CreateCreditNote(c *gin.Context)
+	ApplyCreditNote(c *gin.Context)
+	GetCreditNote(c *gin.Context)
+	GetCreditNotePDF(c *gin.Context)
+	ListCreditNotes(c *gin.Context)
 }
 
 type invoiceControllerV1 struct {
 	invoiceService *services.InvoiceService
-	asynqClient    *asynq.Client
 }
 
 func (i *invoiceControllerV1) CreateInvoice(c *gin.Context) {
+	ctx := context.Background()
 	var req dto.CreateInvoiceRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, utils.ErrorResponse("invalid_request", err.Error()))
+		c.Error(err).SetType(gin.ErrorTypeBind)
 		return
 	}
 
-	client := i.asynqClient
-	var createdClient bool
-	if client == nil {
-		client = asynq.NewClient(asynq.RedisClientOpt{Addr: "localhost:6379"})
-		createdClient = true
+	invoice, lines, err := i.invoiceService.CreateInvoice(ctx, actorFromContext(c), req)
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("success", gin.H{"invoice": dto.NewInvoiceResponse(invoice), "lines": dto.NewInvoiceLineResponses(lines, money.Currency(invoice.Currency))}))
+}
+
+func (i *invoiceControllerV1) GetInvoice(c *gin.Context) {
+	ctx := context.Background()
+	var invoiceID pgtype.UUID
+	if err := invoiceID.Scan(c.Param("id")); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	invoice, lines, err := i.invoiceService.GetInvoiceLines(ctx, actorFromContext(c), invoiceID)
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("success", gin.H{"invoice": dto.NewInvoiceResponse(invoice), "lines": dto.NewInvoiceLineResponses(lines, money.Currency(invoice.Currency))}))
+}
+
+func (i *invoiceControllerV1) GetInvoicePDF(c *gin.Context) {
+	ctx := context.Background()
+	var invoiceID pgtype.UUID
+	if err := invoiceID.Scan(c.Param("id")); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	filePath, err := i.invoiceService.GetInvoicePDF(ctx, actorFromContext(c), invoiceID)
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.FileAttachment(filePath, "invoice-"+invoiceID.String()+".pdf")
+}
+
+func (i *invoiceControllerV1) ListInvoices(c *gin.Context) {
+	ctx := context.Background()
+
+	invoices, page, err := i.invoiceService.ListInvoicesByTenantID(ctx, c.GetString("userID"), c.Query("tenant_id"), pageRequestFromContext(c))
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.PaginatedResponse("success", gin.H{"invoices": dto.NewInvoiceSummaryResponses(invoices)}, page))
+}
+
+func (i *invoiceControllerV1) CreatePayment(c *gin.Context) {
+	ctx := context.Background()
+	var req dto.CreatePaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	payment, invoice, err := i.invoiceService.CreatePayment(ctx, actorFromContext(c), req)
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("success", gin.H{"payment": dto.NewPaymentResponse(payment, money.Currency(invoice.Currency)), "invoice": dto.NewInvoiceResponse(invoice)}))
+}
+
+func (i *invoiceControllerV1) GetPayment(c *gin.Context) {
+	ctx := context.Background()
+	var paymentID pgtype.UUID
+	if err := paymentID.Scan(c.Param("id")); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	payment, invoice, err := i.invoiceService.GetPayment(ctx, actorFromContext(c), paymentID)
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("success", gin.H{"payment": dto.NewPaymentResponse(payment, money.Currency(invoice.Currency))}))
+}
+
+func (i *invoiceControllerV1) UpdatePaymentStatus(c *gin.Context) {
+	ctx := context.Background()
+	var paymentID pgtype.UUID
+	if err := paymentID.Scan(c.Param("id")); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+	var req dto.UpdatePaymentStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	payment, invoice, err := i.invoiceService.UpdatePaymentStatus(ctx, actorFromContext(c), paymentID, req)
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("success", gin.H{"payment": dto.NewPaymentResponse(payment, money.Currency(invoice.Currency)), "invoice": dto.NewInvoiceResponse(invoice)}))
+}
+
+func (i *invoiceControllerV1) ListPayments(c *gin.Context) {
+	ctx := context.Background()
+	var invoiceID pgtype.UUID
+	if err := invoiceID.Scan(c.Param("id")); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	payments, invoice, page, err := i.invoiceService.ListPayments(ctx, actorFromContext(c), invoiceID, pageRequestFromContext(c))
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.PaginatedResponse("success", gin.H{"payments": dto.NewPaymentResponses(payments, money.Currency(invoice.Currency))}, page))
+}
+
+func (i *invoiceControllerV1) CreateRefund(c *gin.Context) {
+	ctx := context.Background()
+	var paymentID pgtype.UUID
+	if err := paymentID.Scan(c.Param("id")); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+	var req dto.CreateRefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
 	}
 
-	// Ensure we close the client we created here (don't close injected client).
-	if createdClient {
-		defer client.Close()
+	refund, invoice, err := i.invoiceService.CreateRefund(ctx, actorFromContext(c), paymentID, req)
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("success", gin.H{"refund": dto.NewRefundResponse(refund, money.Currency(invoice.Currency)), "invoice": dto.NewInvoiceResponse(invoice)}))
+}
+
+func (i *invoiceControllerV1) GetRefund(c *gin.Context) {
+	ctx := context.Background()
+	var refundID pgtype.UUID
+	if err := refundID.Scan(c.Param("id")); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	refund, invoice, err := i.invoiceService.GetRefund(ctx, actorFromContext(c), refundID)
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("success", gin.H{"refund": dto.NewRefundResponse(refund, money.Currency(invoice.Currency))}))
+}
+
+func (i *invoiceControllerV1) GetRefundPDF(c *gin.Context) {
+	ctx := context.Background()
+	var refundID pgtype.UUID
+	if err := refundID.Scan(c.Param("id")); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	filePath, err := i.invoiceService.GetRefundPDF(ctx, actorFromContext(c), refundID)
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.FileAttachment(filePath, "refund-"+refundID.String()+".pdf")
+}
+
+func (i *invoiceControllerV1) ListRefunds(c *gin.Context) {
+	ctx := context.Background()
+	var invoiceID pgtype.UUID
+	if err := invoiceID.Scan(c.Param("id")); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	refunds, invoice, page, err := i.invoiceService.ListRefunds(ctx, actorFromContext(c), invoiceID, pageRequestFromContext(c))
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.PaginatedResponse("success", gin.H{"refunds": dto.NewRefundResponses(refunds, money.Currency(invoice.Currency))}, page))
+}
+
+func (i *invoiceControllerV1) CreateCreditNote(c *gin.Context) {
+	ctx := context.Background()
+	var req dto.CreateCreditNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	creditNote, invoice, err := i.invoiceService.CreateCreditNote(ctx, actorFromContext(c), req)
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
 	}
-	if err := jobs.EnqueueInvoice(client, req.TicketID, req.Amount); err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("enqueue_failed", err.Error()))
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("success", gin.H{"credit_note": dto.NewCreditNoteResponse(creditNote), "invoice": dto.NewInvoiceResponse(invoice)}))
+}
+
+func (i *invoiceControllerV1) ApplyCreditNote(c *gin.Context) {
+	ctx := context.Background()
+	var creditNoteID pgtype.UUID
+	if err := creditNoteID.Scan(c.Param("id")); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+	var req dto.ApplyCreditNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	creditNote, invoice, err := i.invoiceService.ApplyCreditNote(ctx, actorFromContext(c), creditNoteID, req)
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("success", gin.H{"credit_note": dto.NewCreditNoteResponse(creditNote), "invoice": dto.NewInvoiceResponse(invoice)}))
+}
+
+func (i *invoiceControllerV1) GetCreditNote(c *gin.Context) {
+	ctx := context.Background()
+	var creditNoteID pgtype.UUID
+	if err := creditNoteID.Scan(c.Param("id")); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	creditNote, applications, err := i.invoiceService.GetCreditNoteApplications(ctx, actorFromContext(c), creditNoteID)
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("success", gin.H{"credit_note": dto.NewCreditNoteResponse(creditNote), "applications": dto.NewCreditNoteApplicationResponses(applications, money.Currency(creditNote.Currency))}))
+}
+
+func (i *invoiceControllerV1) GetCreditNotePDF(c *gin.Context) {
+	ctx := context.Background()
+	var creditNoteID pgtype.UUID
+	if err := creditNoteID.Scan(c.Param("id")); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	filePath, err := i.invoiceService.GetCreditNotePDF(ctx, actorFromContext(c), creditNoteID)
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.FileAttachment(filePath, "credit-note-"+creditNoteID.String()+".pdf")
+}
+
+func (i *invoiceControllerV1) ListCreditNotes(c *gin.Context) {
+	ctx := context.Background()
+	var invoiceID pgtype.UUID
+	if err := invoiceID.Scan(c.Param("id")); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	creditNotes, page, err := i.invoiceService.ListCreditNotes(ctx, actorFromContext(c), invoiceID, pageRequestFromContext(c))
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
 		return
 	}
 
-	c.JSON(http.StatusOK, utils.SuccessResponse("success", "Invoice created and PDF generation enqueued"))
+	c.JSON(http.StatusOK, utils.PaginatedResponse("success", gin.H{"credit_notes": dto.NewCreditNoteResponses(creditNotes)}, page))
 }
 
 func NewInvoiceControllerV1() InvoiceControllerV1 {
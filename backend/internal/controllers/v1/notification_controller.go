@@ -0,0 +1,102 @@
+package v1_controllers
+
+import (
+	"backend/internal/dto"
+	"backend/internal/services"
+	"backend/utils"
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type NotificationControllerV1 interface {
+	ListNotifications(c *gin.Context)
+	MarkNotificationRead(c *gin.Context)
+	MarkAllNotificationsRead(c *gin.Context)
+	GetPreferences(c *gin.Context)
+	UpdatePreferences(c *gin.Context)
+}
+
+type notificationControllerV1 struct {
+	notificationService *services.NotificationService
+}
+
+func (n *notificationControllerV1) ListNotifications(c *gin.Context) {
+	ctx := context.Background()
+	unreadOnly := c.Query("unread") == "true"
+
+	notifications, unread, page, err := n.notificationService.ListNotifications(ctx, c.GetString("userID"), unreadOnly, pageRequestFromContext(c))
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.PaginatedResponse("success", gin.H{"notifications": notifications, "unread_count": unread}, page))
+}
+
+func (n *notificationControllerV1) MarkNotificationRead(c *gin.Context) {
+	ctx := context.Background()
+	var notificationID pgtype.UUID
+	if err := notificationID.Scan(c.Param("id")); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	notification, err := n.notificationService.MarkNotificationRead(ctx, c.GetString("userID"), notificationID)
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("success", gin.H{"notification": notification}))
+}
+
+func (n *notificationControllerV1) MarkAllNotificationsRead(c *gin.Context) {
+	ctx := context.Background()
+
+	count, err := n.notificationService.MarkAllNotificationsRead(ctx, c.GetString("userID"))
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("success", gin.H{"marked": count}))
+}
+
+func (n *notificationControllerV1) GetPreferences(c *gin.Context) {
+	ctx := context.Background()
+
+	prefs, err := n.notificationService.GetPreferences(ctx, c.GetString("userID"))
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("success", prefs))
+}
+
+func (n *notificationControllerV1) UpdatePreferences(c *gin.Context) {
+	ctx := context.Background()
+	prefsDto := dto.UpdateNotificationPreferencesDto{}
+
+	if err := c.ShouldBindJSON(&prefsDto); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	prefs, err := n.notificationService.UpdatePreferences(ctx, c.GetString("userID"), prefsDto)
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("success", prefs))
+}
+
+func NewNotificationControllerV1() NotificationControllerV1 {
+	return &notificationControllerV1{
+		notificationService: services.NewNotificationService(),
+	}
+}
@@ -20,6 +20,17 @@ type TenantControllerV1 interface {
 	GetUserTenants(c *gin.Context)
 	AllTenants(c *gin.Context)
 	AddUserToTenant(c *gin.Context)
+	SetAssignmentStrategy(c *gin.Context)
+	SetBusinessHours(c *gin.Context)
+	SetSLAPolicy(c *gin.Context)
+	SetEmailSender(c *gin.Context)
+	SetTaxRate(c *gin.Context)
+	ListEmailDeliveries(c *gin.Context)
+	ListSLAPolicies(c *gin.Context)
+	CreateEscalationRule(c *gin.Context)
+	ListEscalationRules(c *gin.Context)
+	SetEscalationRuleActive(c *gin.Context)
+	DeleteEscalationRule(c *gin.Context)
 }
 
 type tenantControllerV1 struct {
@@ -101,15 +112,13 @@ func (t *tenantControllerV1) GetUserTenants(c *gin.Context) {
 
 func (t *tenantControllerV1) AllTenants(c *gin.Context) {
 	ctx := context.Background()
-	page, _ := c.Get("page")
-	size, _ := c.Get("size")
-	tenants, err := t.tenantService.GetAllTenants(ctx, page.(int32), size.(int32))
+	tenants, page, err := t.tenantService.GetAllTenants(ctx, pageRequestFromContext(c))
 	if err != nil {
 		c.Error(err).SetType(gin.ErrorTypeAny)
 		return
 	}
 
-	c.JSON(200, utils.SuccessResponse("success", tenants))
+	c.JSON(200, utils.PaginatedResponse("success", tenants, page))
 }
 
 func (t *tenantControllerV1) AddUserToTenant(c *gin.Context) {
@@ -141,6 +150,179 @@ func (t *tenantControllerV1) AddUserToTenant(c *gin.Context) {
 	c.JSON(200, utils.SuccessResponse("success", "User added to tenant successfully"))
 }
 
+func (t *tenantControllerV1) SetAssignmentStrategy(c *gin.Context) {
+	ctx := context.Background()
+	strategyDto := dto.SetAssignmentStrategyDto{}
+
+	if err := c.ShouldBindJSON(&strategyDto); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	settings, err := t.tenantService.SetAssignmentStrategy(ctx, c.GetString("userID"), strategyDto)
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.JSON(200, utils.SuccessResponse("success", settings))
+}
+
+func (t *tenantControllerV1) SetBusinessHours(c *gin.Context) {
+	ctx := context.Background()
+	hoursDto := dto.SetBusinessHoursDto{}
+
+	if err := c.ShouldBindJSON(&hoursDto); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	settings, err := t.tenantService.SetBusinessHours(ctx, c.GetString("userID"), hoursDto)
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.JSON(200, utils.SuccessResponse("success", settings))
+}
+
+func (t *tenantControllerV1) SetSLAPolicy(c *gin.Context) {
+	ctx := context.Background()
+	policyDto := dto.SetSLAPolicyDto{}
+
+	if err := c.ShouldBindJSON(&policyDto); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	policy, err := t.tenantService.SetSLAPolicy(ctx, c.GetString("userID"), policyDto)
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.JSON(200, utils.SuccessResponse("success", policy))
+}
+
+func (t *tenantControllerV1) ListSLAPolicies(c *gin.Context) {
+	ctx := context.Background()
+
+	policies, err := t.tenantService.ListSLAPolicies(ctx, c.GetString("userID"), c.Param("id"))
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.JSON(200, utils.SuccessResponse("success", gin.H{"sla_policies": policies}))
+}
+
+func (t *tenantControllerV1) SetEmailSender(c *gin.Context) {
+	ctx := context.Background()
+	senderDto := dto.SetEmailSenderDto{}
+
+	if err := c.ShouldBindJSON(&senderDto); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	settings, err := t.tenantService.SetEmailSender(ctx, c.GetString("userID"), senderDto)
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.JSON(200, utils.SuccessResponse("success", settings))
+}
+
+func (t *tenantControllerV1) SetTaxRate(c *gin.Context) {
+	ctx := context.Background()
+	taxDto := dto.SetTaxRateDto{}
+
+	if err := c.ShouldBindJSON(&taxDto); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	settings, err := t.tenantService.SetTaxRate(ctx, c.GetString("userID"), taxDto)
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.JSON(200, utils.SuccessResponse("success", settings))
+}
+
+func (t *tenantControllerV1) ListEmailDeliveries(c *gin.Context) {
+	ctx := context.Background()
+
+	deliveries, page, err := t.tenantService.ListEmailDeliveries(ctx, c.GetString("userID"), c.Param("id"), pageRequestFromContext(c))
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.JSON(200, utils.PaginatedResponse("success", gin.H{"email_deliveries": deliveries}, page))
+}
+
+func (t *tenantControllerV1) CreateEscalationRule(c *gin.Context) {
+	ctx := context.Background()
+	ruleDto := dto.CreateEscalationRuleDto{}
+
+	if err := c.ShouldBindJSON(&ruleDto); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	rule, err := t.tenantService.CreateEscalationRule(ctx, c.GetString("userID"), ruleDto)
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.JSON(200, utils.SuccessResponse("success", rule))
+}
+
+func (t *tenantControllerV1) ListEscalationRules(c *gin.Context) {
+	ctx := context.Background()
+
+	rules, err := t.tenantService.ListEscalationRules(ctx, c.GetString("userID"), c.Param("id"))
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.JSON(200, utils.SuccessResponse("success", gin.H{"escalation_rules": rules}))
+}
+
+func (t *tenantControllerV1) SetEscalationRuleActive(c *gin.Context) {
+	ctx := context.Background()
+	activeDto := dto.SetEscalationRuleActiveDto{}
+
+	if err := c.ShouldBindJSON(&activeDto); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	rule, err := t.tenantService.SetEscalationRuleActive(ctx, c.GetString("userID"), c.Param("id"), c.Param("ruleId"), *activeDto.IsActive)
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.JSON(200, utils.SuccessResponse("success", rule))
+}
+
+func (t *tenantControllerV1) DeleteEscalationRule(c *gin.Context) {
+	ctx := context.Background()
+
+	if err := t.tenantService.DeleteEscalationRule(ctx, c.GetString("userID"), c.Param("id"), c.Param("ruleId")); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.JSON(200, utils.SuccessResponse("success", "Escalation Rule Deleted"))
+}
+
 func NewTenantControllerV1() TenantControllerV1 {
 	return &tenantControllerV1{
 		tenantService: services.NewTenantService(),
@@ -17,7 +17,14 @@ type TicketControllerV1 interface {
 	DeleteTicket(c *gin.Context)
 	ListTicketsByUserId(c *gin.Context)
 	ListCommentsByTicketID(c *gin.Context)
+	CreateComment(c *gin.Context)
 	ListTicketsByCustomerId(c *gin.Context)
+	TransitionTicket(c *gin.Context)
+	ListTicketTransitions(c *gin.Context)
+	ListTicketHistory(c *gin.Context)
+	ListTicketAssignments(c *gin.Context)
+	SearchTickets(c *gin.Context)
+	GetTicketPresence(c *gin.Context)
 }
 
 type ticketControllerV1 struct {
@@ -33,7 +40,7 @@ func (t *ticketControllerV1) CreateTicket(c *gin.Context) {
 		return
 	}
 
-	ticket, err := t.ticketService.CreateTicket(ctx, ticketDto)
+	ticket, err := t.ticketService.CreateTicket(ctx, actorFromContext(c), ticketDto)
 	if err != nil {
 		c.Error(err).SetType(gin.ErrorTypeAny)
 		return
@@ -58,7 +65,7 @@ func (t *ticketControllerV1) UpdateTicket(c *gin.Context) {
 		return
 	}
 
-	ticket, err := t.ticketService.UpdateTicket(ctx, ticketID, ticketDto)
+	ticket, err := t.ticketService.UpdateTicket(ctx, ticketID, actorFromContext(c), ticketDto)
 	if err != nil {
 		c.Error(err).SetType(gin.ErrorTypeAny)
 		return
@@ -76,7 +83,7 @@ func (t *ticketControllerV1) DeleteTicket(c *gin.Context) {
 		return
 	}
 
-	if err := t.ticketService.DeleteTicket(ctx, ticketID); err != nil {
+	if err := t.ticketService.DeleteTicket(ctx, ticketID, actorFromContext(c)); err != nil {
 		c.Error(err).SetType(gin.ErrorTypeAny)
 		return
 	}
@@ -85,45 +92,103 @@ func (t *ticketControllerV1) DeleteTicket(c *gin.Context) {
 }
 
 func (t *ticketControllerV1) ListTicketsByUserId(c *gin.Context) {
+	t.listTickets(c)
+}
+
+func (t *ticketControllerV1) ListTicketsByCustomerId(c *gin.Context) {
+	t.listTickets(c)
+}
+
+// listTickets serves both ticket list endpoints; the service scopes the list
+// by the caller's role.
+func (t *ticketControllerV1) listTickets(c *gin.Context) {
 	ctx := context.Background()
-	userIDVal, _ := c.Get("userID")
-	var userID pgtype.UUID
-	if err := userID.Scan(userIDVal); err != nil {
+	filterDto := dto.TicketFilterDto{}
+
+	if err := c.ShouldBindQuery(&filterDto); err != nil {
 		c.Error(err).SetType(gin.ErrorTypeBind)
 		return
 	}
 
-	page, _ := c.Get("page")
-	size, _ := c.Get("size")
+	tickets, page, err := t.ticketService.ListTickets(ctx, actorFromContext(c), filterDto, pageRequestFromContext(c))
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.JSON(200, utils.PaginatedResponse("success", gin.H{"tickets": tickets}, page))
+}
 
-	tickets, err := t.ticketService.ListTicketsByUserID(ctx, userID, page.(int32), size.(int32))
+func (t *ticketControllerV1) GetTicket(c *gin.Context) {
+	ctx := context.Background()
+	ticketIDParam := c.Param("id")
+	var ticketID pgtype.UUID
+	if err := ticketID.Scan(ticketIDParam); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	ticket, err := t.ticketService.GetTicketByID(ctx, actorFromContext(c), ticketID)
 	if err != nil {
 		c.Error(err).SetType(gin.ErrorTypeAny)
 		return
 	}
 
-	c.JSON(200, utils.SuccessResponse("success", gin.H{"tickets": tickets}))
+	c.JSON(200, utils.SuccessResponse("success", gin.H{"ticket": ticket}))
 }
 
-func (t *ticketControllerV1) ListTicketsByCustomerId(c *gin.Context) {
+func (t *ticketControllerV1) ListCommentsByTicketID(c *gin.Context) {
+	ctx := context.Background()
+	ticketIDParam := c.Param("id")
+	var ticketID pgtype.UUID
+	if err := ticketID.Scan(ticketIDParam); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	comments, page, err := t.ticketService.ListCommentsByTicketID(ctx, ticketID, actorFromContext(c), pageRequestFromContext(c))
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.JSON(200, utils.PaginatedResponse("success", gin.H{"comments": comments}, page))
+}
+
+func (t *ticketControllerV1) CreateComment(c *gin.Context) {
 	ctx := context.Background()
-	userIDVal, _ := c.Get("userID")
-	customerID := userIDVal.(string)
+	commentDto := dto.CreateCommentDto{}
 
-	page, _ := c.Get("page")
-	size, _ := c.Get("size")
+	if err := c.ShouldBindJSON(&commentDto); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
 
-	tickets, err := t.ticketService.ListTicketsByCustomerID(ctx, customerID, page.(int32), size.(int32))
+	ticketIDParam := c.Param("id")
+	var ticketID pgtype.UUID
+	if err := ticketID.Scan(ticketIDParam); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	commentID, err := t.ticketService.CreateComment(ctx, ticketID.String(), commentDto.Comment, commentDto.Visibility, actorFromContext(c))
 	if err != nil {
 		c.Error(err).SetType(gin.ErrorTypeAny)
 		return
 	}
 
-	c.JSON(200, utils.SuccessResponse("success", gin.H{"tickets": tickets}))
+	c.JSON(200, utils.SuccessResponse("success", gin.H{"comment_id": commentID}))
 }
 
-func (t *ticketControllerV1) GetTicket(c *gin.Context) {
+func (t *ticketControllerV1) TransitionTicket(c *gin.Context) {
 	ctx := context.Background()
+	transitionDto := dto.TransitionTicketDto{}
+
+	if err := c.ShouldBindJSON(&transitionDto); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
 	ticketIDParam := c.Param("id")
 	var ticketID pgtype.UUID
 	if err := ticketID.Scan(ticketIDParam); err != nil {
@@ -131,16 +196,92 @@ func (t *ticketControllerV1) GetTicket(c *gin.Context) {
 		return
 	}
 
-	ticket, err := t.ticketService.GetTicketByID(ctx, ticketID)
+	actor := actorFromContext(c)
+	ticket, err := t.ticketService.TransitionTicket(ctx, ticketID, actor, transitionDto.Status)
 	if err != nil {
 		c.Error(err).SetType(gin.ErrorTypeAny)
 		return
 	}
 
-	c.JSON(200, utils.SuccessResponse("success", gin.H{"ticket": ticket}))
+	c.JSON(200, utils.SuccessResponse("success", gin.H{
+		"ticket":              ticket,
+		"allowed_transitions": services.AllowedTicketTransitions(ticket.Status, actor.Role),
+	}))
 }
 
-func (t *ticketControllerV1) ListCommentsByTicketID(c *gin.Context) {
+func (t *ticketControllerV1) ListTicketTransitions(c *gin.Context) {
+	ctx := context.Background()
+	ticketIDParam := c.Param("id")
+	var ticketID pgtype.UUID
+	if err := ticketID.Scan(ticketIDParam); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	transitions, err := t.ticketService.ListTicketTransitions(ctx, ticketID, actorFromContext(c))
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.JSON(200, utils.SuccessResponse("success", gin.H{"allowed_transitions": transitions}))
+}
+
+func (t *ticketControllerV1) ListTicketHistory(c *gin.Context) {
+	ctx := context.Background()
+	ticketIDParam := c.Param("id")
+	var ticketID pgtype.UUID
+	if err := ticketID.Scan(ticketIDParam); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	events, page, err := t.ticketService.ListTicketHistory(ctx, ticketID, actorFromContext(c), pageRequestFromContext(c))
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.JSON(200, utils.PaginatedResponse("success", gin.H{"events": events}, page))
+}
+
+func (t *ticketControllerV1) ListTicketAssignments(c *gin.Context) {
+	ctx := context.Background()
+	ticketIDParam := c.Param("id")
+	var ticketID pgtype.UUID
+	if err := ticketID.Scan(ticketIDParam); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	assignments, err := t.ticketService.ListTicketAssignments(ctx, ticketID, actorFromContext(c))
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.JSON(200, utils.SuccessResponse("success", gin.H{"assignments": assignments}))
+}
+
+func (t *ticketControllerV1) SearchTickets(c *gin.Context) {
+	ctx := context.Background()
+	searchDto := dto.SearchTicketsDto{}
+
+	if err := c.ShouldBindQuery(&searchDto); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	results, page, err := t.ticketService.SearchTickets(ctx, actorFromContext(c), searchDto, pageRequestFromContext(c))
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.JSON(200, utils.PaginatedResponse("success", gin.H{"results": results}, page))
+}
+
+func (t *ticketControllerV1) GetTicketPresence(c *gin.Context) {
 	ctx := context.Background()
 	ticketIDParam := c.Param("id")
 	var ticketID pgtype.UUID
@@ -149,13 +290,13 @@ func (t *ticketControllerV1) ListCommentsByTicketID(c *gin.Context) {
 		return
 	}
 
-	comments, err := t.ticketService.ListCommentsByTicketID(ctx, ticketID, 50, 0)
+	users, err := t.ticketService.GetTicketPresence(ctx, ticketID, actorFromContext(c))
 	if err != nil {
 		c.Error(err).SetType(gin.ErrorTypeAny)
 		return
 	}
 
-	c.JSON(200, utils.SuccessResponse("success", gin.H{"comments": comments}))
+	c.JSON(200, utils.SuccessResponse("success", gin.H{"users": users}))
 }
 
 func NewTicketControllerV1() TicketControllerV1 {
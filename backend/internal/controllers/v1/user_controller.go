@@ -16,6 +16,7 @@ type UserControllerV1 interface {
 	GetTechnicians(c *gin.Context)
 	DeleteUser(c *gin.Context)
 	UpdateUser(c *gin.Context)
+	SetTechnicianSkills(c *gin.Context)
 }
 
 type userControllerV1 struct {
@@ -103,6 +104,24 @@ func (u *userControllerV1) DeleteUser(c *gin.Context) {
 	c.JSON(200, utils.SuccessResponse("success", "User deleted successfully"))
 }
 
+func (u *userControllerV1) SetTechnicianSkills(c *gin.Context) {
+	ctx := context.Background()
+	skillsDto := dto.SetTechnicianSkillsDto{}
+
+	if err := c.ShouldBindJSON(&skillsDto); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	skills, err := u.userService.SetTechnicianSkills(ctx, actorFromContext(c), c.Param("id"), skillsDto.Skills)
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeAny)
+		return
+	}
+
+	c.JSON(200, utils.SuccessResponse("success", gin.H{"skills": skills}))
+}
+
 // 4. Factory function (optional)
 func NewUserControllerV1() UserControllerV1 {
 	return &userControllerV1{
@@ -0,0 +1,121 @@
+package ws
+
+import (
+	"backend/internal/services"
+	"backend/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// EventError is sent only to the client whose message was rejected.
+const EventError = "error"
+
+const (
+	PresenceJoined = "joined"
+	PresenceLeft   = "left"
+)
+
+// TypingData is the data of a typing event.
+type TypingData struct {
+	Typing bool `json:"typing"`
+}
+
+// CommentData is the data of a comment event. Staff set visibility to
+// INTERNAL to write a note the customer does not see; the server adds the
+// saved comment's id.
+type CommentData struct {
+	ID         string `json:"id,omitempty"`
+	Visibility string `json:"visibility,omitempty"`
+}
+
+// ReadReceiptData is the data of a read_receipt event: the seq of the last
+// message the user has read.
+type ReadReceiptData struct {
+	Seq int64 `json:"seq"`
+}
+
+// PresenceData is the data of a presence event: who joined or left, and
+// everyone viewing the ticket afterwards.
+type PresenceData struct {
+	Event string                  `json:"event"`
+	User  services.PresenceUser   `json:"user"`
+	Users []services.PresenceUser `json:"users"`
+}
+
+type directMessage struct {
+	client  *Client
+	message *Message
+}
+
+// isEphemeral reports whether messages of the type are only relayed to the
+// clients connected at the time, without a seq or a place in the room log.
+func isEphemeral(eventType string) bool {
+	return eventType == services.RealtimeTyping || eventType == services.RealtimePresence
+}
+
+// handleMessage validates a message sent by the client and broadcasts it.
+// Comments are saved, and then pushed to the room by the ticket service like
+// comments posted over REST; typing indicators and read receipts are not
+// persisted.
+func (c *Client) handleMessage(msg *Message) error {
+	if isUserRoom(c.room) {
+		return errors.New("the notification stream does not accept messages")
+	}
+	msg.Visibility = ""
+	switch msg.Type {
+	case "", services.RealtimeComment:
+		if strings.TrimSpace(msg.Content) == "" {
+			return errors.New("comment content is required")
+		}
+		var data CommentData
+		if err := decodeData(msg.Data, &data); err != nil {
+			return err
+		}
+		ticketService := services.NewTicketService()
+		actor := services.Actor{ID: c.userId, Username: c.username, Role: c.userRole}
+		if _, err := ticketService.CreateComment(context.Background(), msg.Room, msg.Content, data.Visibility, actor); err != nil {
+			log.Printf("failed to save comment: %v", err)
+			var httpErr *utils.HTTPError
+			if errors.As(err, &httpErr) {
+				return httpErr
+			}
+			return errors.New("failed to save comment")
+		}
+		return nil
+	case services.RealtimeTyping:
+		var data TypingData
+		if err := decodeData(msg.Data, &data); err != nil {
+			return err
+		}
+		msg.Content = ""
+		msg.Data = data
+	case services.RealtimeReadReceipt:
+		var data ReadReceiptData
+		if err := decodeData(msg.Data, &data); err != nil || data.Seq <= 0 {
+			return errors.New("read_receipt requires data.seq")
+		}
+		msg.Content = ""
+		msg.Data = data
+	default:
+		return fmt.Errorf("unsupported message type %q", msg.Type)
+	}
+
+	c.hub.broadcast <- msg
+	return nil
+}
+
+// decodeData reads the loosely typed data of an incoming message into v.
+func decodeData(data interface{}, v interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return errors.New("invalid message data")
+	}
+	return nil
+}
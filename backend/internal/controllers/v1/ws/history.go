@@ -0,0 +1,212 @@
+package ws
+
+import (
+	"backend/internal/redis"
+	"backend/internal/services"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+const (
+	// roomLogSize is how many recent messages a room keeps for reconnecting
+	// clients to catch up from.
+	roomLogSize = 200
+	roomLogTTL  = 24 * time.Hour
+	// commentReplaySize is how many comments a newly joined client receives.
+	commentReplaySize = 50
+)
+
+// publishScript numbers a message with the room's next sequence number, keeps
+// it in the room log and publishes it, atomically so that messages reach
+// subscribers in sequence order. Entries are "<seq>\n<message json>".
+var publishScript = goredis.NewScript(`
+local seq = redis.call('INCR', KEYS[1])
+local entry = seq .. '\n' .. ARGV[1]
+redis.call('ZADD', KEYS[2], seq, entry)
+redis.call('ZREMRANGEBYRANK', KEYS[2], 0, -tonumber(ARGV[2]) - 1)
+redis.call('EXPIRE', KEYS[1], ARGV[3])
+redis.call('EXPIRE', KEYS[2], ARGV[3])
+redis.call('PUBLISH', KEYS[3], entry)
+return seq
+`)
+
+func roomSeqKey(room string) string {
+	return roomChannelPrefix + room + ":seq"
+}
+
+func roomLogKey(room string) string {
+	return roomChannelPrefix + room + ":log"
+}
+
+func decodeEntry(entry string) (*Message, error) {
+	seq, payload, ok := strings.Cut(entry, "\n")
+	if !ok {
+		return nil, fmt.Errorf("malformed room log entry")
+	}
+	var message Message
+	if err := json.Unmarshal([]byte(payload), &message); err != nil {
+		return nil, err
+	}
+	message.Seq, _ = strconv.ParseInt(seq, 10, 64)
+	return &message, nil
+}
+
+// publish numbers the message and sends it to every instance through Redis;
+// ephemeral messages skip the numbering and the room log. It reports false
+// when the message could not be published and should be delivered locally
+// instead.
+func (h *Hub) publish(message *Message) bool {
+	if h.pubsub == nil {
+		return false
+	}
+	payload, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("Hub - Failed to encode message: %v", err)
+		return false
+	}
+	if isEphemeral(message.Type) {
+		if err := redis.Rdb.Publish(redis.Ctx, roomChannel(message.Room), "0\n"+string(payload)).Err(); err != nil {
+			log.Printf("Hub - Failed to publish to room %s: %v", message.Room, err)
+			return false
+		}
+		return true
+	}
+	keys := []string{roomSeqKey(message.Room), roomLogKey(message.Room), roomChannel(message.Room)}
+	if err := publishScript.Run(redis.Ctx, redis.Rdb, keys, payload, roomLogSize, int(roomLogTTL.Seconds())).Err(); err != nil {
+		log.Printf("Hub - Failed to publish to room %s: %v", message.Room, err)
+		return false
+	}
+	return true
+}
+
+// currentSeq returns the sequence number of the room's latest message.
+func (h *Hub) currentSeq(room string) int64 {
+	if h.pubsub == nil {
+		return 0
+	}
+	seq, err := redis.Rdb.Get(redis.Ctx, roomSeqKey(room)).Int64()
+	if err != nil && err != goredis.Nil {
+		log.Printf("Hub - Failed to read sequence of room %s: %v", room, err)
+	}
+	return seq
+}
+
+// canReplaySince reports whether the room log still holds every message
+// after since.
+func (h *Hub) canReplaySince(room string, since int64) bool {
+	current := h.currentSeq(room)
+	if since > current {
+		return false
+	}
+	if since == current {
+		return true
+	}
+	oldest, err := redis.Rdb.ZRangeWithScores(redis.Ctx, roomLogKey(room), 0, 0).Result()
+	if err != nil || len(oldest) == 0 {
+		return false
+	}
+	return since >= int64(oldest[0].Score)-1
+}
+
+// history returns the logged messages of the room after since, oldest first.
+func (h *Hub) history(room string, since int64) []*Message {
+	if h.pubsub == nil {
+		return nil
+	}
+	entries, err := redis.Rdb.ZRangeByScore(redis.Ctx, roomLogKey(room), &goredis.ZRangeBy{
+		Min: "(" + strconv.FormatInt(since, 10),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		log.Printf("Hub - Failed to read history of room %s: %v", room, err)
+		return nil
+	}
+	messages := make([]*Message, 0, len(entries))
+	for _, entry := range entries {
+		message, err := decodeEntry(entry)
+		if err != nil {
+			log.Printf("Hub - Failed to decode history of room %s: %v", room, err)
+			continue
+		}
+		messages = append(messages, message)
+	}
+	return messages
+}
+
+// replay queues what the client missed before its first live message: the
+// prepared replay, then the logged messages after the client's since.
+func (h *Hub) replay(client *Client) {
+	messages := append(client.replay, h.history(client.room, client.since)...)
+	client.replay = nil
+	client.replayedSeq = client.since
+	for _, message := range messages {
+		if !client.canReceive(message) {
+			continue
+		}
+		select {
+		case client.send <- message:
+			client.replayedSeq = max(client.replayedSeq, message.Seq)
+		default:
+			log.Printf("Hub - Replay to room %s truncated", client.room)
+			return
+		}
+	}
+}
+
+// prepareReplay decides where the client's history starts. A reconnecting
+// client whose since is still in the room log resumes right after it; anyone
+// else gets the latest comments of the ticket, preceded by a replay.reset
+// message when their since could not be honoured.
+func (c *Client) prepareReplay(since int64, resume bool) {
+	if resume && c.hub.canReplaySince(c.room, since) {
+		c.since = since
+		return
+	}
+
+	// Read the sequence before the comments so no comment falls between them.
+	c.since = c.hub.currentSeq(c.room)
+	if resume {
+		c.replay = append(c.replay, &Message{
+			Type:      "replay.reset",
+			Room:      c.room,
+			CreatedAt: time.Now().Format(time.RFC3339),
+		})
+	}
+
+	if isUserRoom(c.room) {
+		return
+	}
+	var ticketID pgtype.UUID
+	if err := ticketID.Scan(c.room); err != nil {
+		return
+	}
+	actor := services.Actor{ID: c.userId, Username: c.username, Role: c.userRole}
+	comments, _, err := services.NewTicketService().ListCommentsByTicketID(context.Background(), ticketID, actor, services.PageRequest{Size: commentReplaySize})
+	if err != nil {
+		log.Printf("failed to load comment history: %v", err)
+		return
+	}
+	slices.Reverse(comments)
+	for _, comment := range comments {
+		c.replay = append(c.replay, &Message{
+			Type:       services.RealtimeComment,
+			Content:    comment.Comment,
+			Room:       c.room,
+			UserID:     comment.AuthorID.String(),
+			Username:   comment.Username.String,
+			UserRole:   comment.UserRole,
+			Visibility: comment.Visibility,
+			Data:       CommentData{ID: comment.ID.String(), Visibility: comment.Visibility},
+			CreatedAt:  comment.CreatedAt.Time.Format(time.RFC3339),
+		})
+	}
+}
@@ -1,46 +1,230 @@
 package ws
 
-import "fmt"
+import (
+	"backend/internal/redis"
+	"backend/internal/services"
+	"fmt"
+	"log"
+	"strings"
+	"time"
 
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// roomChannelPrefix namespaces the Redis pub/sub channel of each room.
+const roomChannelPrefix = "ws:room:"
+
+// userRoomPrefix marks the personal notification room of a user, as opposed
+// to ticket rooms, which are named by ticket id.
+const userRoomPrefix = "user:"
+
+// Hub tracks the clients connected to this instance. Broadcasts are numbered
+// per room, published to Redis and delivered back to every instance
+// subscribed to the room, so clients on other replicas receive them too. An
+// instance subscribes to a room's channel only while it has local clients in
+// that room. Without Redis the hub numbers and delivers messages locally.
 type Hub struct {
 	clients    map[string]map[*Client]bool
 	broadcast  chan *Message
+	deliver    chan *Message
 	register   chan *Client
 	unregister chan *Client
+	direct     chan directMessage
+	presence   chan presenceRequest
+	pubsub     *goredis.PubSub
+	// seqs numbers messages per room when there is no Redis.
+	seqs map[string]int64
 }
 
 func NewHub() *Hub {
-	return &Hub{
+	h := &Hub{
 		broadcast:  make(chan *Message),
+		deliver:    make(chan *Message, 256),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
+		direct:     make(chan directMessage),
+		presence:   make(chan presenceRequest),
 		clients:    make(map[string]map[*Client]bool),
+		seqs:       make(map[string]int64),
+	}
+	if redis.Rdb != nil {
+		h.pubsub = redis.Rdb.Subscribe(redis.Ctx)
 	}
+	return h
+}
+
+func roomChannel(room string) string {
+	return roomChannelPrefix + room
+}
+
+func userRoom(userID string) string {
+	return userRoomPrefix + userID
+}
+
+func isUserRoom(room string) bool {
+	return strings.HasPrefix(room, userRoomPrefix)
 }
 
 func (h *Hub) Run() {
+	if h.pubsub != nil {
+		go h.receive()
+	}
 	for {
 		select {
 		case client := <-h.register:
 			if h.clients[client.room] == nil {
 				h.clients[client.room] = make(map[*Client]bool)
+				h.subscribe(client.room)
 			}
 			h.clients[client.room][client] = true
+			h.replay(client)
+			h.trackPresence(client)
+			h.broadcastPresence(client, PresenceJoined)
 			fmt.Println("size of conn", len(h.clients[client.room]))
 		case client := <-h.unregister:
 			if _, ok := h.clients[client.room][client]; ok {
 				delete(h.clients[client.room], client)
 				close(client.send)
+				h.releaseRoom(client.room)
+				h.untrackPresence(client)
+				h.broadcastPresence(client, PresenceLeft)
 			}
 		case message := <-h.broadcast:
-			for client := range h.clients[message.Room] {
+			h.dispatch(message)
+		case direct := <-h.direct:
+			if h.clients[direct.client.room][direct.client] {
 				select {
-				case client.send <- message:
+				case direct.client.send <- direct.message:
 				default:
-					close(client.send)
-					delete(h.clients[message.Room], client)
 				}
 			}
+		case request := <-h.presence:
+			users, _ := h.roomPresence(request.room)
+			request.reply <- users
+		case message := <-h.deliver:
+			h.deliverLocal(message)
 		}
 	}
 }
+
+// dispatch publishes a message to every instance, falling back to local
+// delivery. Ephemeral messages are not numbered.
+func (h *Hub) dispatch(message *Message) {
+	if h.publish(message) {
+		return
+	}
+	if !isEphemeral(message.Type) {
+		h.seqs[message.Room]++
+		message.Seq = h.seqs[message.Room]
+	}
+	h.deliverLocal(message)
+}
+
+// sendTo delivers a message to a single client, such as an error in reply to
+// something it sent.
+func (h *Hub) sendTo(client *Client, message *Message) {
+	h.direct <- directMessage{client: client, message: message}
+}
+
+func (h *Hub) deliverLocal(message *Message) {
+	for client := range h.clients[message.Room] {
+		if message.Seq != 0 && message.Seq <= client.replayedSeq {
+			continue // already sent as part of the client's replay
+		}
+		if !client.canReceive(message) {
+			continue
+		}
+		select {
+		case client.send <- message:
+		default:
+			close(client.send)
+			delete(h.clients[message.Room], client)
+			h.untrackPresence(client)
+		}
+	}
+	h.releaseRoom(message.Room)
+}
+
+// releaseRoom drops the room and its subscription once it has no local
+// clients left.
+func (h *Hub) releaseRoom(room string) {
+	if clients, ok := h.clients[room]; ok && len(clients) == 0 {
+		delete(h.clients, room)
+		h.unsubscribe(room)
+	}
+}
+
+func (h *Hub) subscribe(room string) {
+	if h.pubsub == nil {
+		return
+	}
+	if err := h.pubsub.Subscribe(redis.Ctx, roomChannel(room)); err != nil {
+		log.Printf("Hub - Failed to subscribe to room %s: %v", room, err)
+	}
+}
+
+func (h *Hub) unsubscribe(room string) {
+	if h.pubsub == nil {
+		return
+	}
+	if err := h.pubsub.Unsubscribe(redis.Ctx, roomChannel(room)); err != nil {
+		log.Printf("Hub - Failed to unsubscribe from room %s: %v", room, err)
+	}
+}
+
+// receive hands messages published by any instance to the run loop.
+func (h *Hub) receive() {
+	for msg := range h.pubsub.Channel() {
+		message, err := decodeEntry(msg.Payload)
+		if err != nil {
+			log.Printf("Hub - Failed to decode message from %s: %v", msg.Channel, err)
+			continue
+		}
+		h.deliver <- message
+	}
+}
+
+// NotifyTicket pushes a server generated event to everyone in the ticket room.
+// Comments are sent in the shape clients post them in, and staff-only events
+// are withheld from the customer.
+func (h *Hub) NotifyTicket(ticketID, eventType string, data interface{}) {
+	message := &Message{
+		Type:      eventType,
+		Room:      ticketID,
+		Data:      data,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	switch event := data.(type) {
+	case services.CommentEvent:
+		message = commentMessage(ticketID, event)
+	case services.StaffOnlyEvent:
+		message.Data = event.Data
+		message.Visibility = services.CommentInternal
+	}
+	h.broadcast <- message
+}
+
+func commentMessage(ticketID string, comment services.CommentEvent) *Message {
+	return &Message{
+		Type:       services.RealtimeComment,
+		Content:    comment.Content,
+		Room:       ticketID,
+		UserID:     comment.AuthorID,
+		Username:   comment.Username,
+		UserRole:   comment.Role,
+		Visibility: comment.Visibility,
+		Data:       CommentData{ID: comment.ID, Visibility: comment.Visibility},
+		CreatedAt:  comment.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// NotifyUser pushes a notification to the user's personal stream on every
+// instance.
+func (h *Hub) NotifyUser(userID, eventType string, data interface{}) {
+	h.broadcast <- &Message{
+		Type:      eventType,
+		Room:      userRoom(userID),
+		Data:      data,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+}
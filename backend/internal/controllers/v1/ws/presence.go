@@ -0,0 +1,147 @@
+package ws
+
+import (
+	"backend/internal/redis"
+	"backend/internal/services"
+	"context"
+	"encoding/json"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// presenceTTL is how long a connection stays present without a heartbeat.
+// writePump refreshes it on every ping, so a live connection never lapses.
+const presenceTTL = 2 * pingPeriod
+
+// presenceEntry is a connection in a room's presence set. Connections are
+// tracked rather than users so a user with two tabs open stays present until
+// both close.
+type presenceEntry struct {
+	ConnID string `json:"conn_id"`
+	services.PresenceUser
+}
+
+type presenceRequest struct {
+	room  string
+	reply chan []services.PresenceUser
+}
+
+func roomPresenceKey(room string) string {
+	return roomChannelPrefix + room + ":presence"
+}
+
+func (c *Client) presenceUser() services.PresenceUser {
+	return services.PresenceUser{UserID: c.userId, Username: c.username, Role: c.userRole}
+}
+
+func (c *Client) presenceMember() string {
+	member, _ := json.Marshal(presenceEntry{ConnID: c.id, PresenceUser: c.presenceUser()})
+	return string(member)
+}
+
+// trackPresence adds or refreshes the client in the room's presence set,
+// scored by when the entry expires.
+func (h *Hub) trackPresence(client *Client) {
+	if h.pubsub == nil || isUserRoom(client.room) {
+		return
+	}
+	key := roomPresenceKey(client.room)
+	_, err := redis.Rdb.TxPipelined(redis.Ctx, func(pipe goredis.Pipeliner) error {
+		pipe.ZAdd(redis.Ctx, key, goredis.Z{
+			Score:  float64(time.Now().Add(presenceTTL).Unix()),
+			Member: client.presenceMember(),
+		})
+		pipe.Expire(redis.Ctx, key, presenceTTL)
+		return nil
+	})
+	if err != nil {
+		log.Printf("Hub - Failed to track presence in room %s: %v", client.room, err)
+	}
+}
+
+func (h *Hub) untrackPresence(client *Client) {
+	if h.pubsub == nil || isUserRoom(client.room) {
+		return
+	}
+	if err := redis.Rdb.ZRem(redis.Ctx, roomPresenceKey(client.room), client.presenceMember()).Err(); err != nil {
+		log.Printf("Hub - Failed to untrack presence in room %s: %v", client.room, err)
+	}
+}
+
+// roomPresence lists the users viewing a room on any instance, dropping
+// connections whose heartbeat lapsed. Without Redis it lists the local
+// clients and must only be called from Run.
+func (h *Hub) roomPresence(room string) ([]services.PresenceUser, error) {
+	byUser := map[string]services.PresenceUser{}
+	if h.pubsub == nil {
+		for client := range h.clients[room] {
+			byUser[client.userId] = client.presenceUser()
+		}
+	} else {
+		key := roomPresenceKey(room)
+		now := strconv.FormatInt(time.Now().Unix(), 10)
+		if err := redis.Rdb.ZRemRangeByScore(redis.Ctx, key, "-inf", "("+now).Err(); err != nil {
+			return nil, err
+		}
+		members, err := redis.Rdb.ZRange(redis.Ctx, key, 0, -1).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range members {
+			var entry presenceEntry
+			if err := json.Unmarshal([]byte(member), &entry); err != nil {
+				continue
+			}
+			byUser[entry.UserID] = entry.PresenceUser
+		}
+	}
+
+	users := make([]services.PresenceUser, 0, len(byUser))
+	for _, user := range byUser {
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Username < users[j].Username })
+	return users, nil
+}
+
+// broadcastPresence tells a ticket room who joined or left, with the
+// resulting presence list.
+func (h *Hub) broadcastPresence(client *Client, event string) {
+	if isUserRoom(client.room) {
+		return
+	}
+	users, err := h.roomPresence(client.room)
+	if err != nil {
+		log.Printf("Hub - Failed to list presence in room %s: %v", client.room, err)
+		return
+	}
+	h.dispatch(&Message{
+		Type: services.RealtimePresence,
+		Room: client.room,
+		Data: PresenceData{
+			Event: event,
+			User:  client.presenceUser(),
+			Users: users,
+		},
+		CreatedAt: time.Now().Format(time.RFC3339),
+	})
+}
+
+// TicketPresence lists who is viewing the ticket, for clients that are not
+// on the socket.
+func (h *Hub) TicketPresence(ctx context.Context, ticketID string) ([]services.PresenceUser, error) {
+	if h.pubsub != nil {
+		return h.roomPresence(ticketID)
+	}
+	request := presenceRequest{room: ticketID, reply: make(chan []services.PresenceUser, 1)}
+	select {
+	case h.presence <- request:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return <-request.reply, nil
+}
@@ -0,0 +1,87 @@
+package ws
+
+import (
+	"backend/utils"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ServeUserSSE streams the caller's personal notifications as Server-Sent
+// Events, for clients behind proxies that break WebSockets. It must run
+// behind AuthMiddleware. Each event carries the message seq as its id, so a
+// reconnecting EventSource resumes from Last-Event-ID.
+func ServeUserSSE(hub *Hub, c *gin.Context) {
+	sinceParam := c.GetHeader("Last-Event-ID")
+	if sinceParam == "" {
+		sinceParam = c.Query("since")
+	}
+	since, resume, err := parseSince(sinceParam)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, utils.ErrorResponse("error", err.Error()))
+		return
+	}
+
+	user := utils.EntityData{ID: c.GetString("userID"), Username: c.GetString("username"), Role: c.GetString("role")}
+	client := newClient(hub, userRoom(user.ID), user)
+	client.prepareReplay(since, resume)
+
+	// The stream outlives the server's write timeout.
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("SSE - Failed to clear write deadline: %v", err)
+	}
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	hub.register <- client
+	defer func() {
+		hub.unregister <- client
+	}()
+
+	ticker := time.NewTicker(pingPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case message, ok := <-client.send:
+			if !ok {
+				return
+			}
+			if err := writeEvent(c.Writer, message); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if _, err := fmt.Fprint(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
+
+func writeEvent(w gin.ResponseWriter, message *Message) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	if message.Seq > 0 {
+		if _, err := fmt.Fprintf(w, "id: %s\n", strconv.FormatInt(message.Seq, 10)); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", message.Type, data); err != nil {
+		return err
+	}
+	w.Flush()
+	return nil
+}
@@ -5,12 +5,16 @@ import (
 	"backend/utils"
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 )
 
@@ -21,15 +25,44 @@ const (
 	maxMessageSize = 512
 )
 
+// Application close codes sent when a connection is refused after the
+// handshake, mirroring the HTTP status of the failure.
+const (
+	CloseUnauthorized = 4401
+	CloseForbidden    = 4403
+	CloseNotFound     = 4404
+)
+
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
-	CheckOrigin: func(r *http.Request) bool {
+	CheckOrigin:     checkOrigin,
+}
+
+// checkOrigin allows requests without an Origin header (non-browser clients)
+// and origins listed in WS_ALLOWED_ORIGINS, a comma separated list that
+// defaults to the frontend dev server. "*" allows every origin.
+func checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
 		return true
-	},
+	}
+	allowed := os.Getenv("WS_ALLOWED_ORIGINS")
+	if allowed == "" {
+		allowed = "http://localhost:3000"
+	}
+	for _, candidate := range strings.Split(allowed, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.EqualFold(candidate, origin) {
+			return true
+		}
+	}
+	log.Printf("WebSocket origin rejected: %s", origin)
+	return false
 }
 
 type Client struct {
+	id       string
 	hub      *Hub
 	conn     *websocket.Conn
 	send     chan *Message
@@ -37,14 +70,34 @@ type Client struct {
 	userId   string
 	username string
 	userRole string
+
+	// replay is sent on registration, followed by the logged messages after
+	// since. Live messages numbered up to replayedSeq were part of it.
+	replay      []*Message
+	since       int64
+	replayedSeq int64
 }
 
+// Message is the envelope of every event on a ticket socket. Type tells the
+// client how to read Content and Data; see events.go.
 type Message struct {
-	Content   string `json:"content"`
-	Room      string `json:"room"`
-	Username  string `json:"username"`
-	UserRole  string `json:"userRole"`
-	CreatedAt string `json:"createdAt"`
+	Type     string `json:"type,omitempty"`
+	Seq      int64  `json:"seq,omitempty"`
+	Content  string `json:"content"`
+	Room     string `json:"room"`
+	UserID   string `json:"userId,omitempty"`
+	Username string `json:"username"`
+	UserRole string `json:"userRole"`
+	// Visibility is INTERNAL for messages only the tenant's staff receive.
+	Visibility string      `json:"visibility,omitempty"`
+	Data       interface{} `json:"data,omitempty"`
+	CreatedAt  string      `json:"createdAt"`
+}
+
+// canReceive reports whether the message may be delivered to the client:
+// internal messages never reach customers.
+func (c *Client) canReceive(message *Message) bool {
+	return message.Visibility != services.CommentInternal || c.userRole != "Customer"
 }
 
 func (c *Client) readPump() {
@@ -70,18 +123,20 @@ func (c *Client) readPump() {
 			continue
 		}
 		msg.Room = c.room
+		msg.UserID = c.userId
 		msg.Username = c.username
 		msg.UserRole = c.userRole
 		msg.CreatedAt = time.Now().Format(time.RFC3339)
 
-		// Save comment to DB
-		ticketService := services.NewTicketService()
-		_, err = ticketService.CreateComment(context.Background(), msg.Room, msg.Content, c.userId, msg.UserRole)
-		if err != nil {
-			log.Printf("failed to save comment: %v", err)
+		if err := c.handleMessage(&msg); err != nil {
+			c.hub.sendTo(c, &Message{
+				Type:      EventError,
+				Content:   err.Error(),
+				Room:      c.room,
+				CreatedAt: msg.CreatedAt,
+			})
+			continue
 		}
-
-		c.hub.broadcast <- &msg
 	}
 }
 
@@ -114,58 +169,127 @@ func (c *Client) writePump() {
 			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
 				return
 			}
+			c.hub.trackPresence(c)
 		}
 	}
 }
 
+// ServeWs joins the caller to a ticket room. Only the ticket's customer and
+// members of its tenant may join.
 func ServeWs(hub *Hub, c *gin.Context) {
-	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	serveClient(hub, c, func(claims *utils.Claims) (string, error) {
+		room := c.Param("ticketId")
+		actor := services.Actor{ID: claims.Data.ID, Username: claims.Data.Username, Role: claims.Data.Role}
+		return room, services.NewTicketService().AuthorizeTicketAccess(context.Background(), room, actor)
+	})
+}
+
+// ServeUserWs connects the caller to their personal notification stream.
+func ServeUserWs(hub *Hub, c *gin.Context) {
+	serveClient(hub, c, func(claims *utils.Claims) (string, error) {
+		return userRoom(claims.Data.ID), nil
+	})
+}
+
+// serveClient authenticates the request, lets authorize pick the room and
+// registers the upgraded connection in it.
+func serveClient(hub *Hub, c *gin.Context, authorize func(claims *utils.Claims) (string, error)) {
+	var room string
+	since, resume, sinceErr := parseSince(c.Query("since"))
+	claims, err := authenticate(c)
+	if err == nil && sinceErr != nil {
+		err = sinceErr
+	}
+	if err == nil {
+		room, err = authorize(claims)
+	}
+
+	conn, upgradeErr := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if upgradeErr != nil {
+		log.Println("WebSocket upgrade failed:", upgradeErr)
+		return
+	}
 	if err != nil {
-		log.Println("WebSocket upgrade failed:", err)
+		// Browsers cannot read the status of a failed handshake, so the
+		// refusal is sent as a close frame on the upgraded connection.
+		log.Printf("WebSocket join to room %s refused: %v", room, err)
+		rejectConn(conn, err)
 		return
 	}
 
-	// 1. Try to read from query param
-	tokenString := c.Query("token")
+	client := newClient(hub, room, claims.Data)
+	client.conn = conn
+	client.prepareReplay(since, resume)
+	client.hub.register <- client
+
+	go client.writePump()
+	go client.readPump()
+}
+
+func newClient(hub *Hub, room string, user utils.EntityData) *Client {
+	return &Client{
+		id:       uuid.NewString(),
+		hub:      hub,
+		send:     make(chan *Message, 256),
+		room:     room,
+		userId:   user.ID,
+		username: user.Username,
+		userRole: user.Role,
+	}
+}
+
+// parseSince reads the since query parameter of a reconnecting client, the
+// seq of the last message it received.
+func parseSince(value string) (int64, bool, error) {
+	if value == "" {
+		return 0, false, nil
+	}
+	since, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || since < 0 {
+		return 0, false, utils.NewHTTPError(http.StatusBadRequest, "since must be a message seq")
+	}
+	return since, true, nil
+}
 
-	// 2. Fallback: check Authorization header
+// authenticate reads the JWT from the token query parameter, falling back to
+// the Authorization header.
+func authenticate(c *gin.Context) (*utils.Claims, error) {
+	tokenString := c.Query("token")
 	if tokenString == "" {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			log.Println("Authorization header not found")
-			conn.Close()
-			return
+			return nil, utils.NewHTTPError(http.StatusUnauthorized, "authorization token is required")
 		}
 
 		tokenParts := strings.Split(authHeader, " ")
 		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-			log.Println("Invalid Authorization header format")
-			conn.Close()
-			return
+			return nil, utils.NewHTTPError(http.StatusUnauthorized, "invalid authorization header format")
 		}
 		tokenString = tokenParts[1]
 	}
 
-	// 3. Validate token
 	claims, err := utils.ValidateToken(tokenString)
 	if err != nil {
-		log.Println("Token invalid:", err)
-		conn.Close()
-		return
+		return nil, utils.NewHTTPError(http.StatusUnauthorized, "invalid token")
 	}
+	return claims, nil
+}
 
-	// 4. Register client
-	client := &Client{
-		hub:      hub,
-		conn:     conn,
-		send:     make(chan *Message, 256),
-		room:     c.Param("ticketId"),
-		userId:   claims.Data.ID,
-		username: claims.Data.Username,
-		userRole: claims.Data.Role,
+// rejectConn closes the connection with the close code matching err.
+func rejectConn(conn *websocket.Conn, err error) {
+	code, reason := websocket.CloseInternalServerErr, "internal error"
+	var httpErr *utils.HTTPError
+	if errors.As(err, &httpErr) {
+		reason = httpErr.Message
+		switch httpErr.Status {
+		case http.StatusUnauthorized:
+			code = CloseUnauthorized
+		case http.StatusForbidden:
+			code = CloseForbidden
+		case http.StatusNotFound:
+			code = CloseNotFound
+		}
 	}
-	client.hub.register <- client
-
-	go client.writePump()
-	go client.readPump()
+	conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(writeWait))
+	conn.Close()
 }
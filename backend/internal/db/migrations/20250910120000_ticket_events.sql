@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+CREATE TABLE ticket.ticket_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    ticket_id UUID NOT NULL,
+    tenant_id UUID NOT NULL REFERENCES tenant.tenants(id) ON DELETE CASCADE,
+    actor_id UUID,
+    actor_role VARCHAR(20) NOT NULL,
+    event_type VARCHAR(30) NOT NULL CHECK (event_type IN ('CREATED', 'UPDATED', 'ASSIGNED', 'STATUS_CHANGED', 'DELETED', 'COMMENTED')),
+    field VARCHAR(50),
+    old_value TEXT,
+    new_value TEXT,
+    created_at TIMESTAMPTZ DEFAULT timezone('UTC', now())
+);
+
+CREATE INDEX idx_ticket_events_ticket_id_created_at ON ticket.ticket_events (ticket_id, created_at DESC);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE IF EXISTS ticket.ticket_events;
+-- +goose StatementEnd
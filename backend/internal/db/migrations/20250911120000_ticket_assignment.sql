@@ -0,0 +1,45 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+ALTER TABLE ticket.tickets ADD COLUMN category VARCHAR(50);
+
+CREATE TABLE IF NOT EXISTS technician_skills (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    category VARCHAR(50) NOT NULL,
+    PRIMARY KEY (user_id, category)
+);
+
+CREATE TABLE IF NOT EXISTS tenant.tenant_settings (
+    tenant_id UUID PRIMARY KEY REFERENCES tenant.tenants(id) ON DELETE CASCADE,
+    assignment_strategy VARCHAR(30) NOT NULL DEFAULT 'RANDOM' CHECK (assignment_strategy IN ('RANDOM', 'ROUND_ROBIN', 'LEAST_OPEN', 'SKILL_MATCH')),
+    created_at TIMESTAMPTZ DEFAULT timezone('UTC', now()),
+    updated_at TIMESTAMPTZ DEFAULT timezone('UTC', now())
+);
+
+CREATE TRIGGER update_tenant_settings_timestamp
+BEFORE UPDATE ON tenant.tenant_settings
+FOR EACH ROW
+EXECUTE FUNCTION updated_at_column();
+
+CREATE TABLE ticket.ticket_assignments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    ticket_id UUID NOT NULL REFERENCES ticket.tickets(id) ON DELETE CASCADE,
+    technician_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    strategy VARCHAR(30) NOT NULL,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT timezone('UTC', now())
+);
+
+CREATE INDEX idx_ticket_assignments_ticket_id ON ticket.ticket_assignments (ticket_id, created_at DESC);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE IF EXISTS ticket.ticket_assignments;
+DROP TABLE IF EXISTS tenant.tenant_settings;
+DROP TABLE IF EXISTS technician_skills;
+ALTER TABLE ticket.tickets DROP COLUMN IF EXISTS category;
+-- +goose StatementEnd
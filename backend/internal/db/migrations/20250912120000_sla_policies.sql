@@ -0,0 +1,53 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+CREATE TABLE ticket.sla_policies (
+    tenant_id UUID NOT NULL REFERENCES tenant.tenants(id) ON DELETE CASCADE,
+    priority ticket.ticket_priority NOT NULL,
+    first_response_minutes INTEGER NOT NULL CHECK (first_response_minutes > 0),
+    resolution_minutes INTEGER NOT NULL CHECK (resolution_minutes > 0),
+    business_hours_only BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ DEFAULT timezone('UTC', now()),
+    updated_at TIMESTAMPTZ DEFAULT timezone('UTC', now()),
+    PRIMARY KEY (tenant_id, priority)
+);
+
+CREATE TRIGGER update_sla_policies_timestamp
+BEFORE UPDATE ON ticket.sla_policies
+FOR EACH ROW
+EXECUTE FUNCTION updated_at_column();
+
+ALTER TABLE tenant.tenant_settings
+    ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    ADD COLUMN business_hours_start SMALLINT NOT NULL DEFAULT 9 CHECK (business_hours_start BETWEEN 0 AND 23),
+    ADD COLUMN business_hours_end SMALLINT NOT NULL DEFAULT 17 CHECK (business_hours_end BETWEEN 1 AND 24),
+    ADD COLUMN business_days SMALLINT[] NOT NULL DEFAULT '{1,2,3,4,5}';
+
+ALTER TABLE ticket.tickets
+    ADD COLUMN first_response_due_at TIMESTAMPTZ,
+    ADD COLUMN resolution_due_at TIMESTAMPTZ,
+    ADD COLUMN first_responded_at TIMESTAMPTZ,
+    ADD COLUMN sla_status VARCHAR(20) CHECK (sla_status IN ('ON_TRACK', 'AT_RISK', 'BREACHED'));
+
+CREATE INDEX idx_tickets_sla_open ON ticket.tickets (resolution_due_at)
+WHERE status IN ('OPEN', 'IN_PROGRESS', 'REOPENED');
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX IF EXISTS ticket.idx_tickets_sla_open;
+ALTER TABLE ticket.tickets
+    DROP COLUMN IF EXISTS first_response_due_at,
+    DROP COLUMN IF EXISTS resolution_due_at,
+    DROP COLUMN IF EXISTS first_responded_at,
+    DROP COLUMN IF EXISTS sla_status;
+ALTER TABLE tenant.tenant_settings
+    DROP COLUMN IF EXISTS timezone,
+    DROP COLUMN IF EXISTS business_hours_start,
+    DROP COLUMN IF EXISTS business_hours_end,
+    DROP COLUMN IF EXISTS business_days;
+DROP TABLE IF EXISTS ticket.sla_policies;
+-- +goose StatementEnd
@@ -0,0 +1,53 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+CREATE TABLE ticket.escalation_rules (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES tenant.tenants(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    condition_type VARCHAR(30) NOT NULL CHECK (condition_type IN ('UNTOUCHED', 'REOPENED_COUNT')),
+    match_priority ticket.ticket_priority,
+    threshold INTEGER NOT NULL CHECK (threshold > 0),
+    set_priority ticket.ticket_priority,
+    reassign_to_admin BOOLEAN NOT NULL DEFAULT FALSE,
+    notify_admin BOOLEAN NOT NULL DEFAULT FALSE,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ DEFAULT timezone('UTC', now()),
+    updated_at TIMESTAMPTZ DEFAULT timezone('UTC', now()),
+    CHECK (set_priority IS NOT NULL OR reassign_to_admin OR notify_admin)
+);
+
+CREATE INDEX idx_escalation_rules_tenant_id ON ticket.escalation_rules (tenant_id);
+
+CREATE TRIGGER update_escalation_rules_timestamp
+BEFORE UPDATE ON ticket.escalation_rules
+FOR EACH ROW
+EXECUTE FUNCTION updated_at_column();
+
+-- A rule fires at most once per ticket.
+CREATE TABLE ticket.escalation_logs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    rule_id UUID NOT NULL REFERENCES ticket.escalation_rules(id) ON DELETE CASCADE,
+    ticket_id UUID NOT NULL REFERENCES ticket.tickets(id) ON DELETE CASCADE,
+    actions TEXT[] NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT timezone('UTC', now()),
+    UNIQUE (rule_id, ticket_id)
+);
+
+ALTER TABLE ticket.ticket_events DROP CONSTRAINT ticket_events_event_type_check;
+ALTER TABLE ticket.ticket_events ADD CONSTRAINT ticket_events_event_type_check
+    CHECK (event_type IN ('CREATED', 'UPDATED', 'ASSIGNED', 'STATUS_CHANGED', 'DELETED', 'COMMENTED', 'ESCALATED'));
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DELETE FROM ticket.ticket_events WHERE event_type = 'ESCALATED';
+ALTER TABLE ticket.ticket_events DROP CONSTRAINT ticket_events_event_type_check;
+ALTER TABLE ticket.ticket_events ADD CONSTRAINT ticket_events_event_type_check
+    CHECK (event_type IN ('CREATED', 'UPDATED', 'ASSIGNED', 'STATUS_CHANGED', 'DELETED', 'COMMENTED'));
+DROP TABLE IF EXISTS ticket.escalation_logs;
+DROP TABLE IF EXISTS ticket.escalation_rules;
+-- +goose StatementEnd
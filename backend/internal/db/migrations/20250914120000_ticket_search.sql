@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+-- Search document of a ticket; titles rank above descriptions. Queries must
+-- call this function so the index below is used.
+CREATE OR REPLACE FUNCTION ticket.search_document(title TEXT, description TEXT)
+RETURNS tsvector AS $$
+    SELECT setweight(to_tsvector('english', COALESCE(title, '')), 'A') ||
+           setweight(to_tsvector('english', COALESCE(description, '')), 'B');
+$$ LANGUAGE SQL IMMUTABLE;
+
+CREATE INDEX idx_tickets_search ON ticket.tickets
+USING GIN (ticket.search_document(title, description));
+
+CREATE INDEX idx_comments_search ON ticket.comments
+USING GIN (to_tsvector('english', comment));
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX IF EXISTS ticket.idx_comments_search;
+DROP INDEX IF EXISTS ticket.idx_tickets_search;
+DROP FUNCTION IF EXISTS ticket.search_document(TEXT, TEXT);
+-- +goose StatementEnd
@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+CREATE TABLE ticket.attachments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    ticket_id UUID NOT NULL REFERENCES ticket.tickets(id) ON DELETE CASCADE,
+    comment_id UUID REFERENCES ticket.comments(id) ON DELETE CASCADE,
+    uploader_type VARCHAR(20) NOT NULL CHECK (uploader_type IN ('USER', 'CUSTOMER')),
+    uploader_id UUID NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size_bytes BIGINT NOT NULL CHECK (size_bytes > 0),
+    storage_key TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ DEFAULT timezone('UTC', now())
+);
+
+CREATE INDEX idx_attachments_ticket_id_created_at ON ticket.attachments (ticket_id, created_at DESC);
+
+ALTER TABLE ticket.ticket_events DROP CONSTRAINT ticket_events_event_type_check;
+ALTER TABLE ticket.ticket_events ADD CONSTRAINT ticket_events_event_type_check
+    CHECK (event_type IN ('CREATED', 'UPDATED', 'ASSIGNED', 'STATUS_CHANGED', 'DELETED', 'COMMENTED', 'ESCALATED', 'ATTACHED'));
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DELETE FROM ticket.ticket_events WHERE event_type = 'ATTACHED';
+ALTER TABLE ticket.ticket_events DROP CONSTRAINT ticket_events_event_type_check;
+ALTER TABLE ticket.ticket_events ADD CONSTRAINT ticket_events_event_type_check
+    CHECK (event_type IN ('CREATED', 'UPDATED', 'ASSIGNED', 'STATUS_CHANGED', 'DELETED', 'COMMENTED', 'ESCALATED'));
+DROP TABLE IF EXISTS ticket.attachments;
+-- +goose StatementEnd
@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+ALTER TABLE tenant.tenant_settings
+    ADD COLUMN email_from_name VARCHAR(100),
+    ADD COLUMN email_from_address VARCHAR(100);
+
+CREATE TABLE IF NOT EXISTS email_deliveries (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID REFERENCES tenant.tenants(id) ON DELETE SET NULL,
+    ticket_id UUID REFERENCES ticket.tickets(id) ON DELETE SET NULL,
+    recipient VARCHAR(100) NOT NULL,
+    template VARCHAR(50) NOT NULL,
+    sender VARCHAR(200) NOT NULL DEFAULT '',
+    subject TEXT NOT NULL DEFAULT '',
+    message_id VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'QUEUED' CHECK (status IN ('QUEUED', 'SENT', 'FAILED')),
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    sent_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT timezone('UTC', now()),
+    updated_at TIMESTAMPTZ DEFAULT timezone('UTC', now())
+);
+
+CREATE INDEX idx_email_deliveries_tenant_id_created_at ON email_deliveries (tenant_id, created_at DESC);
+CREATE INDEX idx_email_deliveries_message_id ON email_deliveries (message_id) WHERE message_id <> '';
+
+CREATE TRIGGER update_email_deliveries_timestamp
+BEFORE UPDATE ON email_deliveries
+FOR EACH ROW
+EXECUTE FUNCTION updated_at_column();
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE IF EXISTS email_deliveries;
+ALTER TABLE tenant.tenant_settings
+    DROP COLUMN IF EXISTS email_from_name,
+    DROP COLUMN IF EXISTS email_from_address;
+-- +goose StatementEnd
@@ -0,0 +1,46 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+-- Users and customers without a row get every channel of every category.
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id UUID NOT NULL,
+    category VARCHAR(20) NOT NULL CHECK (category IN ('ASSIGNED', 'COMMENT', 'STATUS_CHANGE', 'SLA', 'INVOICE')),
+    in_app BOOLEAN NOT NULL DEFAULT TRUE,
+    email BOOLEAN NOT NULL DEFAULT TRUE,
+    websocket BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_at TIMESTAMPTZ DEFAULT timezone('UTC', now()),
+    PRIMARY KEY (user_id, category)
+);
+
+-- Quiet hours run from the start to the end hour in the user's timezone,
+-- wrapping past midnight when start is after end. Emails due in them are held
+-- back until they end and live websocket pushes are skipped; notifications
+-- still reach the in-app inbox.
+CREATE TABLE IF NOT EXISTS notification_settings (
+    user_id UUID PRIMARY KEY,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    quiet_hours_start SMALLINT CHECK (quiet_hours_start BETWEEN 0 AND 23),
+    quiet_hours_end SMALLINT CHECK (quiet_hours_end BETWEEN 0 AND 23),
+    updated_at TIMESTAMPTZ DEFAULT timezone('UTC', now()),
+    CHECK ((quiet_hours_start IS NULL) = (quiet_hours_end IS NULL))
+);
+
+CREATE TRIGGER update_notification_preferences_timestamp
+BEFORE UPDATE ON notification_preferences
+FOR EACH ROW
+EXECUTE FUNCTION updated_at_column();
+
+CREATE TRIGGER update_notification_settings_timestamp
+BEFORE UPDATE ON notification_settings
+FOR EACH ROW
+EXECUTE FUNCTION updated_at_column();
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE IF EXISTS notification_settings;
+DROP TABLE IF EXISTS notification_preferences;
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+-- Every email received by the inbound gateway, so redelivered messages are
+-- not processed twice and replies can be threaded by Message-ID.
+CREATE TABLE IF NOT EXISTS inbound_emails (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    message_id VARCHAR(255) NOT NULL UNIQUE,
+    tenant_id UUID REFERENCES tenant.tenants(id) ON DELETE SET NULL,
+    ticket_id UUID REFERENCES ticket.tickets(id) ON DELETE SET NULL,
+    comment_id UUID REFERENCES ticket.comments(id) ON DELETE SET NULL,
+    sender VARCHAR(100) NOT NULL,
+    subject TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL CHECK (status IN ('TICKET_CREATED', 'COMMENTED', 'IGNORED')),
+    reason TEXT,
+    created_at TIMESTAMPTZ DEFAULT timezone('UTC', now())
+);
+
+CREATE INDEX idx_inbound_emails_tenant_id_created_at ON inbound_emails (tenant_id, created_at DESC);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE IF EXISTS inbound_emails;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+-- Internal comments are notes between the tenant's staff, never shown to the
+-- ticket's customer.
+ALTER TABLE ticket.comments
+    ADD COLUMN visibility VARCHAR(10) NOT NULL DEFAULT 'PUBLIC'
+        CHECK (visibility IN ('PUBLIC', 'INTERNAL'));
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+ALTER TABLE ticket.comments DROP COLUMN IF EXISTS visibility;
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+-- The invoice queries soft delete, so invoices need the column they filter on.
+-- The PDF columns are filled in by the PDF job once the invoice is stored.
+ALTER TABLE invoice.invoices
+    ADD COLUMN deleted_at TIMESTAMPTZ DEFAULT NULL,
+    ADD COLUMN pdf_status VARCHAR(20) NOT NULL DEFAULT 'PENDING'
+        CHECK (pdf_status IN ('PENDING', 'GENERATED', 'FAILED')),
+    ADD COLUMN pdf_path TEXT,
+    ADD COLUMN pdf_error TEXT,
+    ADD COLUMN pdf_generated_at TIMESTAMPTZ;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+ALTER TABLE invoice.invoices
+    DROP COLUMN IF EXISTS pdf_generated_at,
+    DROP COLUMN IF EXISTS pdf_error,
+    DROP COLUMN IF EXISTS pdf_path,
+    DROP COLUMN IF EXISTS pdf_status,
+    DROP COLUMN IF EXISTS deleted_at;
+-- +goose StatementEnd
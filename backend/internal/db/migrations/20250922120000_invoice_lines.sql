@@ -0,0 +1,54 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+-- Lines keep the computed subtotal, tax and total alongside their inputs so
+-- an invoice reads back exactly as it was issued, whatever the tenant's tax
+-- rate is later changed to.
+CREATE TABLE invoice.invoice_lines (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    invoice_id UUID NOT NULL REFERENCES invoice.invoices(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    description VARCHAR(255) NOT NULL,
+    quantity NUMERIC(10,3) NOT NULL CHECK (quantity > 0),
+    unit_price NUMERIC(10,2) NOT NULL CHECK (unit_price >= 0),
+    tax_rate NUMERIC(5,2) NOT NULL DEFAULT 0 CHECK (tax_rate >= 0 AND tax_rate <= 100),
+    discount NUMERIC(10,2) NOT NULL DEFAULT 0 CHECK (discount >= 0),
+    subtotal NUMERIC(10,2) NOT NULL,
+    tax_amount NUMERIC(10,2) NOT NULL,
+    total NUMERIC(10,2) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT timezone('UTC', now()),
+    UNIQUE (invoice_id, position),
+    CHECK (discount <= subtotal)
+);
+
+-- amount stays the grand total, so the totals below only break it down.
+ALTER TABLE invoice.invoices
+    ADD COLUMN subtotal NUMERIC(10,2) NOT NULL DEFAULT 0,
+    ADD COLUMN discount_total NUMERIC(10,2) NOT NULL DEFAULT 0,
+    ADD COLUMN tax_total NUMERIC(10,2) NOT NULL DEFAULT 0;
+
+-- Invoices issued before lines existed become a single untaxed line.
+INSERT INTO invoice.invoice_lines (invoice_id, position, description, quantity, unit_price, subtotal, tax_amount, total)
+SELECT id, 1, 'Services', 1, amount, amount, 0, amount
+FROM invoice.invoices;
+
+UPDATE invoice.invoices SET subtotal = amount;
+
+ALTER TABLE tenant.tenant_settings
+    ADD COLUMN tax_rate NUMERIC(5,2) NOT NULL DEFAULT 0
+        CHECK (tax_rate >= 0 AND tax_rate <= 100);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+ALTER TABLE tenant.tenant_settings
+    DROP COLUMN IF EXISTS tax_rate;
+ALTER TABLE invoice.invoices
+    DROP COLUMN IF EXISTS tax_total,
+    DROP COLUMN IF EXISTS discount_total,
+    DROP COLUMN IF EXISTS subtotal;
+DROP TABLE IF EXISTS invoice.invoice_lines;
+-- +goose StatementEnd
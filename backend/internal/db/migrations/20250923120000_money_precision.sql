@@ -0,0 +1,46 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+-- Amounts are kept to the minor unit of their currency, which is three
+-- decimal places for currencies such as KWD and four for CLF. Quantities and
+-- tax rates keep their own precision.
+ALTER TABLE invoice.invoices
+    ALTER COLUMN amount TYPE NUMERIC(19,4),
+    ALTER COLUMN subtotal TYPE NUMERIC(19,4),
+    ALTER COLUMN discount_total TYPE NUMERIC(19,4),
+    ALTER COLUMN tax_total TYPE NUMERIC(19,4);
+
+ALTER TABLE invoice.invoice_lines
+    ALTER COLUMN unit_price TYPE NUMERIC(19,4),
+    ALTER COLUMN discount TYPE NUMERIC(19,4),
+    ALTER COLUMN subtotal TYPE NUMERIC(19,4),
+    ALTER COLUMN tax_amount TYPE NUMERIC(19,4),
+    ALTER COLUMN total TYPE NUMERIC(19,4);
+
+ALTER TABLE invoice.payments
+    ALTER COLUMN amount TYPE NUMERIC(19,4);
+
+UPDATE invoice.invoices SET currency = upper(currency) WHERE currency <> upper(currency);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+ALTER TABLE invoice.payments
+    ALTER COLUMN amount TYPE NUMERIC(10,2);
+
+ALTER TABLE invoice.invoice_lines
+    ALTER COLUMN total TYPE NUMERIC(10,2),
+    ALTER COLUMN tax_amount TYPE NUMERIC(10,2),
+    ALTER COLUMN subtotal TYPE NUMERIC(10,2),
+    ALTER COLUMN discount TYPE NUMERIC(10,2),
+    ALTER COLUMN unit_price TYPE NUMERIC(10,2);
+
+ALTER TABLE invoice.invoices
+    ALTER COLUMN tax_total TYPE NUMERIC(10,2),
+    ALTER COLUMN discount_total TYPE NUMERIC(10,2),
+    ALTER COLUMN subtotal TYPE NUMERIC(10,2),
+    ALTER COLUMN amount TYPE NUMERIC(10,2);
+-- +goose StatementEnd
@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+-- amount_paid is the sum of the invoice's completed payments. It is kept in
+-- step with them in the transaction that records or completes a payment, so
+-- balance_due can be read with the invoice.
+ALTER TABLE invoice.invoices
+    ADD COLUMN amount_paid NUMERIC(19,4) NOT NULL DEFAULT 0,
+    ADD COLUMN balance_due NUMERIC(19,4) GENERATED ALWAYS AS (amount - amount_paid) STORED;
+
+UPDATE invoice.invoices AS i
+SET amount_paid = p.completed
+FROM (
+    SELECT invoice_id, SUM(amount) AS completed
+    FROM invoice.payments
+    WHERE status = 'COMPLETED'
+    GROUP BY invoice_id
+) AS p
+WHERE p.invoice_id = i.id;
+
+CREATE INDEX idx_payments_invoice_id_created_at ON invoice.payments (invoice_id, created_at DESC, id DESC);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX IF EXISTS invoice.idx_payments_invoice_id_created_at;
+ALTER TABLE invoice.invoices
+    DROP COLUMN IF EXISTS balance_due,
+    DROP COLUMN IF EXISTS amount_paid;
+-- +goose StatementEnd
@@ -0,0 +1,113 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+ALTER TYPE invoice.invoice_status ADD VALUE IF NOT EXISTS 'PARTIALLY_REFUNDED';
+ALTER TYPE invoice.invoice_status ADD VALUE IF NOT EXISTS 'REFUNDED';
+ALTER TYPE invoice.invoice_status ADD VALUE IF NOT EXISTS 'CREDITED';
+
+-- Refunds and credit notes are numbered per tenant, each in its own series.
+CREATE TABLE invoice.document_numbers (
+    tenant_id UUID NOT NULL REFERENCES tenant.tenants(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('REFUND', 'CREDIT_NOTE')),
+    last_number INTEGER NOT NULL,
+    PRIMARY KEY (tenant_id, kind)
+);
+
+CREATE TABLE invoice.refunds (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES tenant.tenants(id) ON DELETE CASCADE,
+    number VARCHAR(20) NOT NULL,
+    payment_id UUID NOT NULL REFERENCES invoice.payments(id),
+    invoice_id UUID NOT NULL REFERENCES invoice.invoices(id),
+    amount NUMERIC(19,4) NOT NULL CHECK (amount > 0),
+    reason TEXT,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    pdf_status VARCHAR(20) NOT NULL DEFAULT 'PENDING'
+        CHECK (pdf_status IN ('PENDING', 'GENERATED', 'FAILED')),
+    pdf_path TEXT,
+    pdf_error TEXT,
+    pdf_generated_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT timezone('UTC', now()),
+    UNIQUE (tenant_id, number)
+);
+
+CREATE INDEX idx_refunds_invoice_id_created_at ON invoice.refunds (invoice_id, created_at DESC, id DESC);
+CREATE INDEX idx_refunds_payment_id ON invoice.refunds (payment_id);
+
+-- A credit note is issued against an invoice and can be applied to that
+-- invoice or to later ones of the same customer, in the same currency, until
+-- it is used up.
+CREATE TABLE invoice.credit_notes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES tenant.tenants(id) ON DELETE CASCADE,
+    number VARCHAR(20) NOT NULL,
+    invoice_id UUID NOT NULL REFERENCES invoice.invoices(id),
+    customer_id UUID REFERENCES customers(id) ON DELETE SET NULL,
+    amount NUMERIC(19,4) NOT NULL CHECK (amount > 0),
+    amount_applied NUMERIC(19,4) NOT NULL DEFAULT 0,
+    amount_remaining NUMERIC(19,4) GENERATED ALWAYS AS (amount - amount_applied) STORED,
+    currency VARCHAR(3) NOT NULL,
+    reason TEXT,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    pdf_status VARCHAR(20) NOT NULL DEFAULT 'PENDING'
+        CHECK (pdf_status IN ('PENDING', 'GENERATED', 'FAILED')),
+    pdf_path TEXT,
+    pdf_error TEXT,
+    pdf_generated_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT timezone('UTC', now()),
+    UNIQUE (tenant_id, number),
+    CHECK (amount_applied >= 0 AND amount_applied <= amount)
+);
+
+CREATE INDEX idx_credit_notes_invoice_id_created_at ON invoice.credit_notes (invoice_id, created_at DESC, id DESC);
+
+CREATE TABLE invoice.credit_note_applications (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    credit_note_id UUID NOT NULL REFERENCES invoice.credit_notes(id) ON DELETE CASCADE,
+    invoice_id UUID NOT NULL REFERENCES invoice.invoices(id),
+    amount NUMERIC(19,4) NOT NULL CHECK (amount > 0),
+    created_at TIMESTAMPTZ DEFAULT timezone('UTC', now())
+);
+
+CREATE INDEX idx_credit_note_applications_invoice_id ON invoice.credit_note_applications (invoice_id);
+
+-- Credit applied to an invoice lowers what is due on it; refunds do not, as
+-- only settled invoices are refunded.
+ALTER TABLE invoice.invoices
+    DROP COLUMN balance_due,
+    ADD COLUMN amount_credited NUMERIC(19,4) NOT NULL DEFAULT 0,
+    ADD COLUMN amount_refunded NUMERIC(19,4) NOT NULL DEFAULT 0;
+
+ALTER TABLE invoice.invoices
+    ADD COLUMN balance_due NUMERIC(19,4) GENERATED ALWAYS AS (amount - amount_paid - amount_credited) STORED;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+ALTER TABLE invoice.invoices
+    DROP COLUMN balance_due,
+    DROP COLUMN IF EXISTS amount_refunded,
+    DROP COLUMN IF EXISTS amount_credited;
+
+ALTER TABLE invoice.invoices
+    ADD COLUMN balance_due NUMERIC(19,4) GENERATED ALWAYS AS (amount - amount_paid) STORED;
+
+DROP TABLE IF EXISTS invoice.credit_note_applications;
+DROP TABLE IF EXISTS invoice.credit_notes;
+DROP TABLE IF EXISTS invoice.refunds;
+DROP TABLE IF EXISTS invoice.document_numbers;
+
+-- Enum values cannot be dropped, so the type is rebuilt without them.
+ALTER TABLE invoice.invoices ALTER COLUMN status DROP DEFAULT;
+UPDATE invoice.invoices SET status = 'PAID' WHERE status IN ('PARTIALLY_REFUNDED', 'REFUNDED');
+UPDATE invoice.invoices SET status = 'CANCELLED' WHERE status = 'CREDITED';
+ALTER TYPE invoice.invoice_status RENAME TO invoice_status_old;
+CREATE TYPE invoice.invoice_status AS ENUM ('PENDING', 'PAID', 'CANCELLED');
+ALTER TABLE invoice.invoices
+    ALTER COLUMN status TYPE invoice.invoice_status USING status::text::invoice.invoice_status,
+    ALTER COLUMN status SET DEFAULT 'PENDING';
+DROP TYPE invoice.invoice_status_old;
+-- +goose StatementEnd
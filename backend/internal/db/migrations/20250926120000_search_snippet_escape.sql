@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+-- Escapes text for HTML. Search snippets wrap matches in <mark> tags, so the
+-- ticket and comment text around them is escaped first and the snippets are
+-- safe to render as HTML.
+CREATE OR REPLACE FUNCTION ticket.html_escape(value TEXT)
+RETURNS TEXT AS $$
+    SELECT replace(replace(replace(replace(replace(value,
+        '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;');
+$$ LANGUAGE SQL IMMUTABLE;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP FUNCTION IF EXISTS ticket.html_escape(TEXT);
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+-- An inbound email is recorded as PROCESSING before it is acted on, so a
+-- redelivery of the same message finds it claimed instead of opening a
+-- second ticket.
+ALTER TABLE inbound_emails DROP CONSTRAINT IF EXISTS inbound_emails_status_check;
+ALTER TABLE inbound_emails ADD CONSTRAINT inbound_emails_status_check
+    CHECK (status IN ('PROCESSING', 'TICKET_CREATED', 'COMMENTED', 'IGNORED'));
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DELETE FROM inbound_emails WHERE status = 'PROCESSING';
+ALTER TABLE inbound_emails DROP CONSTRAINT IF EXISTS inbound_emails_status_check;
+ALTER TABLE inbound_emails ADD CONSTRAINT inbound_emails_status_check
+    CHECK (status IN ('TICKET_CREATED', 'COMMENTED', 'IGNORED'));
+-- +goose StatementEnd
@@ -0,0 +1,34 @@
+-- name: CreateAttachment :one
+INSERT INTO ticket.attachments (
+    id,
+    ticket_id,
+    comment_id,
+    uploader_type,
+    uploader_id,
+    file_name,
+    content_type,
+    size_bytes,
+    storage_key
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+RETURNING *;
+
+-- name: GetAttachmentByID :one
+SELECT * FROM ticket.attachments
+WHERE id = $1 AND ticket_id = $2;
+
+-- name: ListAttachmentsByTicketID :many
+SELECT a.* FROM ticket.attachments AS a
+LEFT JOIN ticket.comments AS c ON c.id = a.comment_id
+WHERE a.ticket_id = sqlc.arg(ticket_id)
+  AND (sqlc.arg(include_internal)::boolean OR c.visibility IS DISTINCT FROM 'INTERNAL')
+ORDER BY a.created_at DESC, a.id DESC;
+
+-- name: ListAttachmentKeysByTicketID :many
+SELECT storage_key FROM ticket.attachments
+WHERE ticket_id = $1;
+
+-- name: DeleteAttachment :exec
+DELETE FROM ticket.attachments
+WHERE id = $1;
@@ -0,0 +1,82 @@
+-- name: CreateCreditNote :one
+INSERT INTO invoice.credit_notes (
+    tenant_id,
+    number,
+    invoice_id,
+    customer_id,
+    amount,
+    currency,
+    reason,
+    created_by
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+RETURNING *;
+
+-- name: GetCreditNoteByID :one
+SELECT * FROM invoice.credit_notes
+WHERE id = $1;
+
+-- name: GetCreditNoteByIDForUpdate :one
+SELECT * FROM invoice.credit_notes
+WHERE id = $1
+FOR UPDATE;
+
+-- name: SumCreditNotesByInvoiceID :one
+SELECT COALESCE(SUM(amount), 0)::numeric AS credited
+FROM invoice.credit_notes
+WHERE invoice_id = $1;
+
+-- name: AddCreditNoteApplied :one
+UPDATE invoice.credit_notes
+SET amount_applied = amount_applied + sqlc.arg(amount)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: CreateCreditNoteApplication :one
+INSERT INTO invoice.credit_note_applications (
+    credit_note_id,
+    invoice_id,
+    amount
+) VALUES (
+    $1, $2, $3
+)
+RETURNING *;
+
+-- name: ListCreditNoteApplications :many
+SELECT * FROM invoice.credit_note_applications
+WHERE credit_note_id = $1
+ORDER BY created_at, id;
+
+-- name: ListCreditNotesByInvoiceID :many
+SELECT * FROM invoice.credit_notes
+WHERE invoice_id = sqlc.arg(invoice_id)
+  AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (created_at, id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(size);
+
+-- name: CountCreditNotesByInvoiceID :one
+SELECT COUNT(*) FROM invoice.credit_notes
+WHERE invoice_id = $1;
+
+-- name: GetCreditNoteDocument :one
+SELECT
+    cn.id, cn.number, cn.invoice_id, cn.amount, cn.currency, cn.reason, cn.created_at,
+    tn.tenant_name,
+    tn.email AS tenant_email,
+    c.first_name AS customer_first_name,
+    c.last_name AS customer_last_name,
+    c.email AS customer_email
+FROM invoice.credit_notes AS cn
+JOIN tenant.tenants AS tn ON cn.tenant_id = tn.id
+LEFT JOIN customers AS c ON cn.customer_id = c.id
+WHERE cn.id = $1;
+
+-- name: RecordCreditNotePDF :exec
+UPDATE invoice.credit_notes
+SET
+    pdf_status = sqlc.arg(pdf_status),
+    pdf_path = sqlc.narg(pdf_path),
+    pdf_error = sqlc.narg(pdf_error),
+    pdf_generated_at = CASE WHEN sqlc.arg(pdf_status) = 'GENERATED' THEN timezone('UTC', now()) ELSE pdf_generated_at END
+WHERE id = sqlc.arg(id);
@@ -0,0 +1,45 @@
+-- name: CreateEmailDelivery :one
+INSERT INTO email_deliveries (
+    tenant_id,
+    ticket_id,
+    recipient,
+    template
+) VALUES (
+    $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: GetEmailDeliveryByID :one
+SELECT * FROM email_deliveries
+WHERE id = $1;
+
+-- name: RecordEmailDeliveryAttempt :one
+UPDATE email_deliveries
+SET
+    status = sqlc.arg(status),
+    sender = sqlc.arg(sender),
+    subject = sqlc.arg(subject),
+    message_id = sqlc.arg(message_id),
+    attempts = attempts + 1,
+    last_error = sqlc.narg(last_error),
+    sent_at = CASE WHEN sqlc.arg(status) = 'SENT' THEN timezone('UTC', now()) ELSE sent_at END
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: GetTenantEmailSender :one
+SELECT
+    t.tenant_name,
+    t.email,
+    s.email_from_name,
+    s.email_from_address
+FROM tenant.tenants AS t
+LEFT JOIN tenant.tenant_settings AS s ON s.tenant_id = t.id
+WHERE t.id = $1;
+
+-- name: ListEmailDeliveriesByTenantID :many
+SELECT *
+FROM email_deliveries
+WHERE tenant_id = sqlc.arg(tenant_id)
+  AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (created_at, id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(size);
@@ -0,0 +1,78 @@
+-- name: CreateEscalationRule :one
+INSERT INTO ticket.escalation_rules (
+    tenant_id,
+    name,
+    condition_type,
+    match_priority,
+    threshold,
+    set_priority,
+    reassign_to_admin,
+    notify_admin
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+RETURNING *;
+
+-- name: ListEscalationRulesByTenantID :many
+SELECT * FROM ticket.escalation_rules
+WHERE tenant_id = $1
+ORDER BY created_at;
+
+-- name: ListActiveEscalationRules :many
+SELECT * FROM ticket.escalation_rules
+WHERE is_active = TRUE
+ORDER BY tenant_id, created_at;
+
+-- name: SetEscalationRuleActive :one
+UPDATE ticket.escalation_rules
+SET is_active = $3
+WHERE id = $1 AND tenant_id = $2
+RETURNING *;
+
+-- name: DeleteEscalationRule :exec
+DELETE FROM ticket.escalation_rules
+WHERE id = $1 AND tenant_id = $2;
+
+-- name: ListUntouchedTicketsForRule :many
+SELECT t.* FROM ticket.tickets AS t
+WHERE t.tenant_id = sqlc.arg(tenant_id)
+  AND t.status IN ('OPEN', 'IN_PROGRESS', 'REOPENED')
+  AND (sqlc.narg(match_priority)::ticket.ticket_priority IS NULL OR t.priority = sqlc.narg(match_priority))
+  AND COALESCE(
+        (SELECT MAX(e.created_at) FROM ticket.ticket_events AS e WHERE e.ticket_id = t.id AND e.actor_id IS NOT NULL),
+        t.created_at
+      ) < timezone('UTC', now()) - make_interval(mins => sqlc.arg(minutes)::int)
+  AND NOT EXISTS (
+        SELECT 1 FROM ticket.escalation_logs AS l WHERE l.rule_id = sqlc.arg(rule_id) AND l.ticket_id = t.id
+      );
+
+-- name: ListReopenedTicketsForRule :many
+SELECT t.* FROM ticket.tickets AS t
+WHERE t.tenant_id = sqlc.arg(tenant_id)
+  AND t.status IN ('OPEN', 'IN_PROGRESS', 'REOPENED')
+  AND (sqlc.narg(match_priority)::ticket.ticket_priority IS NULL OR t.priority = sqlc.narg(match_priority))
+  AND (
+        SELECT COUNT(*) FROM ticket.ticket_events AS e
+        WHERE e.ticket_id = t.id AND e.field = 'status' AND e.new_value = 'REOPENED'
+      ) >= sqlc.arg(reopen_count)::int
+  AND NOT EXISTS (
+        SELECT 1 FROM ticket.escalation_logs AS l WHERE l.rule_id = sqlc.arg(rule_id) AND l.ticket_id = t.id
+      );
+
+-- name: CreateEscalationLog :execrows
+INSERT INTO ticket.escalation_logs (
+    rule_id,
+    ticket_id,
+    actions
+) VALUES (
+    $1, $2, $3
+)
+ON CONFLICT (rule_id, ticket_id) DO NOTHING;
+
+-- name: EscalateTicket :one
+UPDATE ticket.tickets
+SET
+    priority = COALESCE(sqlc.narg(priority)::ticket.ticket_priority, priority),
+    assigned_to = COALESCE(sqlc.narg(assigned_to), assigned_to)
+WHERE id = sqlc.arg(id)
+RETURNING *;
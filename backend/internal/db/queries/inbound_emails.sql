@@ -0,0 +1,39 @@
+-- name: ClaimInboundEmail :one
+INSERT INTO inbound_emails (
+    message_id,
+    sender,
+    subject,
+    status
+) VALUES (
+    $1, $2, $3, 'PROCESSING'
+)
+ON CONFLICT (message_id) DO NOTHING
+RETURNING *;
+
+-- name: CompleteInboundEmail :one
+UPDATE inbound_emails
+SET
+    tenant_id = $2,
+    ticket_id = $3,
+    comment_id = $4,
+    status = $5,
+    reason = $6
+WHERE id = $1
+RETURNING *;
+
+-- name: ReleaseInboundEmail :exec
+DELETE FROM inbound_emails
+WHERE id = $1 AND status = 'PROCESSING';
+
+-- name: GetInboundEmailByMessageID :one
+SELECT * FROM inbound_emails
+WHERE message_id = $1;
+
+-- name: GetTicketIDByMessageID :one
+SELECT ticket_id::uuid AS ticket_id
+FROM (
+    SELECT ticket_id FROM email_deliveries WHERE message_id = sqlc.arg(message_id) AND ticket_id IS NOT NULL
+    UNION ALL
+    SELECT ticket_id FROM inbound_emails WHERE message_id = sqlc.arg(message_id) AND ticket_id IS NOT NULL
+) AS messages
+LIMIT 1;
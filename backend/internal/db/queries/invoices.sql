@@ -4,16 +4,71 @@ INSERT INTO invoice.invoices (
     amount,
     currency,
     status,
-    due_date
+    due_date,
+    subtotal,
+    discount_total,
+    tax_total
 ) VALUES (
-    $1, $2, $3, $4, $5
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+RETURNING *;
+
+-- name: CreateInvoiceLine :one
+INSERT INTO invoice.invoice_lines (
+    invoice_id,
+    position,
+    description,
+    quantity,
+    unit_price,
+    tax_rate,
+    discount,
+    subtotal,
+    tax_amount,
+    total
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
 )
 RETURNING *;
 
+-- name: ListInvoiceLinesByInvoiceID :many
+SELECT * FROM invoice.invoice_lines
+WHERE invoice_id = $1
+ORDER BY position;
+
 -- name: GetInvoiceByID :one
 SELECT * FROM invoice.invoices
 WHERE id = $1 AND deleted_at IS NULL;
 
+-- name: GetInvoiceByIDForUpdate :one
+SELECT * FROM invoice.invoices
+WHERE id = $1 AND deleted_at IS NULL
+FOR UPDATE;
+
+-- name: GetInvoiceDocument :one
+SELECT
+    i.id, i.ticket_id, i.amount, i.currency, i.status, i.due_date, i.created_at,
+    i.subtotal, i.discount_total, i.tax_total,
+    t.title AS ticket_title,
+    tn.tenant_name,
+    tn.email AS tenant_email,
+    c.first_name AS customer_first_name,
+    c.last_name AS customer_last_name,
+    c.email AS customer_email
+FROM invoice.invoices AS i
+JOIN ticket.tickets AS t ON i.ticket_id = t.id
+JOIN tenant.tenants AS tn ON t.tenant_id = tn.id
+LEFT JOIN customers AS c ON t.customer_id = c.id
+WHERE i.id = $1 AND i.deleted_at IS NULL;
+
+-- name: RecordInvoicePDF :exec
+UPDATE invoice.invoices
+SET
+    pdf_status = sqlc.arg(pdf_status),
+    pdf_path = sqlc.narg(pdf_path),
+    pdf_error = sqlc.narg(pdf_error),
+    pdf_generated_at = CASE WHEN sqlc.arg(pdf_status) = 'GENERATED' THEN timezone('UTC', now()) ELSE pdf_generated_at END
+WHERE id = sqlc.arg(id);
+
 -- name: UpdateInvoice :one
 UPDATE invoice.invoices
 SET
@@ -44,16 +99,22 @@ WHERE id = $1;
 
 -- name: ListInvoicesByTenantID :many
 SELECT 
-    i.id, i.ticket_id, i.amount, i.currency, i.status, i.due_date,
-    i.created_at, i.updated_at,
+    i.id, i.ticket_id, i.amount, i.amount_paid, i.amount_credited, i.amount_refunded, i.balance_due, i.currency, i.status, i.due_date,
+    i.pdf_status, i.created_at, i.updated_at,
     t.title
 FROM invoice.invoices AS i
 JOIN ticket.tickets AS t ON i.ticket_id = t.id
-WHERE t.tenant_id = $1
+WHERE t.tenant_id = sqlc.arg(tenant_id)
   AND i.deleted_at IS NULL
-ORDER BY i.created_at DESC
-OFFSET $2
-LIMIT $3;
+  AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (i.created_at, i.id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY i.created_at DESC, i.id DESC
+LIMIT sqlc.arg(size);
+
+-- name: CountInvoicesByTenantID :one
+SELECT COUNT(*)
+FROM invoice.invoices AS i
+JOIN ticket.tickets AS t ON i.ticket_id = t.id
+WHERE t.tenant_id = $1 AND i.deleted_at IS NULL;
 
 
 -- name: ListAllInvoices :many
@@ -82,10 +143,74 @@ WHERE id = $1;
 
 -- name: ListPaymentsByInvoiceID :many
 SELECT * FROM invoice.payments
-WHERE invoice_id = $1 
-ORDER BY created_at DESC
-LIMIT $2 
-OFFSET $3;
+WHERE invoice_id = sqlc.arg(invoice_id)
+  AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (created_at, id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(size);
+
+-- name: CountPaymentsByInvoiceID :one
+SELECT COUNT(*) FROM invoice.payments
+WHERE invoice_id = $1;
+
+-- name: GetInvoicePaymentTotals :one
+SELECT
+    COALESCE(SUM(amount) FILTER (WHERE status = 'COMPLETED'), 0)::numeric AS completed,
+    COALESCE(SUM(amount) FILTER (WHERE status = 'PENDING'), 0)::numeric AS pending
+FROM invoice.payments
+WHERE invoice_id = $1;
+
+-- name: UpdatePendingPaymentStatus :one
+UPDATE invoice.payments
+SET status = $2
+WHERE id = $1 AND status = 'PENDING'
+RETURNING *;
+
+-- name: SyncInvoiceBalance :one
+UPDATE invoice.invoices AS i
+SET
+    amount_paid = p.completed,
+    amount_credited = c.credited,
+    status = CASE
+        WHEN i.status = 'PENDING' AND p.completed + c.credited >= i.amount AND p.completed = 0 THEN 'CREDITED'::invoice.invoice_status
+        WHEN i.status = 'PENDING' AND p.completed + c.credited >= i.amount THEN 'PAID'::invoice.invoice_status
+        ELSE i.status
+    END,
+    updated_at = timezone('UTC', now())
+FROM (
+    SELECT COALESCE(SUM(amount), 0) AS completed
+    FROM invoice.payments
+    WHERE invoice_id = sqlc.arg(id) AND status = 'COMPLETED'
+) AS p, (
+    SELECT COALESCE(SUM(amount), 0) AS credited
+    FROM invoice.credit_note_applications
+    WHERE invoice_id = sqlc.arg(id)
+) AS c
+WHERE i.id = sqlc.arg(id)
+RETURNING i.*;
+
+-- name: SyncInvoiceRefunds :one
+UPDATE invoice.invoices AS i
+SET
+    amount_refunded = r.refunded,
+    status = CASE
+        WHEN r.refunded >= i.amount_paid THEN 'REFUNDED'::invoice.invoice_status
+        ELSE 'PARTIALLY_REFUNDED'::invoice.invoice_status
+    END,
+    updated_at = timezone('UTC', now())
+FROM (
+    SELECT COALESCE(SUM(amount), 0) AS refunded
+    FROM invoice.refunds
+    WHERE invoice_id = sqlc.arg(id)
+) AS r
+WHERE i.id = sqlc.arg(id)
+RETURNING i.*;
+
+-- name: NextDocumentNumber :one
+INSERT INTO invoice.document_numbers (tenant_id, kind, last_number)
+VALUES ($1, $2, 1)
+ON CONFLICT (tenant_id, kind) DO UPDATE
+SET last_number = invoice.document_numbers.last_number + 1
+RETURNING last_number;
 
 -- name: ListAllPayments :many
 SELECT * FROM invoice.payments
@@ -0,0 +1,104 @@
+-- name: CreateNotification :one
+INSERT INTO notifications (
+    user_id,
+    type,
+    data
+) VALUES (
+    $1, $2, $3
+)
+RETURNING *;
+
+-- name: ListNotificationsByUserID :many
+SELECT *
+FROM notifications
+WHERE user_id = sqlc.arg(user_id)
+  AND (NOT sqlc.arg(unread_only)::boolean OR read_at IS NULL)
+  AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (created_at, id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(size);
+
+-- name: CountNotificationsByUserID :one
+SELECT COUNT(*)
+FROM notifications
+WHERE user_id = sqlc.arg(user_id)
+  AND (NOT sqlc.arg(unread_only)::boolean OR read_at IS NULL);
+
+-- name: CountUnreadNotifications :one
+SELECT COUNT(*)
+FROM notifications
+WHERE user_id = $1 AND read_at IS NULL;
+
+-- name: MarkNotificationRead :one
+UPDATE notifications
+SET read_at = COALESCE(read_at, timezone('UTC', now()))
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: MarkAllNotificationsRead :execrows
+UPDATE notifications
+SET read_at = timezone('UTC', now())
+WHERE user_id = $1 AND read_at IS NULL;
+
+-- name: GetNotificationRecipient :one
+SELECT email::text AS email, username::text AS name
+FROM users
+WHERE id = $1 AND deleted_at IS NULL
+UNION ALL
+SELECT email::text AS email, (first_name || ' ' || last_name)::text AS name
+FROM customers
+WHERE id = $1 AND deleted_at IS NULL AND email IS NOT NULL
+LIMIT 1;
+
+-- name: ListNotificationPreferences :many
+SELECT * FROM notification_preferences
+WHERE user_id = $1
+ORDER BY category;
+
+-- name: UpsertNotificationPreference :one
+INSERT INTO notification_preferences (
+    user_id,
+    category,
+    in_app,
+    email,
+    websocket
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (user_id, category) DO UPDATE
+SET
+    in_app = EXCLUDED.in_app,
+    email = EXCLUDED.email,
+    websocket = EXCLUDED.websocket
+RETURNING *;
+
+-- name: GetNotificationSettings :one
+SELECT * FROM notification_settings
+WHERE user_id = $1;
+
+-- name: UpsertNotificationSettings :one
+INSERT INTO notification_settings (
+    user_id,
+    timezone,
+    quiet_hours_start,
+    quiet_hours_end
+) VALUES (
+    $1, $2, $3, $4
+)
+ON CONFLICT (user_id) DO UPDATE
+SET
+    timezone = EXCLUDED.timezone,
+    quiet_hours_start = EXCLUDED.quiet_hours_start,
+    quiet_hours_end = EXCLUDED.quiet_hours_end
+RETURNING *;
+
+-- name: GetNotificationDispatchSettings :one
+SELECT
+    COALESCE(p.in_app, TRUE)::boolean AS in_app,
+    COALESCE(p.email, TRUE)::boolean AS email,
+    COALESCE(p.websocket, TRUE)::boolean AS websocket,
+    COALESCE(s.timezone, 'UTC')::text AS timezone,
+    s.quiet_hours_start,
+    s.quiet_hours_end
+FROM (SELECT sqlc.arg(user_id)::uuid AS user_id) AS u
+LEFT JOIN notification_preferences AS p ON p.user_id = u.user_id AND p.category = sqlc.arg(category)
+LEFT JOIN notification_settings AS s ON s.user_id = u.user_id;
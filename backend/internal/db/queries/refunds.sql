@@ -0,0 +1,60 @@
+-- name: CreateRefund :one
+INSERT INTO invoice.refunds (
+    tenant_id,
+    number,
+    payment_id,
+    invoice_id,
+    amount,
+    reason,
+    created_by
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING *;
+
+-- name: GetRefundByID :one
+SELECT * FROM invoice.refunds
+WHERE id = $1;
+
+-- name: SumRefundsByPaymentID :one
+SELECT COALESCE(SUM(amount), 0)::numeric AS refunded
+FROM invoice.refunds
+WHERE payment_id = $1;
+
+-- name: ListRefundsByInvoiceID :many
+SELECT * FROM invoice.refunds
+WHERE invoice_id = sqlc.arg(invoice_id)
+  AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (created_at, id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(size);
+
+-- name: CountRefundsByInvoiceID :one
+SELECT COUNT(*) FROM invoice.refunds
+WHERE invoice_id = $1;
+
+-- name: GetRefundDocument :one
+SELECT
+    r.id, r.number, r.amount, r.reason, r.created_at,
+    i.id AS invoice_id, i.currency,
+    p.method AS payment_method, p.payment_date,
+    tn.tenant_name,
+    tn.email AS tenant_email,
+    c.first_name AS customer_first_name,
+    c.last_name AS customer_last_name,
+    c.email AS customer_email
+FROM invoice.refunds AS r
+JOIN invoice.invoices AS i ON r.invoice_id = i.id
+JOIN invoice.payments AS p ON r.payment_id = p.id
+JOIN ticket.tickets AS t ON i.ticket_id = t.id
+JOIN tenant.tenants AS tn ON r.tenant_id = tn.id
+LEFT JOIN customers AS c ON t.customer_id = c.id
+WHERE r.id = $1;
+
+-- name: RecordRefundPDF :exec
+UPDATE invoice.refunds
+SET
+    pdf_status = sqlc.arg(pdf_status),
+    pdf_path = sqlc.narg(pdf_path),
+    pdf_error = sqlc.narg(pdf_error),
+    pdf_generated_at = CASE WHEN sqlc.arg(pdf_status) = 'GENERATED' THEN timezone('UTC', now()) ELSE pdf_generated_at END
+WHERE id = sqlc.arg(id);
@@ -0,0 +1,25 @@
+-- name: UpsertSLAPolicy :one
+INSERT INTO ticket.sla_policies (
+    tenant_id,
+    priority,
+    first_response_minutes,
+    resolution_minutes,
+    business_hours_only
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (tenant_id, priority) DO UPDATE
+SET
+    first_response_minutes = EXCLUDED.first_response_minutes,
+    resolution_minutes = EXCLUDED.resolution_minutes,
+    business_hours_only = EXCLUDED.business_hours_only
+RETURNING *;
+
+-- name: GetSLAPolicy :one
+SELECT * FROM ticket.sla_policies
+WHERE tenant_id = $1 AND priority = $2;
+
+-- name: ListSLAPoliciesByTenantID :many
+SELECT * FROM ticket.sla_policies
+WHERE tenant_id = $1
+ORDER BY priority;
@@ -57,8 +57,13 @@ RETURNING *;
 -- name: ListTenants :many
 SELECT * FROM tenant.tenants
 WHERE deleted_at IS NULL
-ORDER BY created_at DESC
-LIMIT $1 OFFSET $2;
+  AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (created_at, id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(size);
+
+-- name: CountTenants :one
+SELECT COUNT(*) FROM tenant.tenants
+WHERE deleted_at IS NULL;
 
 -- name: ListAllTenants :many
 SELECT * FROM tenant.tenants
@@ -73,6 +78,10 @@ WHERE tenant_name = $1 AND deleted_at IS NULL;
 SELECT * FROM tenant.tenants
 WHERE domain = $1 AND deleted_at IS NULL;
 
+-- name: GetTenantByEmail :one
+SELECT * FROM tenant.tenants
+WHERE lower(email) = lower($1) AND deleted_at IS NULL;
+
 
 -- name: AddUserToTenant :exec
 INSERT INTO tenant.tenant_users (tenant_id, user_id)
@@ -151,4 +160,83 @@ FROM
 JOIN
     tenant.tenant_users AS tu ON u.id = tu.user_id
 WHERE
-    tu.tenant_id = $1 AND u.role = 'Technician';
\ No newline at end of file
+    tu.tenant_id = $1 AND u.role = 'Technician'
+ORDER BY
+    u.id;
+
+-- name: IsTenantMember :one
+SELECT EXISTS (
+    SELECT 1
+    FROM tenant.tenant_users
+    WHERE tenant_id = $1 AND user_id = $2
+) AS is_member;
+
+-- name: SharesTenant :one
+SELECT EXISTS (
+    SELECT 1
+    FROM tenant.tenant_users AS a
+    JOIN tenant.tenant_users AS b ON a.tenant_id = b.tenant_id
+    WHERE a.user_id = sqlc.arg(user_id) AND b.user_id = sqlc.arg(member_id)
+) AS shares_tenant;
+
+-- name: GetTenantSettings :one
+SELECT * FROM tenant.tenant_settings
+WHERE tenant_id = $1;
+
+-- name: UpsertTenantAssignmentStrategy :one
+INSERT INTO tenant.tenant_settings (tenant_id, assignment_strategy)
+VALUES ($1, $2)
+ON CONFLICT (tenant_id) DO UPDATE
+SET assignment_strategy = EXCLUDED.assignment_strategy
+RETURNING *;
+
+-- name: UpsertTenantBusinessHours :one
+INSERT INTO tenant.tenant_settings (
+    tenant_id,
+    timezone,
+    business_hours_start,
+    business_hours_end,
+    business_days
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (tenant_id) DO UPDATE
+SET
+    timezone = EXCLUDED.timezone,
+    business_hours_start = EXCLUDED.business_hours_start,
+    business_hours_end = EXCLUDED.business_hours_end,
+    business_days = EXCLUDED.business_days
+RETURNING *;
+
+-- name: GetAdminIDsFromTenantID :many
+SELECT
+    u.id
+FROM
+    public.users AS u
+JOIN
+    tenant.tenant_users AS tu ON u.id = tu.user_id
+WHERE
+    tu.tenant_id = $1 AND u.role = 'Admin'
+ORDER BY
+    u.id;
+
+-- name: UpsertTenantEmailSender :one
+INSERT INTO tenant.tenant_settings (
+    tenant_id,
+    email_from_name,
+    email_from_address
+) VALUES (
+    $1, $2, $3
+)
+ON CONFLICT (tenant_id) DO UPDATE
+SET
+    email_from_name = EXCLUDED.email_from_name,
+    email_from_address = EXCLUDED.email_from_address
+RETURNING *;
+
+-- name: UpsertTenantTaxRate :one
+INSERT INTO tenant.tenant_settings (tenant_id, tax_rate)
+VALUES ($1, $2)
+ON CONFLICT (tenant_id) DO UPDATE
+SET tax_rate = EXCLUDED.tax_rate
+RETURNING *;
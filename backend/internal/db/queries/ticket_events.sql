@@ -0,0 +1,38 @@
+-- name: CreateTicketEvent :exec
+INSERT INTO ticket.ticket_events (
+    ticket_id,
+    tenant_id,
+    actor_id,
+    actor_role,
+    event_type,
+    field,
+    old_value,
+    new_value
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+);
+
+-- name: ListTicketEventsByTicketID :many
+SELECT
+    e.id,
+    e.ticket_id,
+    e.tenant_id,
+    e.actor_id,
+    e.actor_role,
+    e.event_type,
+    e.field,
+    e.old_value,
+    e.new_value,
+    e.created_at,
+    COALESCE(u.username, c.username) AS actor_username
+FROM ticket.ticket_events AS e
+LEFT JOIN users AS u ON e.actor_id = u.id
+LEFT JOIN customers AS c ON e.actor_id = c.id
+WHERE e.ticket_id = sqlc.arg(ticket_id)
+  AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (e.created_at, e.id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY e.created_at DESC, e.id DESC
+LIMIT sqlc.arg(size);
+
+-- name: CountTicketEventsByTicketID :one
+SELECT COUNT(*) FROM ticket.ticket_events
+WHERE ticket_id = $1;
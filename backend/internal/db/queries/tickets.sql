@@ -6,9 +6,10 @@ INSERT INTO ticket.tickets (
     title,
     description,
     status,
-    priority
+    priority,
+    category
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7
+    $1, $2, $3, $4, $5, $6, $7, $8
 )
 RETURNING id;
 
@@ -24,25 +25,30 @@ SET
     assigned_to = COALESCE($2, assigned_to),
     title = COALESCE($3, title),
     description = COALESCE($4, description),
-    status = COALESCE($5, status),
-    priority = COALESCE($6, priority),
-    updated_at = timezone('UTC', now()),
-    closed_at = CASE WHEN $5 = 'RESOLVED' OR $5 = 'CLOSED' THEN timezone('UTC', now()) ELSE closed_at END
+    priority = COALESCE($5, priority),
+    updated_at = timezone('UTC', now())
 WHERE id = $1 
 RETURNING *;
 
+-- name: TransitionTicketStatus :one
+UPDATE ticket.tickets
+SET
+    status = sqlc.arg(to_status),
+    updated_at = timezone('UTC', now()),
+    closed_at = CASE
+        WHEN sqlc.arg(to_status) = 'RESOLVED' OR sqlc.arg(to_status) = 'CLOSED' THEN timezone('UTC', now())
+        WHEN sqlc.arg(to_status) = 'REOPENED' THEN NULL
+        ELSE closed_at
+    END
+WHERE id = sqlc.arg(id) AND status = sqlc.arg(from_status)
+RETURNING *;
+
 
 
 -- name: DeleteTicket :exec
 DELETE FROM ticket.tickets
 WHERE id = $1;
 
--- name: ListTicketsByTenantID :many
-SELECT * FROM ticket.tickets
-WHERE tenant_id = $1 
-ORDER BY created_at DESC
-LIMIT $2 OFFSET $3;
-
 -- name: ListTicketsByCustomerID :many
 -- name: ListTicketsByCustomerID :many
 SELECT 
@@ -59,11 +65,6 @@ JOIN users AS u ON t.assigned_to = u.id
 WHERE t.customer_id = $1
 ORDER BY t.created_at DESC
 LIMIT $2 OFFSET $3;
--- name: ListTicketsByAssignedTo :many
-SELECT * FROM ticket.tickets
-WHERE assigned_to = $1 
-ORDER BY created_at DESC
-LIMIT $2 OFFSET $3;
 
 -- name: ListTicketsByStatus :many
 SELECT * FROM ticket.tickets
@@ -84,21 +85,31 @@ INSERT INTO ticket.comments (
     ticket_id,
     author_type,
     author_id,
-    comment
+    comment,
+    visibility
 ) VALUES (
-    $1, $2, $3, $4
+    $1, $2, $3, $4, $5
 )
 RETURNING id;
 
 -- name: ListCommentsByTicketID :many
 SELECT 
     c.*,
-    u.username
+    COALESCE(u.username, cu.username) AS username,
+    COALESCE(u.role::text, 'Customer')::text AS user_role
 FROM ticket.comments c
-LEFT JOIN users u ON c.user_id = u.id
-WHERE c.ticket_id = $1
-ORDER BY c.created_at DESC
-LIMIT $2 OFFSET $3;
+LEFT JOIN users u ON c.author_type = 'USER' AND c.author_id = u.id
+LEFT JOIN customers cu ON c.author_type = 'CUSTOMER' AND c.author_id = cu.id
+WHERE c.ticket_id = sqlc.arg(ticket_id)
+  AND (sqlc.arg(include_internal)::boolean OR c.visibility = 'PUBLIC')
+  AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (c.created_at, c.id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY c.created_at DESC, c.id DESC
+LIMIT sqlc.arg(size);
+
+-- name: CountCommentsByTicketID :one
+SELECT COUNT(*) FROM ticket.comments
+WHERE ticket_id = sqlc.arg(ticket_id)
+  AND (sqlc.arg(include_internal)::boolean OR visibility = 'PUBLIC');
 
 
 -- name: ListTicketsByUserId :many 
@@ -114,6 +125,9 @@ SELECT
     t.created_at,
     t.updated_at,
     t.closed_at,
+    t.first_response_due_at,
+    t.resolution_due_at,
+    t.sla_status,
     c.first_name AS customer_first_name,
     c.last_name AS customer_last_name,
     u.username AS assigned_username
@@ -146,6 +160,9 @@ SELECT
     t.created_at,
     t.updated_at,
     t.closed_at,
+    t.first_response_due_at,
+    t.resolution_due_at,
+    t.sla_status,
     c.username AS customer_username,
     u.username AS assigned_username
 FROM
@@ -158,4 +175,146 @@ WHERE
     t.customer_id = $1
 ORDER BY
     t.created_at DESC
-LIMIT $2 OFFSET $3;
\ No newline at end of file
+LIMIT $2 OFFSET $3;
+
+
+-- name: CreateTicketAssignment :exec
+INSERT INTO ticket.ticket_assignments (
+    ticket_id,
+    technician_id,
+    strategy,
+    reason
+) VALUES (
+    $1, $2, $3, $4
+);
+
+-- name: ListTicketAssignmentsByTicketID :many
+SELECT
+    a.id,
+    a.ticket_id,
+    a.technician_id,
+    a.strategy,
+    a.reason,
+    a.created_at,
+    u.username AS technician_username
+FROM ticket.ticket_assignments AS a
+LEFT JOIN users AS u ON a.technician_id = u.id
+WHERE a.ticket_id = $1
+ORDER BY a.created_at DESC;
+
+-- name: ListTechnicianLoadsByTenantID :many
+SELECT
+    u.id,
+    COUNT(t.id) AS open_tickets
+FROM users AS u
+JOIN tenant.tenant_users AS tu ON u.id = tu.user_id
+LEFT JOIN ticket.tickets AS t
+    ON t.assigned_to = u.id AND t.status IN ('OPEN', 'IN_PROGRESS', 'REOPENED')
+WHERE tu.tenant_id = $1 AND u.role = 'Technician' AND u.deleted_at IS NULL
+GROUP BY u.id
+ORDER BY open_tickets ASC, u.id;
+
+-- name: ListSkilledTechnicianLoadsByTenantID :many
+SELECT
+    u.id,
+    COUNT(t.id) AS open_tickets
+FROM users AS u
+JOIN tenant.tenant_users AS tu ON u.id = tu.user_id
+JOIN technician_skills AS s ON s.user_id = u.id
+LEFT JOIN ticket.tickets AS t
+    ON t.assigned_to = u.id AND t.status IN ('OPEN', 'IN_PROGRESS', 'REOPENED')
+WHERE tu.tenant_id = $1 AND u.role = 'Technician' AND u.deleted_at IS NULL AND s.category = $2
+GROUP BY u.id
+ORDER BY open_tickets ASC, u.id;
+
+-- name: SetTicketSLADeadlines :exec
+UPDATE ticket.tickets
+SET
+    first_response_due_at = $2,
+    resolution_due_at = $3,
+    sla_status = $4
+WHERE id = $1;
+
+-- name: MarkTicketFirstResponse :exec
+UPDATE ticket.tickets
+SET first_responded_at = timezone('UTC', now())
+WHERE id = $1 AND first_responded_at IS NULL;
+
+-- name: ListTicketsForSLACheck :many
+SELECT
+    id,
+    tenant_id,
+    assigned_to,
+    title,
+    status,
+    sla_status,
+    created_at,
+    first_response_due_at,
+    resolution_due_at,
+    first_responded_at
+FROM ticket.tickets
+WHERE status IN ('OPEN', 'IN_PROGRESS', 'REOPENED')
+  AND resolution_due_at IS NOT NULL
+  AND (sla_status IS NULL OR sla_status <> 'BREACHED');
+
+-- name: UpdateTicketSLAStatus :exec
+UPDATE ticket.tickets
+SET sla_status = $2
+WHERE id = $1;
+
+-- name: SearchTickets :many
+WITH search AS (
+    SELECT websearch_to_tsquery('english', sqlc.arg(query)::text) AS q
+),
+comment_matches AS (
+    SELECT DISTINCT ON (cm.ticket_id)
+        cm.ticket_id,
+        cm.id AS comment_id,
+        cm.comment,
+        ts_rank(to_tsvector('english', cm.comment), search.q) AS rank
+    FROM ticket.comments AS cm, search
+    WHERE to_tsvector('english', cm.comment) @@ search.q
+      AND (NOT sqlc.arg(is_customer)::bool OR cm.visibility = 'PUBLIC')
+    ORDER BY cm.ticket_id, rank DESC
+),
+ranked AS (
+    SELECT
+        t.id,
+        t.tenant_id,
+        t.customer_id,
+        t.title,
+        t.status,
+        t.priority,
+        t.created_at,
+        (COALESCE(ts_rank(ticket.search_document(t.title, t.description), search.q), 0) + COALESCE(cm.rank, 0) * 0.5)::real AS rank,
+        ts_headline('english', ticket.html_escape(t.title || ' ' || COALESCE(t.description, '')), search.q,
+            'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=30, MinWords=10') AS snippet,
+        cm.comment_id AS matched_comment_id,
+        (CASE WHEN cm.comment IS NOT NULL THEN ts_headline('english', ticket.html_escape(cm.comment), search.q,
+            'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=30, MinWords=10') END)::text AS comment_snippet
+    FROM
+        ticket.tickets AS t
+    CROSS JOIN
+        search
+    LEFT JOIN
+        comment_matches AS cm ON cm.ticket_id = t.id
+    WHERE
+        (ticket.search_document(t.title, t.description) @@ search.q OR cm.ticket_id IS NOT NULL)
+        AND (
+            (sqlc.arg(is_customer)::bool AND t.customer_id = sqlc.arg(actor_id))
+            OR (NOT sqlc.arg(is_customer)::bool AND t.tenant_id IN (
+                SELECT tu.tenant_id FROM tenant.tenant_users AS tu WHERE tu.user_id = sqlc.arg(actor_id)
+            ))
+        )
+        AND (sqlc.narg(tenant_id)::uuid IS NULL OR t.tenant_id = sqlc.narg(tenant_id))
+)
+SELECT * FROM ranked
+WHERE sqlc.narg(cursor_rank)::real IS NULL
+   OR (ranked.rank, ranked.created_at, ranked.id) < (sqlc.narg(cursor_rank), sqlc.narg(cursor_created_at)::timestamptz, sqlc.narg(cursor_id)::uuid)
+ORDER BY
+    ranked.rank DESC, ranked.created_at DESC, ranked.id DESC
+LIMIT sqlc.arg(size);
+
+-- name: GetCommentByID :one
+SELECT * FROM ticket.comments
+WHERE id = $1;
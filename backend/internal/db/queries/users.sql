@@ -116,4 +116,18 @@ SELECT
    created_at
 FROM users
 WHERE role = $1 AND deleted_at IS NULL
-ORDER BY created_at DESC;
\ No newline at end of file
+ORDER BY created_at DESC;
+
+-- name: DeleteTechnicianSkills :exec
+DELETE FROM technician_skills
+WHERE user_id = $1;
+
+-- name: AddTechnicianSkill :exec
+INSERT INTO technician_skills (user_id, category)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: ListTechnicianSkills :many
+SELECT category FROM technician_skills
+WHERE user_id = $1
+ORDER BY category;
@@ -1,16 +1,221 @@
 package dto
 
+import (
+	"backend/internal/money"
+	"backend/internal/repositories"
+)
+
+// CreateInvoiceRequest issues an invoice either from its lines or, for a
+// single untaxed charge, from an amount. Amounts are decimal strings in the
+// currency, which is an ISO 4217 code.
 type CreateInvoiceRequest struct {
-	TicketID string  `json:"ticket_id" binding:"required,uuid"`
-	Amount   float64 `json:"amount" binding:"required,gt=0"`
-	Currency string  `json:"currency" binding:"required,len=3"`
-	DueDate  string  `json:"due_date" binding:"required"`
+	TicketID string           `json:"ticket_id" binding:"required,uuid"`
+	Amount   money.Decimal    `json:"amount" binding:"omitempty,gt=0"`
+	Lines    []InvoiceLineDto `json:"lines" binding:"omitempty,max=100,dive"`
+	Currency money.Currency   `json:"currency" binding:"required,len=3"`
+	DueDate  string           `json:"due_date" binding:"required"`
 }
 
+// InvoiceLineDto is one billed item. Discount is an amount taken off the
+// line before tax; TaxRate is a percentage and defaults to the tenant's.
+type InvoiceLineDto struct {
+	Description string         `json:"description" binding:"required,max=255"`
+	Quantity    money.Decimal  `json:"quantity" binding:"required,gt=0"`
+	UnitPrice   money.Decimal  `json:"unit_price" binding:"gte=0"`
+	TaxRate     *money.Decimal `json:"tax_rate" binding:"omitempty,gte=0,lte=100"`
+	Discount    money.Decimal  `json:"discount" binding:"gte=0"`
+}
 
+// CreatePaymentRequest records a payment, in the invoice's currency. Payments
+// are recorded as completed unless Status says they are still pending.
 type CreatePaymentRequest struct {
-	InvoiceID string  `json:"invoice_id" binding:"required,uuid"`
-	Amount    float64 `json:"amount" binding:"required,gt=0"`
-	Method    string  `json:"method" binding:"required,oneof=CREDIT_CARD BANK_TRANSFER PAYPAL"`
+	InvoiceID string        `json:"invoice_id" binding:"required,uuid"`
+	Amount    money.Decimal `json:"amount" binding:"required,gt=0"`
+	Method    string        `json:"method" binding:"required,oneof=CREDIT_CARD BANK_TRANSFER PAYPAL"`
+	Status    string        `json:"status" binding:"omitempty,oneof=PENDING COMPLETED"`
+}
+
+// UpdatePaymentStatusRequest settles a pending payment.
+type UpdatePaymentStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=COMPLETED FAILED"`
+}
+
+// CreateRefundRequest refunds a completed payment, in full when no amount is
+// given.
+type CreateRefundRequest struct {
+	Amount money.Decimal `json:"amount" binding:"omitempty,gt=0"`
+	Reason string        `json:"reason" binding:"max=1000"`
+}
+
+// CreateCreditNoteRequest issues a credit note against an invoice. It is
+// applied to what is still due on that invoice, and whatever is left can be
+// applied to later invoices of the same customer.
+type CreateCreditNoteRequest struct {
+	InvoiceID string        `json:"invoice_id" binding:"required,uuid"`
+	Amount    money.Decimal `json:"amount" binding:"required,gt=0"`
+	Reason    string        `json:"reason" binding:"max=1000"`
+}
+
+// ApplyCreditNoteRequest applies what is left of a credit note to an open
+// invoice, as much as it covers when no amount is given.
+type ApplyCreditNoteRequest struct {
+	InvoiceID string        `json:"invoice_id" binding:"required,uuid"`
+	Amount    money.Decimal `json:"amount" binding:"omitempty,gt=0"`
+}
+
+// The responses below write amounts with the decimal places of their
+// currency, such as "12.50" for USD and "1250" for JPY, where a bare Decimal
+// would write "12.5". Each embeds the stored row and replaces its amounts.
+
+// InvoiceResponse is an invoice as the API returns it.
+type InvoiceResponse struct {
+	repositories.InvoiceInvoice
+	Amount         string `json:"amount"`
+	Subtotal       string `json:"subtotal"`
+	DiscountTotal  string `json:"discount_total"`
+	TaxTotal       string `json:"tax_total"`
+	AmountPaid     string `json:"amount_paid"`
+	AmountCredited string `json:"amount_credited"`
+	AmountRefunded string `json:"amount_refunded"`
+	BalanceDue     string `json:"balance_due"`
+}
+
+func NewInvoiceResponse(invoice repositories.InvoiceInvoice) InvoiceResponse {
+	currency := money.Currency(invoice.Currency)
+	return InvoiceResponse{
+		InvoiceInvoice: invoice,
+		Amount:         currency.Format(invoice.Amount),
+		Subtotal:       currency.Format(invoice.Subtotal),
+		DiscountTotal:  currency.Format(invoice.DiscountTotal),
+		TaxTotal:       currency.Format(invoice.TaxTotal),
+		AmountPaid:     currency.Format(invoice.AmountPaid),
+		AmountCredited: currency.Format(invoice.AmountCredited),
+		AmountRefunded: currency.Format(invoice.AmountRefunded),
+		BalanceDue:     currency.Format(invoice.BalanceDue),
+	}
+}
+
+// InvoiceSummaryResponse is an invoice in the tenant's invoice list.
+type InvoiceSummaryResponse struct {
+	repositories.ListInvoicesByTenantIDRow
+	Amount         string `json:"amount"`
+	AmountPaid     string `json:"amount_paid"`
+	AmountCredited string `json:"amount_credited"`
+	AmountRefunded string `json:"amount_refunded"`
+	BalanceDue     string `json:"balance_due"`
+}
+
+func NewInvoiceSummaryResponses(invoices []repositories.ListInvoicesByTenantIDRow) []InvoiceSummaryResponse {
+	return mapResponses(invoices, func(invoice repositories.ListInvoicesByTenantIDRow) InvoiceSummaryResponse {
+		currency := money.Currency(invoice.Currency)
+		return InvoiceSummaryResponse{
+			ListInvoicesByTenantIDRow: invoice,
+			Amount:                    currency.Format(invoice.Amount),
+			AmountPaid:                currency.Format(invoice.AmountPaid),
+			AmountCredited:            currency.Format(invoice.AmountCredited),
+			AmountRefunded:            currency.Format(invoice.AmountRefunded),
+			BalanceDue:                currency.Format(invoice.BalanceDue),
+		}
+	})
+}
+
+// InvoiceLineResponse is a line of an invoice in currency. Quantity and tax
+// rate are not amounts and keep their own precision.
+type InvoiceLineResponse struct {
+	repositories.InvoiceInvoiceLine
+	UnitPrice string `json:"unit_price"`
+	Discount  string `json:"discount"`
+	Subtotal  string `json:"subtotal"`
+	TaxAmount string `json:"tax_amount"`
+	Total     string `json:"total"`
+}
+
+func NewInvoiceLineResponses(lines []repositories.InvoiceInvoiceLine, currency money.Currency) []InvoiceLineResponse {
+	return mapResponses(lines, func(line repositories.InvoiceInvoiceLine) InvoiceLineResponse {
+		return InvoiceLineResponse{
+			InvoiceInvoiceLine: line,
+			UnitPrice:          currency.Format(line.UnitPrice),
+			Discount:           currency.Format(line.Discount),
+			Subtotal:           currency.Format(line.Subtotal),
+			TaxAmount:          currency.Format(line.TaxAmount),
+			Total:              currency.Format(line.Total),
+		}
+	})
 }
 
+// PaymentResponse is a payment of an invoice in currency.
+type PaymentResponse struct {
+	repositories.InvoicePayment
+	Amount string `json:"amount"`
+}
+
+func NewPaymentResponse(payment repositories.InvoicePayment, currency money.Currency) PaymentResponse {
+	return PaymentResponse{InvoicePayment: payment, Amount: currency.Format(payment.Amount)}
+}
+
+func NewPaymentResponses(payments []repositories.InvoicePayment, currency money.Currency) []PaymentResponse {
+	return mapResponses(payments, func(payment repositories.InvoicePayment) PaymentResponse {
+		return NewPaymentResponse(payment, currency)
+	})
+}
+
+// RefundResponse is a refund of an invoice in currency.
+type RefundResponse struct {
+	repositories.InvoiceRefund
+	Amount string `json:"amount"`
+}
+
+func NewRefundResponse(refund repositories.InvoiceRefund, currency money.Currency) RefundResponse {
+	return RefundResponse{InvoiceRefund: refund, Amount: currency.Format(refund.Amount)}
+}
+
+func NewRefundResponses(refunds []repositories.InvoiceRefund, currency money.Currency) []RefundResponse {
+	return mapResponses(refunds, func(refund repositories.InvoiceRefund) RefundResponse {
+		return NewRefundResponse(refund, currency)
+	})
+}
+
+// CreditNoteResponse is a credit note as the API returns it.
+type CreditNoteResponse struct {
+	repositories.InvoiceCreditNote
+	Amount          string `json:"amount"`
+	AmountApplied   string `json:"amount_applied"`
+	AmountRemaining string `json:"amount_remaining"`
+}
+
+func NewCreditNoteResponse(creditNote repositories.InvoiceCreditNote) CreditNoteResponse {
+	currency := money.Currency(creditNote.Currency)
+	return CreditNoteResponse{
+		InvoiceCreditNote: creditNote,
+		Amount:            currency.Format(creditNote.Amount),
+		AmountApplied:     currency.Format(creditNote.AmountApplied),
+		AmountRemaining:   currency.Format(creditNote.AmountRemaining),
+	}
+}
+
+func NewCreditNoteResponses(creditNotes []repositories.InvoiceCreditNote) []CreditNoteResponse {
+	return mapResponses(creditNotes, NewCreditNoteResponse)
+}
+
+// CreditNoteApplicationResponse is an application of a credit note in
+// currency to an invoice.
+type CreditNoteApplicationResponse struct {
+	repositories.InvoiceCreditNoteApplication
+	Amount string `json:"amount"`
+}
+
+func NewCreditNoteApplicationResponses(applications []repositories.InvoiceCreditNoteApplication, currency money.Currency) []CreditNoteApplicationResponse {
+	return mapResponses(applications, func(application repositories.InvoiceCreditNoteApplication) CreditNoteApplicationResponse {
+		return CreditNoteApplicationResponse{InvoiceCreditNoteApplication: application, Amount: currency.Format(application.Amount)}
+	})
+}
+
+// mapResponses converts each item with response. It returns an empty slice,
+// not nil, so empty lists are written as [].
+func mapResponses[T, R any](items []T, response func(T) R) []R {
+	responses := make([]R, 0, len(items))
+	for _, item := range items {
+		responses = append(responses, response(item))
+	}
+	return responses
+}
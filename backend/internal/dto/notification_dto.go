@@ -0,0 +1,15 @@
+package dto
+
+type UpdateNotificationPreferencesDto struct {
+	Timezone        string                    `json:"timezone" binding:"omitempty,max=64"`
+	QuietHoursStart *int16                    `json:"quiet_hours_start" binding:"omitempty,min=0,max=23"`
+	QuietHoursEnd   *int16                    `json:"quiet_hours_end" binding:"omitempty,min=0,max=23"`
+	Categories      []NotificationChannelsDto `json:"categories" binding:"omitempty,dive"`
+}
+
+type NotificationChannelsDto struct {
+	Category  string `json:"category" binding:"required,oneof=ASSIGNED COMMENT STATUS_CHANGE SLA INVOICE"`
+	InApp     *bool  `json:"in_app" binding:"required"`
+	Email     *bool  `json:"email" binding:"required"`
+	Websocket *bool  `json:"websocket" binding:"required"`
+}
@@ -1,5 +1,7 @@
 package dto
 
+import "backend/internal/money"
+
 type CreateTenantDto struct {
 	TenantName string `json:"tenant_name" binding:"required,min=1,max=100"`
 	Domain     string `json:"domain" binding:"required,min=3,max=100"`
@@ -17,3 +19,54 @@ type AddUserToTenantDto struct {
 	UserID   string `json:"user_id" binding:"required,uuid"`
 	TenantID string `json:"tenant_id" binding:"required,uuid"`
 }
+
+type SetAssignmentStrategyDto struct {
+	TenantID string `json:"tenant_id" binding:"required,uuid"`
+	Strategy string `json:"strategy" binding:"required,oneof=RANDOM ROUND_ROBIN LEAST_OPEN SKILL_MATCH"`
+}
+
+type SetBusinessHoursDto struct {
+	TenantID string  `json:"tenant_id" binding:"required,uuid"`
+	Timezone string  `json:"timezone" binding:"required,max=64"`
+	Start    int16   `json:"start" binding:"min=0,max=23"`
+	End      int16   `json:"end" binding:"required,min=1,max=24"`
+	Days     []int16 `json:"days" binding:"required,min=1,max=7,dive,min=0,max=6"`
+}
+
+// SetEmailSenderDto sets who tenant emails come from. Empty fields fall back
+// to the tenant name and the platform sender address.
+type SetEmailSenderDto struct {
+	TenantID    string `json:"tenant_id" binding:"required,uuid"`
+	FromName    string `json:"from_name" binding:"omitempty,max=100"`
+	FromAddress string `json:"from_address" binding:"omitempty,email,max=100"`
+}
+
+// SetTaxRateDto sets the tax rate, in percent, charged on invoice lines that
+// do not carry their own.
+type SetTaxRateDto struct {
+	TenantID string        `json:"tenant_id" binding:"required,uuid"`
+	TaxRate  money.Decimal `json:"tax_rate" binding:"gte=0,lte=100"`
+}
+
+type SetSLAPolicyDto struct {
+	TenantID             string `json:"tenant_id" binding:"required,uuid"`
+	Priority             string `json:"priority" binding:"required,oneof=LOW MEDIUM HIGH URGENT CRITICAL"`
+	FirstResponseMinutes int32  `json:"first_response_minutes" binding:"required,min=1"`
+	ResolutionMinutes    int32  `json:"resolution_minutes" binding:"required,min=1"`
+	BusinessHoursOnly    bool   `json:"business_hours_only"`
+}
+
+type CreateEscalationRuleDto struct {
+	TenantID        string `json:"tenant_id" binding:"required,uuid"`
+	Name            string `json:"name" binding:"required,min=1,max=100"`
+	ConditionType   string `json:"condition_type" binding:"required,oneof=UNTOUCHED REOPENED_COUNT"`
+	MatchPriority   string `json:"match_priority" binding:"omitempty,oneof=LOW MEDIUM HIGH URGENT CRITICAL"`
+	Threshold       int32  `json:"threshold" binding:"required,min=1"`
+	SetPriority     string `json:"set_priority" binding:"omitempty,oneof=LOW MEDIUM HIGH URGENT CRITICAL"`
+	ReassignToAdmin bool   `json:"reassign_to_admin"`
+	NotifyAdmin     bool   `json:"notify_admin"`
+}
+
+type SetEscalationRuleActiveDto struct {
+	IsActive *bool `json:"is_active" binding:"required"`
+}
@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 type CreateTicketDto struct {
 	TenantID    string `json:"tenant_id" binding:"required,uuid"`
 	CustomerID  string `json:"customer_id" binding:"required,uuid"`
@@ -7,6 +9,7 @@ type CreateTicketDto struct {
 	Title       string `json:"title" binding:"required,min=1,max=200"`
 	Description string `json:"description" binding:"omitempty,max=2000"`
 	Priority    string `json:"priority" binding:"omitempty,oneof=LOW MEDIUM HIGH URGENT CRITICAL"`
+	Category    string `json:"category" binding:"omitempty,max=50"`
 }
 
 type UpdateTicketDto struct {
@@ -17,9 +20,42 @@ type UpdateTicketDto struct {
 	Priority    string `json:"priority" binding:"omitempty,oneof=LOW MEDIUM HIGH URGENT CRITICAL"`
 }
 
+type TransitionTicketDto struct {
+	Status string `json:"status" binding:"required,oneof=OPEN IN_PROGRESS RESOLVED CLOSED REOPENED"`
+}
+
 type TicketCommentDto struct {
 	TicketID   string `json:"ticket_id" binding:"required,uuid"`
 	AuthorID   string `json:"user_id" binding:"required,uuid"`
 	AuthorType string `json:"author_type" binding:"required,oneof=User Customer"`
 	Comment    string `json:"comment" binding:"required,min=1,max=1000"`
 }
+
+// CreateCommentDto is a comment posted on a ticket. Only staff may post
+// INTERNAL notes, which the customer does not see.
+type CreateCommentDto struct {
+	Comment    string `json:"comment" binding:"required,min=1,max=2000"`
+	Visibility string `json:"visibility" binding:"omitempty,oneof=PUBLIC INTERNAL"`
+}
+
+type SearchTicketsDto struct {
+	Query    string `form:"q" binding:"required,min=2,max=200"`
+	TenantID string `form:"tenant_id" binding:"omitempty,uuid"`
+}
+
+// TicketFilterDto holds the query parameters of the ticket list endpoints.
+// Status and priority may be repeated to match any of several values; dates
+// are RFC 3339 and the "to" bounds are exclusive.
+type TicketFilterDto struct {
+	Status      []string  `form:"status" binding:"omitempty,dive,oneof=OPEN IN_PROGRESS RESOLVED CLOSED REOPENED"`
+	Priority    []string  `form:"priority" binding:"omitempty,dive,oneof=LOW MEDIUM HIGH URGENT CRITICAL"`
+	AssignedTo  string    `form:"assigned_to" binding:"omitempty,uuid"`
+	TenantID    string    `form:"tenant_id" binding:"omitempty,uuid"`
+	Unassigned  bool      `form:"unassigned"`
+	CreatedFrom time.Time `form:"created_from"`
+	CreatedTo   time.Time `form:"created_to"`
+	UpdatedFrom time.Time `form:"updated_from"`
+	UpdatedTo   time.Time `form:"updated_to"`
+	Sort        string    `form:"sort" binding:"omitempty,oneof=created_at updated_at priority due_at"`
+	Order       string    `form:"order" binding:"omitempty,oneof=asc desc"`
+}
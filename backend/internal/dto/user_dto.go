@@ -16,3 +16,7 @@ type LoginDto struct {
 	Email    string `json:"email" binding:"required,email,min=6,max=100"`
 	Password string `json:"password" binding:"required,min=6,max=100"`
 }
+
+type SetTechnicianSkillsDto struct {
+	Skills []string `json:"skills" binding:"required,dive,min=1,max=50"`
+}
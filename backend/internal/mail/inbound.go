@@ -0,0 +1,272 @@
+package mail
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strings"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// maxMIMEDepth bounds how deeply nested multiparts are walked.
+const maxMIMEDepth = 10
+
+// InboundMessage is the part of a received email the ticket gateway uses.
+type InboundMessage struct {
+	MessageID string
+	// References holds the In-Reply-To and References message ids, most
+	// recent first.
+	References []string
+	From       Address
+	// Recipients are the To, Cc and delivery headers' addresses.
+	Recipients []Address
+	Subject    string
+	Text       string
+	// AutoGenerated is set for auto-replies and bulk mail, which must not be
+	// answered to avoid mail loops.
+	AutoGenerated bool
+	Attachments   []InboundAttachment
+}
+
+type InboundAttachment struct {
+	FileName    string
+	ContentType string
+	Data        []byte
+}
+
+var wordDecoder = &mime.WordDecoder{CharsetReader: charsetReader}
+
+// ParseInbound parses a raw RFC 5322 message.
+func ParseInbound(raw []byte) (*InboundMessage, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid message: %w", err)
+	}
+	header := msg.Header
+	parser := mail.AddressParser{WordDecoder: wordDecoder}
+
+	from, err := parser.Parse(header.Get("From"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid From header: %w", err)
+	}
+	inbound := &InboundMessage{
+		MessageID: strings.TrimSpace(header.Get("Message-ID")),
+		From:      *from,
+		Subject:   decodeHeader(header.Get("Subject")),
+	}
+	inbound.References = append(messageIDs(header.Get("In-Reply-To")), reversed(messageIDs(header.Get("References")))...)
+	for _, name := range []string{"Delivered-To", "X-Original-To", "To", "Cc"} {
+		for _, value := range header[name] {
+			addresses, err := parser.ParseList(value)
+			if err != nil {
+				continue
+			}
+			for _, address := range addresses {
+				inbound.Recipients = append(inbound.Recipients, *address)
+			}
+		}
+	}
+	autoSubmitted := strings.ToLower(strings.TrimSpace(header.Get("Auto-Submitted")))
+	precedence := strings.ToLower(strings.TrimSpace(header.Get("Precedence")))
+	inbound.AutoGenerated = (autoSubmitted != "" && autoSubmitted != "no") ||
+		precedence == "bulk" || precedence == "junk" || precedence == "auto_reply" ||
+		header.Get("X-Autoreply") != "" || header.Get("X-Autorespond") != ""
+
+	var htmlBody string
+	err = walkPart(header, msg.Body, 0, func(contentType, fileName string, body []byte) {
+		switch {
+		case fileName != "":
+			inbound.Attachments = append(inbound.Attachments, InboundAttachment{FileName: fileName, ContentType: contentType, Data: body})
+		case contentType == "text/plain" && inbound.Text == "":
+			inbound.Text = string(body)
+		case contentType == "text/html" && htmlBody == "":
+			htmlBody = string(body)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if inbound.Text == "" && htmlBody != "" {
+		inbound.Text = htmlToText(htmlBody)
+	}
+	inbound.Text = strings.TrimSpace(strings.ReplaceAll(inbound.Text, "\r\n", "\n"))
+	return inbound, nil
+}
+
+type partHeader interface {
+	Get(key string) string
+}
+
+// walkPart calls leaf with every non-multipart part of the message, decoded
+// from its transfer encoding and, for text, from its charset.
+func walkPart(header partHeader, body io.Reader, depth int, leaf func(contentType, fileName string, body []byte)) error {
+	if depth > maxMIMEDepth {
+		return errors.New("message is nested too deeply")
+	}
+	contentType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		contentType, params = "text/plain", map[string]string{"charset": "us-ascii"}
+	}
+
+	if strings.HasPrefix(contentType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("invalid multipart body: %w", err)
+			}
+			if err := walkPart(part.Header, part, depth+1, leaf); err != nil {
+				return err
+			}
+		}
+	}
+
+	decoded, err := io.ReadAll(transferDecoder(header.Get("Content-Transfer-Encoding"), body))
+	if err != nil {
+		return fmt.Errorf("failed to decode part: %w", err)
+	}
+	fileName := partFileName(header, params)
+	if fileName == "" && strings.HasPrefix(contentType, "text/") {
+		decoded = toUTF8(decoded, params["charset"])
+	}
+	leaf(contentType, fileName, decoded)
+	return nil
+}
+
+func transferDecoder(encoding string, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	default:
+		return body
+	}
+}
+
+// partFileName returns the name of an attached file, or "" for body parts.
+func partFileName(header partHeader, contentTypeParams map[string]string) string {
+	disposition, params, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	name := params["filename"]
+	if name == "" {
+		name = contentTypeParams["name"]
+	}
+	if disposition != "attachment" && name == "" {
+		return ""
+	}
+	if name == "" {
+		name = "attachment"
+	}
+	return decodeHeader(name)
+}
+
+func toUTF8(body []byte, charset string) []byte {
+	charset = strings.ToLower(charset)
+	if charset == "" || charset == "utf-8" || charset == "us-ascii" {
+		return body
+	}
+	encoding, err := htmlindex.Get(charset)
+	if err != nil {
+		return body
+	}
+	decoded, err := encoding.NewDecoder().Bytes(body)
+	if err != nil {
+		return body
+	}
+	return decoded
+}
+
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	encoding, err := htmlindex.Get(charset)
+	if err != nil {
+		return nil, err
+	}
+	return encoding.NewDecoder().Reader(input), nil
+}
+
+func decodeHeader(value string) string {
+	decoded, err := wordDecoder.DecodeHeader(value)
+	if err != nil {
+		return value
+	}
+	return decoded
+}
+
+var messageIDPattern = regexp.MustCompile(`<[^<>\s]+>`)
+
+func messageIDs(value string) []string {
+	return messageIDPattern.FindAllString(value, -1)
+}
+
+func reversed(values []string) []string {
+	for i, j := 0, len(values)-1; i < j; i, j = i+1, j-1 {
+		values[i], values[j] = values[j], values[i]
+	}
+	return values
+}
+
+var (
+	htmlBreakPattern = regexp.MustCompile(`(?i)<br\s*/?>|</p>|</div>|</li>|</tr>`)
+	htmlStripPattern = regexp.MustCompile(`(?is)<(script|style)[^>]*>.*?</(script|style)>|<[^>]+>`)
+	blankLinePattern = regexp.MustCompile(`\n{3,}`)
+)
+
+func htmlToText(body string) string {
+	text := htmlBreakPattern.ReplaceAllString(body, "\n")
+	text = htmlStripPattern.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+	return blankLinePattern.ReplaceAllString(text, "\n\n")
+}
+
+var replyHeaderPattern = regexp.MustCompile(`(?i)^(on .+ wrote:|-+\s*original message\s*-+|from:\s.+)$`)
+
+// StripQuotedReply drops the quoted previous message from a reply, keeping
+// what the sender wrote above it.
+func StripQuotedReply(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if replyHeaderPattern.MatchString(trimmed) {
+			lines = lines[:i]
+			break
+		}
+	}
+	kept := lines[:0]
+	for _, line := range lines {
+		if !strings.HasPrefix(strings.TrimSpace(line), ">") {
+			kept = append(kept, line)
+		}
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
+
+// ticketTokenPattern matches the token outgoing ticket emails carry in their
+// subject, which threads replies whose In-Reply-To header was dropped.
+var ticketTokenPattern = regexp.MustCompile(`\[#([0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})\]`)
+
+// TicketToken is the subject token of a ticket's emails.
+func TicketToken(ticketID string) string {
+	return "[#" + ticketID + "]"
+}
+
+// TicketIDFromSubject returns the ticket id of the subject's ticket token, or
+// "" when it has none.
+func TicketIDFromSubject(subject string) string {
+	match := ticketTokenPattern.FindStringSubmatch(subject)
+	if match == nil {
+		return ""
+	}
+	return strings.ToLower(match[1])
+}
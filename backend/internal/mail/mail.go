@@ -0,0 +1,79 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"net/mail"
+	"os"
+	"strconv"
+)
+
+// Address is a mailbox such as "Jane <jane@example.com>".
+type Address = mail.Address
+
+// Message is a rendered email ready to be sent. Emails always carry both a
+// plain text and an HTML body.
+type Message struct {
+	From      Address
+	To        Address
+	Subject   string
+	Text      string
+	HTML      string
+	MessageID string
+	// InReplyTo threads the email under an earlier message, if set.
+	InReplyTo string
+}
+
+// Sender delivers emails.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+var (
+	Mailer Sender
+	// DefaultFrom is the sender of emails of tenants without their own
+	// sender address.
+	DefaultFrom Address
+
+	ErrInvalidAddress = errors.New("invalid email address")
+)
+
+// InitMailer configures Mailer from the SMTP_* environment variables. The
+// defaults target a local SMTP catcher such as MailHog on port 1025.
+func InitMailer() error {
+	port, err := strconv.Atoi(getEnv("SMTP_PORT", "1025"))
+	if err != nil {
+		return errors.New("SMTP_PORT must be a number")
+	}
+	from, err := mail.ParseAddress(getEnv("SMTP_FROM", "Support <no-reply@localhost>"))
+	if err != nil {
+		return errors.New("SMTP_FROM must be an email address")
+	}
+	DefaultFrom = *from
+	Mailer = NewSMTPSender(SMTPConfig{
+		Host:     getEnv("SMTP_HOST", "localhost"),
+		Port:     port,
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		// Implicit TLS, usually on port 465. Otherwise STARTTLS is used when
+		// the server offers it.
+		TLS: getEnv("SMTP_TLS", "false") == "true",
+	})
+	return nil
+}
+
+// ParseAddress parses a single address such as "Jane <jane@example.com>".
+func ParseAddress(address string) (Address, error) {
+	parsed, err := mail.ParseAddress(address)
+	if err != nil {
+		return Address{}, ErrInvalidAddress
+	}
+	return *parsed, nil
+}
+
+func getEnv(key, fallback string) string {
+	if value, exists := os.LookupEnv(key); exists {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,211 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	smtpdCommandTimeout = 5 * time.Minute
+	smtpdMaxRecipients  = 100
+)
+
+// InboundHandler receives a message accepted by the SMTP listener with its
+// envelope sender and recipients. Returning an error rejects the message;
+// ErrRejected rejects it for good, anything else asks the client to retry.
+type InboundHandler func(ctx context.Context, from string, to []string, raw []byte) error
+
+var ErrRejected = errors.New("message rejected")
+
+// SMTPServer is a minimal SMTP listener that hands every message to its
+// handler. It offers neither TLS nor authentication and is meant for local
+// testing of the inbound email gateway; production mail should arrive through
+// the webhook.
+type SMTPServer struct {
+	Addr     string
+	Domain   string
+	MaxBytes int64
+	Handler  InboundHandler
+
+	mu       sync.Mutex
+	listener net.Listener
+	closed   bool
+}
+
+func (s *SMTPServer) ListenAndServe() error {
+	listener, err := net.Listen("tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		listener.Close()
+		return net.ErrClosed
+	}
+	s.listener = listener
+	s.mu.Unlock()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go s.serve(conn)
+	}
+}
+
+func (s *SMTPServer) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.listener != nil {
+		return s.listener.Close()
+	}
+	return nil
+}
+
+type smtpSession struct {
+	// started is set by MAIL; from is empty for bounces.
+	started bool
+	from    string
+	to      []string
+}
+
+func (s *SMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	reply := func(format string, args ...interface{}) bool {
+		conn.SetWriteDeadline(time.Now().Add(smtpdCommandTimeout))
+		return text.PrintfLine(format, args...) == nil
+	}
+
+	domain := s.Domain
+	if domain == "" {
+		domain = "localhost"
+	}
+	reply("220 %s ESMTP ready", domain)
+	var session smtpSession
+	for {
+		conn.SetReadDeadline(time.Now().Add(smtpdCommandTimeout))
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			reply("250-%s", domain)
+			reply("250-SIZE %d", s.MaxBytes)
+			reply("250 8BITMIME")
+		case "HELO":
+			reply("250 %s", domain)
+		case "MAIL":
+			address, ok := envelopeAddress(arg, "FROM:")
+			if !ok {
+				reply("501 Syntax: MAIL FROM:<address>")
+				continue
+			}
+			session = smtpSession{started: true, from: address}
+			reply("250 OK")
+		case "RCPT":
+			address, ok := envelopeAddress(arg, "TO:")
+			switch {
+			case !session.started:
+				reply("503 Need MAIL first")
+			case !ok || address == "":
+				reply("501 Syntax: RCPT TO:<address>")
+			case len(session.to) >= smtpdMaxRecipients:
+				reply("452 Too many recipients")
+			default:
+				session.to = append(session.to, address)
+				reply("250 OK")
+			}
+		case "DATA":
+			if len(session.to) == 0 {
+				reply("503 Need RCPT first")
+				continue
+			}
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			conn.SetReadDeadline(time.Now().Add(smtpdCommandTimeout))
+			raw, err := readData(text.DotReader(), s.MaxBytes)
+			if err != nil {
+				if errors.Is(err, errTooLarge) {
+					reply("552 Message exceeds the size limit")
+					session = smtpSession{}
+					continue
+				}
+				return
+			}
+			if err := s.Handler(context.Background(), session.from, session.to, raw); err != nil {
+				log.Printf("SMTPServer - Rejected message from %s: %v", session.from, err)
+				if errors.Is(err, ErrRejected) {
+					reply("554 %s", firstLine(err.Error()))
+				} else {
+					reply("451 Temporary failure, try again later")
+				}
+			} else {
+				reply("250 OK")
+			}
+			session = smtpSession{}
+		case "RSET":
+			session = smtpSession{}
+			reply("250 OK")
+		case "NOOP":
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+var errTooLarge = errors.New("message too large")
+
+// readData reads a dot-terminated message, draining it past the size limit so
+// the session can continue.
+func readData(r io.Reader, maxBytes int64) ([]byte, error) {
+	raw, err := io.ReadAll(io.LimitReader(r, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(raw)) > maxBytes {
+		if _, err := io.Copy(io.Discard, r); err != nil {
+			return nil, err
+		}
+		return nil, errTooLarge
+	}
+	return raw, nil
+}
+
+// envelopeAddress extracts the address of "FROM:<a@b>" or "TO:<a@b>",
+// ignoring ESMTP parameters after it.
+func envelopeAddress(arg, prefix string) (string, bool) {
+	arg = strings.TrimSpace(arg)
+	if len(arg) < len(prefix) || !strings.EqualFold(arg[:len(prefix)], prefix) {
+		return "", false
+	}
+	arg = strings.TrimSpace(arg[len(prefix):])
+	start, end := strings.Index(arg, "<"), strings.Index(arg, ">")
+	if start != 0 || end < start {
+		return "", false
+	}
+	return arg[start+1 : end], true
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
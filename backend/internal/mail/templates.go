@@ -0,0 +1,79 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Each email template is a pair of files: <name>.txt, which also defines the
+// "subject" template, and <name>.html, which fills the "content" block of
+// layout.html.
+//
+//go:embed templates
+var templateFS embed.FS
+
+type emailTemplate struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+var templates = mustParseTemplates()
+
+// Rendered is the subject and bodies of a rendered email template.
+type Rendered struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+func mustParseTemplates() map[string]emailTemplate {
+	names, err := fs.Glob(templateFS, "templates/*.txt")
+	if err != nil {
+		panic(err)
+	}
+	parsed := make(map[string]emailTemplate, len(names))
+	for _, file := range names {
+		name := strings.TrimSuffix(path.Base(file), ".txt")
+		parsed[name] = emailTemplate{
+			text: texttemplate.Must(texttemplate.ParseFS(templateFS, file)),
+			html: htmltemplate.Must(htmltemplate.ParseFS(templateFS, "templates/layout.html", "templates/"+name+".html")),
+		}
+	}
+	return parsed
+}
+
+// HasTemplate reports whether an email template of that name exists.
+func HasTemplate(name string) bool {
+	_, ok := templates[name]
+	return ok
+}
+
+// Render renders the named email template with data.
+func Render(name string, data interface{}) (Rendered, error) {
+	tmpl, ok := templates[name]
+	if !ok {
+		return Rendered{}, fmt.Errorf("unknown email template %q", name)
+	}
+	var subject, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Rendered{}, fmt.Errorf("failed to render subject of %s: %w", name, err)
+	}
+	if err := tmpl.text.Execute(&text, data); err != nil {
+		return Rendered{}, fmt.Errorf("failed to render text of %s: %w", name, err)
+	}
+	if err := tmpl.html.ExecuteTemplate(&html, "layout.html", data); err != nil {
+		return Rendered{}, fmt.Errorf("failed to render html of %s: %w", name, err)
+	}
+	return Rendered{
+		// Subjects are a single line whatever the template's whitespace.
+		Subject: strings.Join(strings.Fields(subject.String()), " "),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    html.String(),
+	}, nil
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"backend/utils"
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InboundSecretMiddleware authenticates mail provider webhooks with the
+// INBOUND_EMAIL_SECRET shared secret, sent either in the X-Inbound-Secret
+// header or as the basic auth password. Without a configured secret the
+// webhook is disabled.
+func InboundSecretMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		secret := os.Getenv("INBOUND_EMAIL_SECRET")
+		if secret == "" {
+			c.AbortWithStatusJSON(http.StatusNotFound, utils.ErrorResponse("error", "inbound email is not enabled"))
+			return
+		}
+
+		given := c.GetHeader("X-Inbound-Secret")
+		if given == "" {
+			_, given, _ = c.Request.BasicAuth()
+		}
+		if subtle.ConstantTimeCompare([]byte(given), []byte(secret)) != 1 {
+			log.Printf("InboundSecretMiddleware - Rejected inbound email from %s", c.ClientIP())
+			c.AbortWithStatusJSON(http.StatusUnauthorized, utils.ErrorResponse("error", "invalid inbound email secret"))
+			return
+		}
+
+		c.Next()
+	}
+}
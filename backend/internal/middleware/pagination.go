@@ -1,29 +1,47 @@
 package middleware
 
 import (
+	"backend/utils"
 	"log"
+	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
+const maxPageSize = 100
+
+// PaginationMiddleware reads the keyset pagination parameters: size, the
+// opaque cursor returned as next_cursor by the previous page, and
+// include_total to also count all matching rows.
 func PaginationMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		limitStr := c.DefaultQuery("size", "15")
-		offsetStr := c.DefaultQuery("page", "0")
 
 		limit, err := strconv.Atoi(limitStr)
-		if err != nil {
-			log.Printf("PaginationMiddleware - Invalid limit parameter: %v", err)
-			limit = 10
+		if err != nil || limit <= 0 {
+			log.Printf("PaginationMiddleware - Invalid limit parameter: %v", limitStr)
+			limit = 15
+		}
+		if limit > maxPageSize {
+			limit = maxPageSize
 		}
-		offset, err := strconv.Atoi(offsetStr)
-		if err != nil {
-			log.Printf("PaginationMiddleware - Invalid offset parameter: %v", err)
-			offset = 0
+
+		cursor := utils.Cursor{}
+		if cursorStr := c.Query("cursor"); cursorStr != "" {
+			cursor, err = utils.DecodeCursor(cursorStr)
+			if err != nil {
+				log.Printf("PaginationMiddleware - Invalid cursor parameter: %v", err)
+				c.AbortWithStatusJSON(http.StatusBadRequest, utils.ErrorResponse("error", "invalid cursor"))
+				return
+			}
 		}
+
+		includeTotal, _ := strconv.ParseBool(c.Query("include_total"))
+
 		c.Set("size", int32(limit))
-		c.Set("page", int32(offset))
+		c.Set("cursor", cursor)
+		c.Set("include_total", includeTotal)
 		c.Next()
 	}
 }
@@ -0,0 +1,88 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrUnknownCurrency = errors.New("unknown currency")
+
+// Currency is an ISO 4217 alphabetic code, such as "USD".
+type Currency string
+
+// minorUnits are the decimal places of the currencies that do not use two.
+var minorUnits = map[Currency]int32{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0,
+	"KRW": 0, "PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0,
+	"XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// currencies are the codes in circulation that have minor units. Precious
+// metals and testing codes are left out as nothing is invoiced in them.
+var currencies = toSet(`
+	AED AFN ALL AMD ANG AOA ARS AUD AWG AZN BAM BBD BDT BGN BHD BIF BMD BND
+	BOB BOV BRL BSD BTN BWP BYN BZD CAD CDF CHE CHF CHW CLF CLP CNY COP COU
+	CRC CUP CVE CZK DJF DKK DOP DZD EGP ERN ETB EUR FJD FKP GBP GEL GHS GIP
+	GMD GNF GTQ GYD HKD HNL HTG HUF IDR ILS INR IQD IRR ISK JMD JOD JPY KES
+	KGS KHR KMF KPW KRW KWD KYD KZT LAK LBP LKR LRD LSL LYD MAD MDL MGA MKD
+	MMK MNT MOP MRU MUR MVR MWK MXN MXV MYR MZN NAD NGN NIO NOK NPR NZD OMR
+	PAB PEN PGK PHP PKR PLN PYG QAR RON RSD RUB RWF SAR SBD SCR SDG SEK SGD
+	SHP SLE SOS SRD SSP STN SVC SYP SZL THB TJS TMT TND TOP TRY TTD TWD TZS
+	UAH UGX USD USN UYI UYU UYW UZS VED VES VND VUV WST XAF XCD XCG XOF XPF
+	YER ZAR ZMW ZWG
+`)
+
+func toSet(codes string) map[Currency]struct{} {
+	set := make(map[Currency]struct{})
+	for _, code := range strings.Fields(codes) {
+		set[Currency(code)] = struct{}{}
+	}
+	return set
+}
+
+// ParseCurrency reads an ISO 4217 code in any case.
+func ParseCurrency(code string) (Currency, error) {
+	currency := Currency(strings.ToUpper(strings.TrimSpace(code)))
+	if _, ok := currencies[currency]; !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownCurrency, code)
+	}
+	return currency, nil
+}
+
+// MinorUnits is the number of decimal places amounts in c are kept to.
+func (c Currency) MinorUnits() int32 {
+	if places, ok := minorUnits[c]; ok {
+		return places
+	}
+	return 2
+}
+
+// Round rounds d to the minor unit of c.
+func (c Currency) Round(d Decimal) Decimal {
+	return d.Round(c.MinorUnits())
+}
+
+// Fits reports whether d is a whole number of minor units of c.
+func (c Currency) Fits(d Decimal) bool {
+	return d.Places() <= c.MinorUnits()
+}
+
+// Format writes d with the decimal places of c, such as "12.50" for USD and
+// "1250" for JPY.
+func (c Currency) Format(d Decimal) string {
+	return d.StringFixed(c.MinorUnits())
+}
+
+// Money is an amount in a currency.
+type Money struct {
+	Amount   Decimal  `json:"amount"`
+	Currency Currency `json:"currency"`
+}
+
+// String writes m as its currency code and amount, such as "USD 12.50".
+func (m Money) String() string {
+	return fmt.Sprintf("%s %s", m.Currency, m.Currency.Format(m.Amount))
+}
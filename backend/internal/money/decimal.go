@@ -0,0 +1,198 @@
+// Package money holds exact decimal amounts and the ISO 4217 currencies they
+// are in. Amounts never pass through float64, so what a client sends is what
+// is stored, summed and printed.
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"regexp"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var ErrInvalidDecimal = errors.New("invalid decimal")
+
+// decimalPattern is plain decimal notation. Exponents are refused so that a
+// short input cannot ask for an enormous number.
+var decimalPattern = regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)$`)
+
+// Decimal is an exact decimal number. The zero value is 0. It reads from and
+// writes to NUMERIC columns as is, and is encoded in JSON as a string; JSON
+// numbers are accepted too and read from their text, not as floats.
+type Decimal struct {
+	// rat is nil for zero and otherwise always a terminating decimal: every
+	// operation below keeps it one.
+	rat *big.Rat
+}
+
+// NewDecimal returns value × 10^exp, so NewDecimal(1250, -2) is 12.50.
+func NewDecimal(value int64, exp int32) Decimal {
+	return Decimal{rat: new(big.Rat).SetInt64(value)}.Shift(exp)
+}
+
+// ParseDecimal reads a decimal written in plain notation, such as "-12.50".
+func ParseDecimal(s string) (Decimal, error) {
+	if !decimalPattern.MatchString(s) {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+	}
+	return Decimal{rat: r}, nil
+}
+
+func (d Decimal) value() *big.Rat {
+	if d.rat == nil {
+		return new(big.Rat)
+	}
+	return d.rat
+}
+
+func (d Decimal) Add(o Decimal) Decimal {
+	return Decimal{rat: new(big.Rat).Add(d.value(), o.value())}
+}
+
+func (d Decimal) Sub(o Decimal) Decimal {
+	return Decimal{rat: new(big.Rat).Sub(d.value(), o.value())}
+}
+
+func (d Decimal) Mul(o Decimal) Decimal {
+	return Decimal{rat: new(big.Rat).Mul(d.value(), o.value())}
+}
+
+func (d Decimal) Neg() Decimal {
+	return Decimal{rat: new(big.Rat).Neg(d.value())}
+}
+
+// Shift moves the decimal point n places to the right, or to the left when n
+// is negative: Shift(-2) turns a percentage into a fraction.
+func (d Decimal) Shift(n int32) Decimal {
+	factor := new(big.Rat).SetInt(pow10(abs(n)))
+	if n < 0 {
+		return Decimal{rat: new(big.Rat).Quo(d.value(), factor)}
+	}
+	return Decimal{rat: new(big.Rat).Mul(d.value(), factor)}
+}
+
+// Round rounds d to the given number of decimal places, halves away from
+// zero.
+func (d Decimal) Round(places int32) Decimal {
+	scaled := d.Shift(places).value()
+	quo, rem := new(big.Int).QuoRem(scaled.Num(), scaled.Denom(), new(big.Int))
+	// Twice the remainder against the denominator tells which half it is in.
+	if new(big.Int).Abs(new(big.Int).Lsh(rem, 1)).Cmp(scaled.Denom()) >= 0 {
+		if rem.Sign() < 0 {
+			quo.Sub(quo, big.NewInt(1))
+		} else {
+			quo.Add(quo, big.NewInt(1))
+		}
+	}
+	return Decimal{rat: new(big.Rat).SetInt(quo)}.Shift(-places)
+}
+
+// Places is the number of decimal places d needs to be written exactly.
+func (d Decimal) Places() int32 {
+	var places int32
+	for r := d.value(); !r.IsInt(); r = new(big.Rat).Mul(r, big.NewRat(10, 1)) {
+		places++
+	}
+	return places
+}
+
+func (d Decimal) Sign() int {
+	return d.value().Sign()
+}
+
+func (d Decimal) Cmp(o Decimal) int {
+	return d.value().Cmp(o.value())
+}
+
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Float64 is the nearest float64 to d. It is only meant for range checks.
+func (d Decimal) Float64() float64 {
+	f, _ := d.value().Float64()
+	return f
+}
+
+// String writes d exactly, with as many decimal places as it needs.
+func (d Decimal) String() string {
+	return d.value().FloatString(int(d.Places()))
+}
+
+// StringFixed writes d rounded to exactly the given number of decimal places.
+func (d Decimal) StringFixed(places int32) string {
+	return d.Round(places).value().FloatString(int(places))
+}
+
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	text := string(data)
+	if text == "null" {
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+	}
+	parsed, err := ParseDecimal(text)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// NumericValue lets pgx write d to a NUMERIC column.
+func (d Decimal) NumericValue() (pgtype.Numeric, error) {
+	places := d.Places()
+	return pgtype.Numeric{Int: d.Shift(places).value().Num(), Exp: -places, Valid: true}, nil
+}
+
+// ScanNumeric lets pgx read a NUMERIC column into d.
+func (d *Decimal) ScanNumeric(n pgtype.Numeric) error {
+	if !n.Valid {
+		return errors.New("cannot scan NULL into money.Decimal")
+	}
+	if n.NaN || n.InfinityModifier != pgtype.Finite {
+		return fmt.Errorf("%w: cannot scan NaN or infinity", ErrInvalidDecimal)
+	}
+	if n.Int == nil {
+		*d = Decimal{}
+		return nil
+	}
+	*d = Decimal{rat: new(big.Rat).SetInt(n.Int)}.Shift(n.Exp)
+	return nil
+}
+
+// ValidationValue lets validator tags such as gt=0 or lte=100 check a
+// Decimal by handing them its value as a float64. Register it with the
+// validator's RegisterCustomTypeFunc.
+func ValidationValue(field reflect.Value) interface{} {
+	if d, ok := field.Interface().(Decimal); ok {
+		return d.Float64()
+	}
+	return nil
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+func abs(n int32) int32 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,189 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func mustParse(t *testing.T, s string) Decimal {
+	t.Helper()
+	d, err := ParseDecimal(s)
+	if err != nil {
+		t.Fatalf("ParseDecimal(%q): %v", s, err)
+	}
+	return d
+}
+
+func TestDecimalRound(t *testing.T) {
+	tests := []struct {
+		in     string
+		places int32
+		want   string
+	}{
+		{"1.005", 2, "1.01"},
+		{"1.004", 2, "1"},
+		{"2.5", 0, "3"},
+		{"3.5", 0, "4"},
+		{"-2.5", 0, "-3"},
+		{"-1.005", 2, "-1.01"},
+		{"-1.0049", 2, "-1"},
+		{"0.0005", 3, "0.001"},
+		{"-0.0005", 3, "-0.001"},
+		{"12.345", 0, "12"},
+		{"1250", -2, "1300"},
+		{"0", 2, "0"},
+	}
+	for _, tt := range tests {
+		if got := mustParse(t, tt.in).Round(tt.places).String(); got != tt.want {
+			t.Errorf("%s.Round(%d) = %s, want %s", tt.in, tt.places, got, tt.want)
+		}
+	}
+}
+
+func TestDecimalPlaces(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int32
+	}{
+		{"0", 0},
+		{"12", 0},
+		{"12.50", 1},
+		{"12.05", 2},
+		{"-0.0001", 4},
+		{"1000.000", 0},
+	}
+	for _, tt := range tests {
+		if got := mustParse(t, tt.in).Places(); got != tt.want {
+			t.Errorf("%s.Places() = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDecimalNumericRoundTrip(t *testing.T) {
+	m := pgtype.NewMap()
+
+	// Postgres sends a NUMERIC(19,4) column with all four places.
+	for _, column := range []string{"12.5000", "-0.0100", "0.0000", "999999999999999.9999"} {
+		var scanned Decimal
+		if err := m.Scan(pgtype.NumericOID, pgtype.TextFormatCode, []byte(column), &scanned); err != nil {
+			t.Fatalf("scan %s: %v", column, err)
+		}
+		if want := mustParse(t, column); scanned.Cmp(want) != 0 {
+			t.Errorf("scanned %s as %s", column, scanned)
+		}
+
+		encoded, err := m.Encode(pgtype.NumericOID, pgtype.BinaryFormatCode, scanned, nil)
+		if err != nil {
+			t.Fatalf("encode %s: %v", scanned, err)
+		}
+		var back Decimal
+		if err := m.Scan(pgtype.NumericOID, pgtype.BinaryFormatCode, encoded, &back); err != nil {
+			t.Fatalf("scan encoded %s: %v", scanned, err)
+		}
+		if back.Cmp(scanned) != 0 {
+			t.Errorf("round trip of %s gave %s", scanned, back)
+		}
+	}
+
+	var d Decimal
+	if err := d.ScanNumeric(pgtype.Numeric{}); err == nil {
+		t.Error("scanning NULL succeeded")
+	}
+	if err := d.ScanNumeric(pgtype.Numeric{NaN: true, Valid: true}); !errors.Is(err, ErrInvalidDecimal) {
+		t.Errorf("scanning NaN returned %v", err)
+	}
+}
+
+func TestDecimalUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{`"12.50"`, "12.5", false},
+		{`12.50`, "12.5", false},
+		{`"-0.1"`, "-0.1", false},
+		{`0.1`, "0.1", false},
+		{`"1e3"`, "", true},
+		{`1e3`, "", true},
+		{`1E-2`, "", true},
+		{`"12,50"`, "", true},
+		{`""`, "", true},
+		{`true`, "", true},
+	}
+	for _, tt := range tests {
+		var d Decimal
+		err := json.Unmarshal([]byte(tt.in), &d)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Unmarshal(%s) = %s, want an error", tt.in, d)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unmarshal(%s): %v", tt.in, err)
+			continue
+		}
+		if got := d.String(); got != tt.want {
+			t.Errorf("Unmarshal(%s) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+
+	var body struct {
+		Amount Decimal `json:"amount"`
+	}
+	if err := json.Unmarshal([]byte(`{"amount": null}`), &body); err != nil || !body.Amount.IsZero() {
+		t.Errorf("Unmarshal(null) = %s, %v", body.Amount, err)
+	}
+}
+
+func TestParseCurrency(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Currency
+		wantErr bool
+	}{
+		{"USD", "USD", false},
+		{" eur ", "EUR", false},
+		{"jpy", "JPY", false},
+		{"XAU", "", true},
+		{"US", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseCurrency(tt.in)
+		if tt.wantErr {
+			if !errors.Is(err, ErrUnknownCurrency) {
+				t.Errorf("ParseCurrency(%q) = %s, %v, want ErrUnknownCurrency", tt.in, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseCurrency(%q) = %s, %v, want %s", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestCurrencyMinorUnits(t *testing.T) {
+	tests := []struct {
+		currency Currency
+		want     int32
+		format   string
+	}{
+		{"JPY", 0, "1250"},
+		{"USD", 2, "1250.00"},
+		{"KWD", 3, "1250.000"},
+		{"CLF", 4, "1250.0000"},
+	}
+	for _, tt := range tests {
+		if got := tt.currency.MinorUnits(); got != tt.want {
+			t.Errorf("%s.MinorUnits() = %d, want %d", tt.currency, got, tt.want)
+		}
+		if got := tt.currency.Format(NewDecimal(1250, 0)); got != tt.format {
+			t.Errorf("%s.Format(1250) = %s, want %s", tt.currency, got, tt.format)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -58,6 +59,12 @@ func GetDB() *Queries {
 	return queries
 }
 
+// BeginTx starts a transaction on the shared pool; use Queries.WithTx to run
+// generated queries inside it.
+func BeginTx(ctx context.Context) (pgx.Tx, error) {
+	return dbPool.Begin(ctx)
+}
+
 func Close() {
 	if dbPool != nil {
 		dbPool.Close()
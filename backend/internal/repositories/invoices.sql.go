@@ -8,28 +8,61 @@ package repositories
 import (
 	"context"
 
+	"backend/internal/money"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countInvoicesByTenantID = `-- name: CountInvoicesByTenantID :one
+SELECT COUNT(*)
+FROM invoice.invoices AS i
+JOIN ticket.tickets AS t ON i.ticket_id = t.id
+WHERE t.tenant_id = $1 AND i.deleted_at IS NULL
+`
+
+func (q *Queries) CountInvoicesByTenantID(ctx context.Context, tenantID pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countInvoicesByTenantID, tenantID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countPaymentsByInvoiceID = `-- name: CountPaymentsByInvoiceID :one
+SELECT COUNT(*) FROM invoice.payments
+WHERE invoice_id = $1
+`
+
+func (q *Queries) CountPaymentsByInvoiceID(ctx context.Context, invoiceID pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countPaymentsByInvoiceID, invoiceID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createInvoice = `-- name: CreateInvoice :one
 INSERT INTO invoice.invoices (
     ticket_id,
     amount,
     currency,
     status,
-    due_date
+    due_date,
+    subtotal,
+    discount_total,
+    tax_total
 ) VALUES (
-    $1, $2, $3, $4, $5
+    $1, $2, $3, $4, $5, $6, $7, $8
 )
-RETURNING id, ticket_id, amount, currency, status, due_date, created_at, updated_at
+RETURNING id, ticket_id, amount, currency, status, due_date, created_at, updated_at, deleted_at, pdf_status, pdf_path, pdf_error, pdf_generated_at, subtotal, discount_total, tax_total, amount_paid, amount_credited, amount_refunded, balance_due
 `
 
 type CreateInvoiceParams struct {
-	TicketID pgtype.UUID              `json:"ticket_id"`
-	Amount   pgtype.Numeric           `json:"amount"`
-	Currency string                   `json:"currency"`
-	Status   NullInvoiceInvoiceStatus `json:"status"`
-	DueDate  pgtype.Date              `json:"due_date"`
+	TicketID      pgtype.UUID              `json:"ticket_id"`
+	Amount        money.Decimal            `json:"amount"`
+	Currency      string                   `json:"currency"`
+	Status        NullInvoiceInvoiceStatus `json:"status"`
+	DueDate       pgtype.Date              `json:"due_date"`
+	Subtotal      money.Decimal            `json:"subtotal"`
+	DiscountTotal money.Decimal            `json:"discount_total"`
+	TaxTotal      money.Decimal            `json:"tax_total"`
 }
 
 func (q *Queries) CreateInvoice(ctx context.Context, arg CreateInvoiceParams) (InvoiceInvoice, error) {
@@ -39,6 +72,9 @@ func (q *Queries) CreateInvoice(ctx context.Context, arg CreateInvoiceParams) (I
 		arg.Currency,
 		arg.Status,
 		arg.DueDate,
+		arg.Subtotal,
+		arg.DiscountTotal,
+		arg.TaxTotal,
 	)
 	var i InvoiceInvoice
 	err := row.Scan(
@@ -50,6 +86,80 @@ func (q *Queries) CreateInvoice(ctx context.Context, arg CreateInvoiceParams) (I
 		&i.DueDate,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.PdfStatus,
+		&i.PdfPath,
+		&i.PdfError,
+		&i.PdfGeneratedAt,
+		&i.Subtotal,
+		&i.DiscountTotal,
+		&i.TaxTotal,
+		&i.AmountPaid,
+		&i.AmountCredited,
+		&i.AmountRefunded,
+		&i.BalanceDue,
+	)
+	return i, err
+}
+
+const createInvoiceLine = `-- name: CreateInvoiceLine :one
+INSERT INTO invoice.invoice_lines (
+    invoice_id,
+    position,
+    description,
+    quantity,
+    unit_price,
+    tax_rate,
+    discount,
+    subtotal,
+    tax_amount,
+    total
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+)
+RETURNING id, invoice_id, position, description, quantity, unit_price, tax_rate, discount, subtotal, tax_amount, total, created_at
+`
+
+type CreateInvoiceLineParams struct {
+	InvoiceID   pgtype.UUID   `json:"invoice_id"`
+	Position    int32         `json:"position"`
+	Description string        `json:"description"`
+	Quantity    money.Decimal `json:"quantity"`
+	UnitPrice   money.Decimal `json:"unit_price"`
+	TaxRate     money.Decimal `json:"tax_rate"`
+	Discount    money.Decimal `json:"discount"`
+	Subtotal    money.Decimal `json:"subtotal"`
+	TaxAmount   money.Decimal `json:"tax_amount"`
+	Total       money.Decimal `json:"total"`
+}
+
+func (q *Queries) CreateInvoiceLine(ctx context.Context, arg CreateInvoiceLineParams) (InvoiceInvoiceLine, error) {
+	row := q.db.QueryRow(ctx, createInvoiceLine,
+		arg.InvoiceID,
+		arg.Position,
+		arg.Description,
+		arg.Quantity,
+		arg.UnitPrice,
+		arg.TaxRate,
+		arg.Discount,
+		arg.Subtotal,
+		arg.TaxAmount,
+		arg.Total,
+	)
+	var i InvoiceInvoiceLine
+	err := row.Scan(
+		&i.ID,
+		&i.InvoiceID,
+		&i.Position,
+		&i.Description,
+		&i.Quantity,
+		&i.UnitPrice,
+		&i.TaxRate,
+		&i.Discount,
+		&i.Subtotal,
+		&i.TaxAmount,
+		&i.Total,
+		&i.CreatedAt,
 	)
 	return i, err
 }
@@ -69,7 +179,7 @@ RETURNING id, invoice_id, amount, payment_date, method, status, created_at, upda
 
 type CreatePaymentParams struct {
 	InvoiceID   pgtype.UUID              `json:"invoice_id"`
-	Amount      pgtype.Numeric           `json:"amount"`
+	Amount      money.Decimal            `json:"amount"`
 	PaymentDate pgtype.Timestamptz       `json:"payment_date"`
 	Method      NullInvoicePaymentMethod `json:"method"`
 	Status      NullInvoicePaymentStatus `json:"status"`
@@ -108,7 +218,7 @@ func (q *Queries) DeleteInvoice(ctx context.Context, id pgtype.UUID) error {
 }
 
 const getInvoiceByID = `-- name: GetInvoiceByID :one
-SELECT id, ticket_id, amount, currency, status, due_date, created_at, updated_at FROM invoice.invoices
+SELECT id, ticket_id, amount, currency, status, due_date, created_at, updated_at, deleted_at, pdf_status, pdf_path, pdf_error, pdf_generated_at, subtotal, discount_total, tax_total, amount_paid, amount_credited, amount_refunded, balance_due FROM invoice.invoices
 WHERE id = $1 AND deleted_at IS NULL
 `
 
@@ -124,10 +234,136 @@ func (q *Queries) GetInvoiceByID(ctx context.Context, id pgtype.UUID) (InvoiceIn
 		&i.DueDate,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.PdfStatus,
+		&i.PdfPath,
+		&i.PdfError,
+		&i.PdfGeneratedAt,
+		&i.Subtotal,
+		&i.DiscountTotal,
+		&i.TaxTotal,
+		&i.AmountPaid,
+		&i.AmountCredited,
+		&i.AmountRefunded,
+		&i.BalanceDue,
+	)
+	return i, err
+}
+
+const getInvoiceByIDForUpdate = `-- name: GetInvoiceByIDForUpdate :one
+SELECT id, ticket_id, amount, currency, status, due_date, created_at, updated_at, deleted_at, pdf_status, pdf_path, pdf_error, pdf_generated_at, subtotal, discount_total, tax_total, amount_paid, amount_credited, amount_refunded, balance_due FROM invoice.invoices
+WHERE id = $1 AND deleted_at IS NULL
+FOR UPDATE
+`
+
+func (q *Queries) GetInvoiceByIDForUpdate(ctx context.Context, id pgtype.UUID) (InvoiceInvoice, error) {
+	row := q.db.QueryRow(ctx, getInvoiceByIDForUpdate, id)
+	var i InvoiceInvoice
+	err := row.Scan(
+		&i.ID,
+		&i.TicketID,
+		&i.Amount,
+		&i.Currency,
+		&i.Status,
+		&i.DueDate,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.PdfStatus,
+		&i.PdfPath,
+		&i.PdfError,
+		&i.PdfGeneratedAt,
+		&i.Subtotal,
+		&i.DiscountTotal,
+		&i.TaxTotal,
+		&i.AmountPaid,
+		&i.AmountCredited,
+		&i.AmountRefunded,
+		&i.BalanceDue,
+	)
+	return i, err
+}
+
+const getInvoiceDocument = `-- name: GetInvoiceDocument :one
+SELECT
+    i.id, i.ticket_id, i.amount, i.currency, i.status, i.due_date, i.created_at,
+    i.subtotal, i.discount_total, i.tax_total,
+    t.title AS ticket_title,
+    tn.tenant_name,
+    tn.email AS tenant_email,
+    c.first_name AS customer_first_name,
+    c.last_name AS customer_last_name,
+    c.email AS customer_email
+FROM invoice.invoices AS i
+JOIN ticket.tickets AS t ON i.ticket_id = t.id
+JOIN tenant.tenants AS tn ON t.tenant_id = tn.id
+LEFT JOIN customers AS c ON t.customer_id = c.id
+WHERE i.id = $1 AND i.deleted_at IS NULL
+`
+
+type GetInvoiceDocumentRow struct {
+	ID                pgtype.UUID              `json:"id"`
+	TicketID          pgtype.UUID              `json:"ticket_id"`
+	Amount            money.Decimal            `json:"amount"`
+	Currency          string                   `json:"currency"`
+	Status            NullInvoiceInvoiceStatus `json:"status"`
+	DueDate           pgtype.Date              `json:"due_date"`
+	CreatedAt         pgtype.Timestamptz       `json:"created_at"`
+	Subtotal          money.Decimal            `json:"subtotal"`
+	DiscountTotal     money.Decimal            `json:"discount_total"`
+	TaxTotal          money.Decimal            `json:"tax_total"`
+	TicketTitle       string                   `json:"ticket_title"`
+	TenantName        string                   `json:"tenant_name"`
+	TenantEmail       string                   `json:"tenant_email"`
+	CustomerFirstName pgtype.Text              `json:"customer_first_name"`
+	CustomerLastName  pgtype.Text              `json:"customer_last_name"`
+	CustomerEmail     pgtype.Text              `json:"customer_email"`
+}
+
+func (q *Queries) GetInvoiceDocument(ctx context.Context, id pgtype.UUID) (GetInvoiceDocumentRow, error) {
+	row := q.db.QueryRow(ctx, getInvoiceDocument, id)
+	var i GetInvoiceDocumentRow
+	err := row.Scan(
+		&i.ID,
+		&i.TicketID,
+		&i.Amount,
+		&i.Currency,
+		&i.Status,
+		&i.DueDate,
+		&i.CreatedAt,
+		&i.Subtotal,
+		&i.DiscountTotal,
+		&i.TaxTotal,
+		&i.TicketTitle,
+		&i.TenantName,
+		&i.TenantEmail,
+		&i.CustomerFirstName,
+		&i.CustomerLastName,
+		&i.CustomerEmail,
 	)
 	return i, err
 }
 
+const getInvoicePaymentTotals = `-- name: GetInvoicePaymentTotals :one
+SELECT
+    COALESCE(SUM(amount) FILTER (WHERE status = 'COMPLETED'), 0)::numeric AS completed,
+    COALESCE(SUM(amount) FILTER (WHERE status = 'PENDING'), 0)::numeric AS pending
+FROM invoice.payments
+WHERE invoice_id = $1
+`
+
+type GetInvoicePaymentTotalsRow struct {
+	Completed money.Decimal `json:"completed"`
+	Pending   money.Decimal `json:"pending"`
+}
+
+func (q *Queries) GetInvoicePaymentTotals(ctx context.Context, invoiceID pgtype.UUID) (GetInvoicePaymentTotalsRow, error) {
+	row := q.db.QueryRow(ctx, getInvoicePaymentTotals, invoiceID)
+	var i GetInvoicePaymentTotalsRow
+	err := row.Scan(&i.Completed, &i.Pending)
+	return i, err
+}
+
 const getPaymentByID = `-- name: GetPaymentByID :one
 SELECT id, invoice_id, amount, payment_date, method, status, created_at, updated_at FROM invoice.payments
 WHERE id = $1
@@ -150,7 +386,7 @@ func (q *Queries) GetPaymentByID(ctx context.Context, id pgtype.UUID) (InvoicePa
 }
 
 const listAllInvoices = `-- name: ListAllInvoices :many
-SELECT id, ticket_id, amount, currency, status, due_date, created_at, updated_at
+SELECT id, ticket_id, amount, currency, status, due_date, created_at, updated_at, deleted_at, pdf_status, pdf_path, pdf_error, pdf_generated_at, subtotal, discount_total, tax_total, amount_paid, amount_credited, amount_refunded, balance_due
 FROM invoice.invoices 
 ORDER BY created_at DESC
 LIMIT $1
@@ -180,6 +416,18 @@ func (q *Queries) ListAllInvoices(ctx context.Context, arg ListAllInvoicesParams
 			&i.DueDate,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.PdfStatus,
+			&i.PdfPath,
+			&i.PdfError,
+			&i.PdfGeneratedAt,
+			&i.Subtotal,
+			&i.DiscountTotal,
+			&i.TaxTotal,
+			&i.AmountPaid,
+			&i.AmountCredited,
+			&i.AmountRefunded,
+			&i.BalanceDue,
 		); err != nil {
 			return nil, err
 		}
@@ -231,40 +479,90 @@ func (q *Queries) ListAllPayments(ctx context.Context, arg ListAllPaymentsParams
 	return items, nil
 }
 
+const listInvoiceLinesByInvoiceID = `-- name: ListInvoiceLinesByInvoiceID :many
+SELECT id, invoice_id, position, description, quantity, unit_price, tax_rate, discount, subtotal, tax_amount, total, created_at FROM invoice.invoice_lines
+WHERE invoice_id = $1
+ORDER BY position
+`
+
+func (q *Queries) ListInvoiceLinesByInvoiceID(ctx context.Context, invoiceID pgtype.UUID) ([]InvoiceInvoiceLine, error) {
+	rows, err := q.db.Query(ctx, listInvoiceLinesByInvoiceID, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []InvoiceInvoiceLine{}
+	for rows.Next() {
+		var i InvoiceInvoiceLine
+		if err := rows.Scan(
+			&i.ID,
+			&i.InvoiceID,
+			&i.Position,
+			&i.Description,
+			&i.Quantity,
+			&i.UnitPrice,
+			&i.TaxRate,
+			&i.Discount,
+			&i.Subtotal,
+			&i.TaxAmount,
+			&i.Total,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listInvoicesByTenantID = `-- name: ListInvoicesByTenantID :many
 SELECT 
-    i.id, i.ticket_id, i.amount, i.currency, i.status, i.due_date,
-    i.created_at, i.updated_at,
+    i.id, i.ticket_id, i.amount, i.amount_paid, i.amount_credited, i.amount_refunded, i.balance_due, i.currency, i.status, i.due_date,
+    i.pdf_status, i.created_at, i.updated_at,
     t.title
 FROM invoice.invoices AS i
 JOIN ticket.tickets AS t ON i.ticket_id = t.id
 WHERE t.tenant_id = $1
   AND i.deleted_at IS NULL
-ORDER BY i.created_at DESC
-OFFSET $2
-LIMIT $3
+  AND ($2::timestamptz IS NULL OR (i.created_at, i.id) < ($2, $3::uuid))
+ORDER BY i.created_at DESC, i.id DESC
+LIMIT $4
 `
 
 type ListInvoicesByTenantIDParams struct {
-	TenantID pgtype.UUID `json:"tenant_id"`
-	Offset   int32       `json:"offset"`
-	Limit    int32       `json:"limit"`
+	TenantID        pgtype.UUID        `json:"tenant_id"`
+	CursorCreatedAt pgtype.Timestamptz `json:"cursor_created_at"`
+	CursorID        pgtype.UUID        `json:"cursor_id"`
+	Size            int32              `json:"size"`
 }
 
 type ListInvoicesByTenantIDRow struct {
-	ID        pgtype.UUID              `json:"id"`
-	TicketID  pgtype.UUID              `json:"ticket_id"`
-	Amount    pgtype.Numeric           `json:"amount"`
-	Currency  string                   `json:"currency"`
-	Status    NullInvoiceInvoiceStatus `json:"status"`
-	DueDate   pgtype.Date              `json:"due_date"`
-	CreatedAt pgtype.Timestamptz       `json:"created_at"`
-	UpdatedAt pgtype.Timestamptz       `json:"updated_at"`
-	Title     string                   `json:"title"`
+	ID             pgtype.UUID              `json:"id"`
+	TicketID       pgtype.UUID              `json:"ticket_id"`
+	Amount         money.Decimal            `json:"amount"`
+	AmountPaid     money.Decimal            `json:"amount_paid"`
+	AmountCredited money.Decimal            `json:"amount_credited"`
+	AmountRefunded money.Decimal            `json:"amount_refunded"`
+	BalanceDue     money.Decimal            `json:"balance_due"`
+	Currency       string                   `json:"currency"`
+	Status         NullInvoiceInvoiceStatus `json:"status"`
+	DueDate        pgtype.Date              `json:"due_date"`
+	PdfStatus      string                   `json:"pdf_status"`
+	CreatedAt      pgtype.Timestamptz       `json:"created_at"`
+	UpdatedAt      pgtype.Timestamptz       `json:"updated_at"`
+	Title          string                   `json:"title"`
 }
 
 func (q *Queries) ListInvoicesByTenantID(ctx context.Context, arg ListInvoicesByTenantIDParams) ([]ListInvoicesByTenantIDRow, error) {
-	rows, err := q.db.Query(ctx, listInvoicesByTenantID, arg.TenantID, arg.Offset, arg.Limit)
+	rows, err := q.db.Query(ctx, listInvoicesByTenantID,
+		arg.TenantID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Size,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -276,9 +574,14 @@ func (q *Queries) ListInvoicesByTenantID(ctx context.Context, arg ListInvoicesBy
 			&i.ID,
 			&i.TicketID,
 			&i.Amount,
+			&i.AmountPaid,
+			&i.AmountCredited,
+			&i.AmountRefunded,
+			&i.BalanceDue,
 			&i.Currency,
 			&i.Status,
 			&i.DueDate,
+			&i.PdfStatus,
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.Title,
@@ -295,20 +598,26 @@ func (q *Queries) ListInvoicesByTenantID(ctx context.Context, arg ListInvoicesBy
 
 const listPaymentsByInvoiceID = `-- name: ListPaymentsByInvoiceID :many
 SELECT id, invoice_id, amount, payment_date, method, status, created_at, updated_at FROM invoice.payments
-WHERE invoice_id = $1 
-ORDER BY created_at DESC
-LIMIT $2 
-OFFSET $3
+WHERE invoice_id = $1
+  AND ($2::timestamptz IS NULL OR (created_at, id) < ($2, $3::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT $4
 `
 
 type ListPaymentsByInvoiceIDParams struct {
-	InvoiceID pgtype.UUID `json:"invoice_id"`
-	Limit     int32       `json:"limit"`
-	Offset    int32       `json:"offset"`
+	InvoiceID       pgtype.UUID        `json:"invoice_id"`
+	CursorCreatedAt pgtype.Timestamptz `json:"cursor_created_at"`
+	CursorID        pgtype.UUID        `json:"cursor_id"`
+	Size            int32              `json:"size"`
 }
 
 func (q *Queries) ListPaymentsByInvoiceID(ctx context.Context, arg ListPaymentsByInvoiceIDParams) ([]InvoicePayment, error) {
-	rows, err := q.db.Query(ctx, listPaymentsByInvoiceID, arg.InvoiceID, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, listPaymentsByInvoiceID,
+		arg.InvoiceID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Size,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -336,11 +645,58 @@ func (q *Queries) ListPaymentsByInvoiceID(ctx context.Context, arg ListPaymentsB
 	return items, nil
 }
 
+const nextDocumentNumber = `-- name: NextDocumentNumber :one
+INSERT INTO invoice.document_numbers (tenant_id, kind, last_number)
+VALUES ($1, $2, 1)
+ON CONFLICT (tenant_id, kind) DO UPDATE
+SET last_number = invoice.document_numbers.last_number + 1
+RETURNING last_number
+`
+
+type NextDocumentNumberParams struct {
+	TenantID pgtype.UUID `json:"tenant_id"`
+	Kind     string      `json:"kind"`
+}
+
+func (q *Queries) NextDocumentNumber(ctx context.Context, arg NextDocumentNumberParams) (int32, error) {
+	row := q.db.QueryRow(ctx, nextDocumentNumber, arg.TenantID, arg.Kind)
+	var last_number int32
+	err := row.Scan(&last_number)
+	return last_number, err
+}
+
+const recordInvoicePDF = `-- name: RecordInvoicePDF :exec
+UPDATE invoice.invoices
+SET
+    pdf_status = $1,
+    pdf_path = $2,
+    pdf_error = $3,
+    pdf_generated_at = CASE WHEN $1 = 'GENERATED' THEN timezone('UTC', now()) ELSE pdf_generated_at END
+WHERE id = $4
+`
+
+type RecordInvoicePDFParams struct {
+	PdfStatus string      `json:"pdf_status"`
+	PdfPath   pgtype.Text `json:"pdf_path"`
+	PdfError  pgtype.Text `json:"pdf_error"`
+	ID        pgtype.UUID `json:"id"`
+}
+
+func (q *Queries) RecordInvoicePDF(ctx context.Context, arg RecordInvoicePDFParams) error {
+	_, err := q.db.Exec(ctx, recordInvoicePDF,
+		arg.PdfStatus,
+		arg.PdfPath,
+		arg.PdfError,
+		arg.ID,
+	)
+	return err
+}
+
 const restoreInvoice = `-- name: RestoreInvoice :one
 UPDATE invoice.invoices
 SET deleted_at = NULL
 WHERE id = $1
-RETURNING id, ticket_id, amount, currency, status, due_date, created_at, updated_at
+RETURNING id, ticket_id, amount, currency, status, due_date, created_at, updated_at, deleted_at, pdf_status, pdf_path, pdf_error, pdf_generated_at, subtotal, discount_total, tax_total, amount_paid, amount_credited, amount_refunded, balance_due
 `
 
 func (q *Queries) RestoreInvoice(ctx context.Context, id pgtype.UUID) (InvoiceInvoice, error) {
@@ -355,6 +711,18 @@ func (q *Queries) RestoreInvoice(ctx context.Context, id pgtype.UUID) (InvoiceIn
 		&i.DueDate,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.PdfStatus,
+		&i.PdfPath,
+		&i.PdfError,
+		&i.PdfGeneratedAt,
+		&i.Subtotal,
+		&i.DiscountTotal,
+		&i.TaxTotal,
+		&i.AmountPaid,
+		&i.AmountCredited,
+		&i.AmountRefunded,
+		&i.BalanceDue,
 	)
 	return i, err
 }
@@ -370,6 +738,104 @@ func (q *Queries) SoftDeleteInvoice(ctx context.Context, id pgtype.UUID) error {
 	return err
 }
 
+const syncInvoiceBalance = `-- name: SyncInvoiceBalance :one
+UPDATE invoice.invoices AS i
+SET
+    amount_paid = p.completed,
+    amount_credited = c.credited,
+    status = CASE
+        WHEN i.status = 'PENDING' AND p.completed + c.credited >= i.amount AND p.completed = 0 THEN 'CREDITED'::invoice.invoice_status
+        WHEN i.status = 'PENDING' AND p.completed + c.credited >= i.amount THEN 'PAID'::invoice.invoice_status
+        ELSE i.status
+    END,
+    updated_at = timezone('UTC', now())
+FROM (
+    SELECT COALESCE(SUM(amount), 0) AS completed
+    FROM invoice.payments
+    WHERE invoice_id = $1 AND status = 'COMPLETED'
+) AS p, (
+    SELECT COALESCE(SUM(amount), 0) AS credited
+    FROM invoice.credit_note_applications
+    WHERE invoice_id = $1
+) AS c
+WHERE i.id = $1
+RETURNING i.id, i.ticket_id, i.amount, i.currency, i.status, i.due_date, i.created_at, i.updated_at, i.deleted_at, i.pdf_status, i.pdf_path, i.pdf_error, i.pdf_generated_at, i.subtotal, i.discount_total, i.tax_total, i.amount_paid, i.amount_credited, i.amount_refunded, i.balance_due
+`
+
+func (q *Queries) SyncInvoiceBalance(ctx context.Context, id pgtype.UUID) (InvoiceInvoice, error) {
+	row := q.db.QueryRow(ctx, syncInvoiceBalance, id)
+	var i InvoiceInvoice
+	err := row.Scan(
+		&i.ID,
+		&i.TicketID,
+		&i.Amount,
+		&i.Currency,
+		&i.Status,
+		&i.DueDate,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.PdfStatus,
+		&i.PdfPath,
+		&i.PdfError,
+		&i.PdfGeneratedAt,
+		&i.Subtotal,
+		&i.DiscountTotal,
+		&i.TaxTotal,
+		&i.AmountPaid,
+		&i.AmountCredited,
+		&i.AmountRefunded,
+		&i.BalanceDue,
+	)
+	return i, err
+}
+
+const syncInvoiceRefunds = `-- name: SyncInvoiceRefunds :one
+UPDATE invoice.invoices AS i
+SET
+    amount_refunded = r.refunded,
+    status = CASE
+        WHEN r.refunded >= i.amount_paid THEN 'REFUNDED'::invoice.invoice_status
+        ELSE 'PARTIALLY_REFUNDED'::invoice.invoice_status
+    END,
+    updated_at = timezone('UTC', now())
+FROM (
+    SELECT COALESCE(SUM(amount), 0) AS refunded
+    FROM invoice.refunds
+    WHERE invoice_id = $1
+) AS r
+WHERE i.id = $1
+RETURNING i.id, i.ticket_id, i.amount, i.currency, i.status, i.due_date, i.created_at, i.updated_at, i.deleted_at, i.pdf_status, i.pdf_path, i.pdf_error, i.pdf_generated_at, i.subtotal, i.discount_total, i.tax_total, i.amount_paid, i.amount_credited, i.amount_refunded, i.balance_due
+`
+
+func (q *Queries) SyncInvoiceRefunds(ctx context.Context, id pgtype.UUID) (InvoiceInvoice, error) {
+	row := q.db.QueryRow(ctx, syncInvoiceRefunds, id)
+	var i InvoiceInvoice
+	err := row.Scan(
+		&i.ID,
+		&i.TicketID,
+		&i.Amount,
+		&i.Currency,
+		&i.Status,
+		&i.DueDate,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.PdfStatus,
+		&i.PdfPath,
+		&i.PdfError,
+		&i.PdfGeneratedAt,
+		&i.Subtotal,
+		&i.DiscountTotal,
+		&i.TaxTotal,
+		&i.AmountPaid,
+		&i.AmountCredited,
+		&i.AmountRefunded,
+		&i.BalanceDue,
+	)
+	return i, err
+}
+
 const updateInvoice = `-- name: UpdateInvoice :one
 UPDATE invoice.invoices
 SET
@@ -380,13 +846,13 @@ SET
     status = COALESCE($6, status),
     updated_at = timezone('UTC', now())
 WHERE id = $1 AND deleted_at IS NULL
-RETURNING id, ticket_id, amount, currency, status, due_date, created_at, updated_at
+RETURNING id, ticket_id, amount, currency, status, due_date, created_at, updated_at, deleted_at, pdf_status, pdf_path, pdf_error, pdf_generated_at, subtotal, discount_total, tax_total, amount_paid, amount_credited, amount_refunded, balance_due
 `
 
 type UpdateInvoiceParams struct {
 	ID       pgtype.UUID              `json:"id"`
 	TicketID pgtype.UUID              `json:"ticket_id"`
-	Amount   pgtype.Numeric           `json:"amount"`
+	Amount   money.Decimal            `json:"amount"`
 	Currency string                   `json:"currency"`
 	DueDate  pgtype.Date              `json:"due_date"`
 	Status   NullInvoiceInvoiceStatus `json:"status"`
@@ -411,6 +877,46 @@ func (q *Queries) UpdateInvoice(ctx context.Context, arg UpdateInvoiceParams) (I
 		&i.DueDate,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.PdfStatus,
+		&i.PdfPath,
+		&i.PdfError,
+		&i.PdfGeneratedAt,
+		&i.Subtotal,
+		&i.DiscountTotal,
+		&i.TaxTotal,
+		&i.AmountPaid,
+		&i.AmountCredited,
+		&i.AmountRefunded,
+		&i.BalanceDue,
+	)
+	return i, err
+}
+
+const updatePendingPaymentStatus = `-- name: UpdatePendingPaymentStatus :one
+UPDATE invoice.payments
+SET status = $2
+WHERE id = $1 AND status = 'PENDING'
+RETURNING id, invoice_id, amount, payment_date, method, status, created_at, updated_at
+`
+
+type UpdatePendingPaymentStatusParams struct {
+	ID     pgtype.UUID              `json:"id"`
+	Status NullInvoicePaymentStatus `json:"status"`
+}
+
+func (q *Queries) UpdatePendingPaymentStatus(ctx context.Context, arg UpdatePendingPaymentStatusParams) (InvoicePayment, error) {
+	row := q.db.QueryRow(ctx, updatePendingPaymentStatus, arg.ID, arg.Status)
+	var i InvoicePayment
+	err := row.Scan(
+		&i.ID,
+		&i.InvoiceID,
+		&i.Amount,
+		&i.PaymentDate,
+		&i.Method,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
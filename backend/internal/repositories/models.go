@@ -6,17 +6,22 @@ package repositories
 
 import (
 	"database/sql/driver"
+	"encoding/json"
 	"fmt"
 
+	"backend/internal/money"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type InvoiceInvoiceStatus string
 
 const (
-	InvoiceInvoiceStatusPENDING   InvoiceInvoiceStatus = "PENDING"
-	InvoiceInvoiceStatusPAID      InvoiceInvoiceStatus = "PAID"
-	InvoiceInvoiceStatusCANCELLED InvoiceInvoiceStatus = "CANCELLED"
+	InvoiceInvoiceStatusPENDING           InvoiceInvoiceStatus = "PENDING"
+	InvoiceInvoiceStatusPAID              InvoiceInvoiceStatus = "PAID"
+	InvoiceInvoiceStatusCANCELLED         InvoiceInvoiceStatus = "CANCELLED"
+	InvoiceInvoiceStatusPARTIALLYREFUNDED InvoiceInvoiceStatus = "PARTIALLY_REFUNDED"
+	InvoiceInvoiceStatusREFUNDED          InvoiceInvoiceStatus = "REFUNDED"
+	InvoiceInvoiceStatusCREDITED          InvoiceInvoiceStatus = "CREDITED"
 )
 
 func (e *InvoiceInvoiceStatus) Scan(src interface{}) error {
@@ -285,21 +290,111 @@ type Customer struct {
 	DeletedAt  pgtype.Timestamptz `json:"deleted_at"`
 }
 
+type EmailDelivery struct {
+	ID        pgtype.UUID        `json:"id"`
+	TenantID  pgtype.UUID        `json:"tenant_id"`
+	TicketID  pgtype.UUID        `json:"ticket_id"`
+	Recipient string             `json:"recipient"`
+	Template  string             `json:"template"`
+	Sender    string             `json:"sender"`
+	Subject   string             `json:"subject"`
+	MessageID string             `json:"message_id"`
+	Status    string             `json:"status"`
+	Attempts  int32              `json:"attempts"`
+	LastError pgtype.Text        `json:"last_error"`
+	SentAt    pgtype.Timestamptz `json:"sent_at"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+type InboundEmail struct {
+	ID        pgtype.UUID        `json:"id"`
+	MessageID string             `json:"message_id"`
+	TenantID  pgtype.UUID        `json:"tenant_id"`
+	TicketID  pgtype.UUID        `json:"ticket_id"`
+	CommentID pgtype.UUID        `json:"comment_id"`
+	Sender    string             `json:"sender"`
+	Subject   string             `json:"subject"`
+	Status    string             `json:"status"`
+	Reason    pgtype.Text        `json:"reason"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type InvoiceCreditNote struct {
+	ID              pgtype.UUID        `json:"id"`
+	TenantID        pgtype.UUID        `json:"tenant_id"`
+	Number          string             `json:"number"`
+	InvoiceID       pgtype.UUID        `json:"invoice_id"`
+	CustomerID      pgtype.UUID        `json:"customer_id"`
+	Amount          money.Decimal      `json:"amount"`
+	AmountApplied   money.Decimal      `json:"amount_applied"`
+	AmountRemaining money.Decimal      `json:"amount_remaining"`
+	Currency        string             `json:"currency"`
+	Reason          pgtype.Text        `json:"reason"`
+	CreatedBy       pgtype.UUID        `json:"created_by"`
+	PdfStatus       string             `json:"pdf_status"`
+	PdfPath         pgtype.Text        `json:"pdf_path"`
+	PdfError        pgtype.Text        `json:"pdf_error"`
+	PdfGeneratedAt  pgtype.Timestamptz `json:"pdf_generated_at"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+}
+
+type InvoiceCreditNoteApplication struct {
+	ID           pgtype.UUID        `json:"id"`
+	CreditNoteID pgtype.UUID        `json:"credit_note_id"`
+	InvoiceID    pgtype.UUID        `json:"invoice_id"`
+	Amount       money.Decimal      `json:"amount"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+}
+
+type InvoiceDocumentNumber struct {
+	TenantID   pgtype.UUID `json:"tenant_id"`
+	Kind       string      `json:"kind"`
+	LastNumber int32       `json:"last_number"`
+}
+
 type InvoiceInvoice struct {
-	ID        pgtype.UUID              `json:"id"`
-	TicketID  pgtype.UUID              `json:"ticket_id"`
-	Amount    pgtype.Numeric           `json:"amount"`
-	Currency  string                   `json:"currency"`
-	Status    NullInvoiceInvoiceStatus `json:"status"`
-	DueDate   pgtype.Date              `json:"due_date"`
-	CreatedAt pgtype.Timestamptz       `json:"created_at"`
-	UpdatedAt pgtype.Timestamptz       `json:"updated_at"`
+	ID             pgtype.UUID              `json:"id"`
+	TicketID       pgtype.UUID              `json:"ticket_id"`
+	Amount         money.Decimal            `json:"amount"`
+	Currency       string                   `json:"currency"`
+	Status         NullInvoiceInvoiceStatus `json:"status"`
+	DueDate        pgtype.Date              `json:"due_date"`
+	CreatedAt      pgtype.Timestamptz       `json:"created_at"`
+	UpdatedAt      pgtype.Timestamptz       `json:"updated_at"`
+	DeletedAt      pgtype.Timestamptz       `json:"deleted_at"`
+	PdfStatus      string                   `json:"pdf_status"`
+	PdfPath        pgtype.Text              `json:"pdf_path"`
+	PdfError       pgtype.Text              `json:"pdf_error"`
+	PdfGeneratedAt pgtype.Timestamptz       `json:"pdf_generated_at"`
+	Subtotal       money.Decimal            `json:"subtotal"`
+	DiscountTotal  money.Decimal            `json:"discount_total"`
+	TaxTotal       money.Decimal            `json:"tax_total"`
+	AmountPaid     money.Decimal            `json:"amount_paid"`
+	AmountCredited money.Decimal            `json:"amount_credited"`
+	AmountRefunded money.Decimal            `json:"amount_refunded"`
+	BalanceDue     money.Decimal            `json:"balance_due"`
+}
+
+type InvoiceInvoiceLine struct {
+	ID          pgtype.UUID        `json:"id"`
+	InvoiceID   pgtype.UUID        `json:"invoice_id"`
+	Position    int32              `json:"position"`
+	Description string             `json:"description"`
+	Quantity    money.Decimal      `json:"quantity"`
+	UnitPrice   money.Decimal      `json:"unit_price"`
+	TaxRate     money.Decimal      `json:"tax_rate"`
+	Discount    money.Decimal      `json:"discount"`
+	Subtotal    money.Decimal      `json:"subtotal"`
+	TaxAmount   money.Decimal      `json:"tax_amount"`
+	Total       money.Decimal      `json:"total"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
 }
 
 type InvoicePayment struct {
 	ID          pgtype.UUID              `json:"id"`
 	InvoiceID   pgtype.UUID              `json:"invoice_id"`
-	Amount      pgtype.Numeric           `json:"amount"`
+	Amount      money.Decimal            `json:"amount"`
 	PaymentDate pgtype.Timestamptz       `json:"payment_date"`
 	Method      NullInvoicePaymentMethod `json:"method"`
 	Status      NullInvoicePaymentStatus `json:"status"`
@@ -307,6 +402,53 @@ type InvoicePayment struct {
 	UpdatedAt   pgtype.Timestamptz       `json:"updated_at"`
 }
 
+type InvoiceRefund struct {
+	ID             pgtype.UUID        `json:"id"`
+	TenantID       pgtype.UUID        `json:"tenant_id"`
+	Number         string             `json:"number"`
+	PaymentID      pgtype.UUID        `json:"payment_id"`
+	InvoiceID      pgtype.UUID        `json:"invoice_id"`
+	Amount         money.Decimal      `json:"amount"`
+	Reason         pgtype.Text        `json:"reason"`
+	CreatedBy      pgtype.UUID        `json:"created_by"`
+	PdfStatus      string             `json:"pdf_status"`
+	PdfPath        pgtype.Text        `json:"pdf_path"`
+	PdfError       pgtype.Text        `json:"pdf_error"`
+	PdfGeneratedAt pgtype.Timestamptz `json:"pdf_generated_at"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+}
+
+type Notification struct {
+	ID        pgtype.UUID        `json:"id"`
+	UserID    pgtype.UUID        `json:"user_id"`
+	Type      string             `json:"type"`
+	Data      json.RawMessage    `json:"data"`
+	ReadAt    pgtype.Timestamptz `json:"read_at"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type NotificationPreference struct {
+	UserID    pgtype.UUID        `json:"user_id"`
+	Category  string             `json:"category"`
+	InApp     bool               `json:"in_app"`
+	Email     bool               `json:"email"`
+	Websocket bool               `json:"websocket"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+type NotificationSetting struct {
+	UserID          pgtype.UUID        `json:"user_id"`
+	Timezone        string             `json:"timezone"`
+	QuietHoursStart pgtype.Int2        `json:"quiet_hours_start"`
+	QuietHoursEnd   pgtype.Int2        `json:"quiet_hours_end"`
+	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
+}
+
+type TechnicianSkill struct {
+	UserID   pgtype.UUID `json:"user_id"`
+	Category string      `json:"category"`
+}
+
 type TenantTenant struct {
 	ID         pgtype.UUID        `json:"id"`
 	TenantName string             `json:"tenant_name"`
@@ -318,11 +460,38 @@ type TenantTenant struct {
 	DeletedAt  pgtype.Timestamptz `json:"deleted_at"`
 }
 
+type TenantTenantSetting struct {
+	TenantID           pgtype.UUID        `json:"tenant_id"`
+	AssignmentStrategy string             `json:"assignment_strategy"`
+	CreatedAt          pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt          pgtype.Timestamptz `json:"updated_at"`
+	Timezone           string             `json:"timezone"`
+	BusinessHoursStart int16              `json:"business_hours_start"`
+	BusinessHoursEnd   int16              `json:"business_hours_end"`
+	BusinessDays       []int16            `json:"business_days"`
+	EmailFromName      pgtype.Text        `json:"email_from_name"`
+	EmailFromAddress   pgtype.Text        `json:"email_from_address"`
+	TaxRate            money.Decimal      `json:"tax_rate"`
+}
+
 type TenantTenantUser struct {
 	TenantID pgtype.UUID `json:"tenant_id"`
 	UserID   pgtype.UUID `json:"user_id"`
 }
 
+type TicketAttachment struct {
+	ID           pgtype.UUID        `json:"id"`
+	TicketID     pgtype.UUID        `json:"ticket_id"`
+	CommentID    pgtype.UUID        `json:"comment_id"`
+	UploaderType string             `json:"uploader_type"`
+	UploaderID   pgtype.UUID        `json:"uploader_id"`
+	FileName     string             `json:"file_name"`
+	ContentType  string             `json:"content_type"`
+	SizeBytes    int64              `json:"size_bytes"`
+	StorageKey   string             `json:"storage_key"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+}
+
 type TicketComment struct {
 	ID         pgtype.UUID        `json:"id"`
 	TicketID   pgtype.UUID        `json:"ticket_id"`
@@ -330,20 +499,81 @@ type TicketComment struct {
 	AuthorID   pgtype.UUID        `json:"author_id"`
 	Comment    string             `json:"comment"`
 	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+	Visibility string             `json:"visibility"`
+}
+
+type TicketTicketAssignment struct {
+	ID           pgtype.UUID        `json:"id"`
+	TicketID     pgtype.UUID        `json:"ticket_id"`
+	TechnicianID pgtype.UUID        `json:"technician_id"`
+	Strategy     string             `json:"strategy"`
+	Reason       string             `json:"reason"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+}
+
+type TicketTicketEvent struct {
+	ID        pgtype.UUID        `json:"id"`
+	TicketID  pgtype.UUID        `json:"ticket_id"`
+	TenantID  pgtype.UUID        `json:"tenant_id"`
+	ActorID   pgtype.UUID        `json:"actor_id"`
+	ActorRole string             `json:"actor_role"`
+	EventType string             `json:"event_type"`
+	Field     pgtype.Text        `json:"field"`
+	OldValue  pgtype.Text        `json:"old_value"`
+	NewValue  pgtype.Text        `json:"new_value"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type TicketEscalationLog struct {
+	ID        pgtype.UUID        `json:"id"`
+	RuleID    pgtype.UUID        `json:"rule_id"`
+	TicketID  pgtype.UUID        `json:"ticket_id"`
+	Actions   []string           `json:"actions"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type TicketEscalationRule struct {
+	ID              pgtype.UUID              `json:"id"`
+	TenantID        pgtype.UUID              `json:"tenant_id"`
+	Name            string                   `json:"name"`
+	ConditionType   string                   `json:"condition_type"`
+	MatchPriority   NullTicketTicketPriority `json:"match_priority"`
+	Threshold       int32                    `json:"threshold"`
+	SetPriority     NullTicketTicketPriority `json:"set_priority"`
+	ReassignToAdmin bool                     `json:"reassign_to_admin"`
+	NotifyAdmin     bool                     `json:"notify_admin"`
+	IsActive        bool                     `json:"is_active"`
+	CreatedAt       pgtype.Timestamptz       `json:"created_at"`
+	UpdatedAt       pgtype.Timestamptz       `json:"updated_at"`
+}
+
+type TicketSlaPolicy struct {
+	TenantID             pgtype.UUID          `json:"tenant_id"`
+	Priority             TicketTicketPriority `json:"priority"`
+	FirstResponseMinutes int32                `json:"first_response_minutes"`
+	ResolutionMinutes    int32                `json:"resolution_minutes"`
+	BusinessHoursOnly    bool                 `json:"business_hours_only"`
+	CreatedAt            pgtype.Timestamptz   `json:"created_at"`
+	UpdatedAt            pgtype.Timestamptz   `json:"updated_at"`
 }
 
 type TicketTicket struct {
-	ID          pgtype.UUID        `json:"id"`
-	TenantID    pgtype.UUID        `json:"tenant_id"`
-	CustomerID  pgtype.UUID        `json:"customer_id"`
-	AssignedTo  pgtype.UUID        `json:"assigned_to"`
-	Title       string             `json:"title"`
-	Description pgtype.Text        `json:"description"`
-	Status      string             `json:"status"`
-	Priority    string             `json:"priority"`
-	CreatedAt   pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
-	ClosedAt    pgtype.Timestamptz `json:"closed_at"`
+	ID                 pgtype.UUID        `json:"id"`
+	TenantID           pgtype.UUID        `json:"tenant_id"`
+	CustomerID         pgtype.UUID        `json:"customer_id"`
+	AssignedTo         pgtype.UUID        `json:"assigned_to"`
+	Title              string             `json:"title"`
+	Description        pgtype.Text        `json:"description"`
+	Status             string             `json:"status"`
+	Priority           string             `json:"priority"`
+	CreatedAt          pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt          pgtype.Timestamptz `json:"updated_at"`
+	ClosedAt           pgtype.Timestamptz `json:"closed_at"`
+	Category           pgtype.Text        `json:"category"`
+	FirstResponseDueAt pgtype.Timestamptz `json:"first_response_due_at"`
+	ResolutionDueAt    pgtype.Timestamptz `json:"resolution_due_at"`
+	FirstRespondedAt   pgtype.Timestamptz `json:"first_responded_at"`
+	SlaStatus          pgtype.Text        `json:"sla_status"`
 }
 
 type User struct {
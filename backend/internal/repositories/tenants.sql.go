@@ -8,6 +8,7 @@ package repositories
 import (
 	"context"
 
+	"backend/internal/money"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
@@ -50,6 +51,18 @@ func (q *Queries) AddUserToTenant(ctx context.Context, arg AddUserToTenantParams
 	return err
 }
 
+const countTenants = `-- name: CountTenants :one
+SELECT COUNT(*) FROM tenant.tenants
+WHERE deleted_at IS NULL
+`
+
+func (q *Queries) CountTenants(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, countTenants)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createTenant = `-- name: CreateTenant :one
 INSERT INTO tenant.tenants (
     tenant_name,
@@ -108,6 +121,39 @@ func (q *Queries) DeleteTenant(ctx context.Context, id pgtype.UUID) error {
 	return err
 }
 
+const getAdminIDsFromTenantID = `-- name: GetAdminIDsFromTenantID :many
+SELECT
+    u.id
+FROM
+    public.users AS u
+JOIN
+    tenant.tenant_users AS tu ON u.id = tu.user_id
+WHERE
+    tu.tenant_id = $1 AND u.role = 'Admin'
+ORDER BY
+    u.id
+`
+
+func (q *Queries) GetAdminIDsFromTenantID(ctx context.Context, tenantID pgtype.UUID) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, getAdminIDsFromTenantID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []pgtype.UUID{}
+	for rows.Next() {
+		var id pgtype.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getTechnicianIDsFromTenantID = `-- name: GetTechnicianIDsFromTenantID :many
 SELECT
     u.id
@@ -117,6 +163,8 @@ JOIN
     tenant.tenant_users AS tu ON u.id = tu.user_id
 WHERE
     tu.tenant_id = $1 AND u.role = 'Technician'
+ORDER BY
+    u.id
 `
 
 func (q *Queries) GetTechnicianIDsFromTenantID(ctx context.Context, tenantID pgtype.UUID) ([]pgtype.UUID, error) {
@@ -160,6 +208,27 @@ func (q *Queries) GetTenantByDomain(ctx context.Context, domain string) (TenantT
 	return i, err
 }
 
+const getTenantByEmail = `-- name: GetTenantByEmail :one
+SELECT id, tenant_name, domain, email, is_active, created_at, updated_at, deleted_at FROM tenant.tenants
+WHERE lower(email) = lower($1) AND deleted_at IS NULL
+`
+
+func (q *Queries) GetTenantByEmail(ctx context.Context, lower string) (TenantTenant, error) {
+	row := q.db.QueryRow(ctx, getTenantByEmail, lower)
+	var i TenantTenant
+	err := row.Scan(
+		&i.ID,
+		&i.TenantName,
+		&i.Domain,
+		&i.Email,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
 const getTenantByID = `-- name: GetTenantByID :one
 SELECT id, tenant_name, domain, email, is_active, created_at, updated_at, deleted_at FROM tenant.tenants
 WHERE id = $1 AND deleted_at IS NULL
@@ -202,6 +271,30 @@ func (q *Queries) GetTenantByName(ctx context.Context, tenantName string) (Tenan
 	return i, err
 }
 
+const getTenantSettings = `-- name: GetTenantSettings :one
+SELECT tenant_id, assignment_strategy, created_at, updated_at, timezone, business_hours_start, business_hours_end, business_days, email_from_name, email_from_address, tax_rate FROM tenant.tenant_settings
+WHERE tenant_id = $1
+`
+
+func (q *Queries) GetTenantSettings(ctx context.Context, tenantID pgtype.UUID) (TenantTenantSetting, error) {
+	row := q.db.QueryRow(ctx, getTenantSettings, tenantID)
+	var i TenantTenantSetting
+	err := row.Scan(
+		&i.TenantID,
+		&i.AssignmentStrategy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Timezone,
+		&i.BusinessHoursStart,
+		&i.BusinessHoursEnd,
+		&i.BusinessDays,
+		&i.EmailFromName,
+		&i.EmailFromAddress,
+		&i.TaxRate,
+	)
+	return i, err
+}
+
 const getTenantsByUserID = `-- name: GetTenantsByUserID :many
 SELECT
     t.id,
@@ -307,6 +400,26 @@ func (q *Queries) GetTenantsByUserId(ctx context.Context, userID pgtype.UUID) ([
 	return items, nil
 }
 
+const isTenantMember = `-- name: IsTenantMember :one
+SELECT EXISTS (
+    SELECT 1
+    FROM tenant.tenant_users
+    WHERE tenant_id = $1 AND user_id = $2
+) AS is_member
+`
+
+type IsTenantMemberParams struct {
+	TenantID pgtype.UUID `json:"tenant_id"`
+	UserID   pgtype.UUID `json:"user_id"`
+}
+
+func (q *Queries) IsTenantMember(ctx context.Context, arg IsTenantMemberParams) (bool, error) {
+	row := q.db.QueryRow(ctx, isTenantMember, arg.TenantID, arg.UserID)
+	var is_member bool
+	err := row.Scan(&is_member)
+	return is_member, err
+}
+
 const listAllTenants = `-- name: ListAllTenants :many
 SELECT id, tenant_name, domain, email, is_active, created_at, updated_at, deleted_at FROM tenant.tenants
 ORDER BY created_at DESC
@@ -350,17 +463,19 @@ func (q *Queries) ListAllTenants(ctx context.Context, arg ListAllTenantsParams)
 const listTenants = `-- name: ListTenants :many
 SELECT id, tenant_name, domain, email, is_active, created_at, updated_at, deleted_at FROM tenant.tenants
 WHERE deleted_at IS NULL
-ORDER BY created_at DESC
-LIMIT $1 OFFSET $2
+  AND ($1::timestamptz IS NULL OR (created_at, id) < ($1, $2::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT $3
 `
 
 type ListTenantsParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+	CursorCreatedAt pgtype.Timestamptz `json:"cursor_created_at"`
+	CursorID        pgtype.UUID        `json:"cursor_id"`
+	Size            int32              `json:"size"`
 }
 
 func (q *Queries) ListTenants(ctx context.Context, arg ListTenantsParams) ([]TenantTenant, error) {
-	rows, err := q.db.Query(ctx, listTenants, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, listTenants, arg.CursorCreatedAt, arg.CursorID, arg.Size)
 	if err != nil {
 		return nil, err
 	}
@@ -496,6 +611,27 @@ func (q *Queries) RestoreTenant(ctx context.Context, id pgtype.UUID) (TenantTena
 	return i, err
 }
 
+const sharesTenant = `-- name: SharesTenant :one
+SELECT EXISTS (
+    SELECT 1
+    FROM tenant.tenant_users AS a
+    JOIN tenant.tenant_users AS b ON a.tenant_id = b.tenant_id
+    WHERE a.user_id = $1 AND b.user_id = $2
+) AS shares_tenant
+`
+
+type SharesTenantParams struct {
+	UserID   pgtype.UUID `json:"user_id"`
+	MemberID pgtype.UUID `json:"member_id"`
+}
+
+func (q *Queries) SharesTenant(ctx context.Context, arg SharesTenantParams) (bool, error) {
+	row := q.db.QueryRow(ctx, sharesTenant, arg.UserID, arg.MemberID)
+	var shares_tenant bool
+	err := row.Scan(&shares_tenant)
+	return shares_tenant, err
+}
+
 const softDeleteTenant = `-- name: SoftDeleteTenant :exec
 UPDATE tenant.tenants
 SET deleted_at = timezone('UTC', now())
@@ -548,3 +684,159 @@ func (q *Queries) UpdateTenant(ctx context.Context, arg UpdateTenantParams) (Ten
 	)
 	return i, err
 }
+
+const upsertTenantAssignmentStrategy = `-- name: UpsertTenantAssignmentStrategy :one
+INSERT INTO tenant.tenant_settings (tenant_id, assignment_strategy)
+VALUES ($1, $2)
+ON CONFLICT (tenant_id) DO UPDATE
+SET assignment_strategy = EXCLUDED.assignment_strategy
+RETURNING tenant_id, assignment_strategy, created_at, updated_at, timezone, business_hours_start, business_hours_end, business_days, email_from_name, email_from_address, tax_rate
+`
+
+type UpsertTenantAssignmentStrategyParams struct {
+	TenantID           pgtype.UUID `json:"tenant_id"`
+	AssignmentStrategy string      `json:"assignment_strategy"`
+}
+
+func (q *Queries) UpsertTenantAssignmentStrategy(ctx context.Context, arg UpsertTenantAssignmentStrategyParams) (TenantTenantSetting, error) {
+	row := q.db.QueryRow(ctx, upsertTenantAssignmentStrategy, arg.TenantID, arg.AssignmentStrategy)
+	var i TenantTenantSetting
+	err := row.Scan(
+		&i.TenantID,
+		&i.AssignmentStrategy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Timezone,
+		&i.BusinessHoursStart,
+		&i.BusinessHoursEnd,
+		&i.BusinessDays,
+		&i.EmailFromName,
+		&i.EmailFromAddress,
+		&i.TaxRate,
+	)
+	return i, err
+}
+
+const upsertTenantBusinessHours = `-- name: UpsertTenantBusinessHours :one
+INSERT INTO tenant.tenant_settings (
+    tenant_id,
+    timezone,
+    business_hours_start,
+    business_hours_end,
+    business_days
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (tenant_id) DO UPDATE
+SET
+    timezone = EXCLUDED.timezone,
+    business_hours_start = EXCLUDED.business_hours_start,
+    business_hours_end = EXCLUDED.business_hours_end,
+    business_days = EXCLUDED.business_days
+RETURNING tenant_id, assignment_strategy, created_at, updated_at, timezone, business_hours_start, business_hours_end, business_days, email_from_name, email_from_address, tax_rate
+`
+
+type UpsertTenantBusinessHoursParams struct {
+	TenantID           pgtype.UUID `json:"tenant_id"`
+	Timezone           string      `json:"timezone"`
+	BusinessHoursStart int16       `json:"business_hours_start"`
+	BusinessHoursEnd   int16       `json:"business_hours_end"`
+	BusinessDays       []int16     `json:"business_days"`
+}
+
+func (q *Queries) UpsertTenantBusinessHours(ctx context.Context, arg UpsertTenantBusinessHoursParams) (TenantTenantSetting, error) {
+	row := q.db.QueryRow(ctx, upsertTenantBusinessHours,
+		arg.TenantID,
+		arg.Timezone,
+		arg.BusinessHoursStart,
+		arg.BusinessHoursEnd,
+		arg.BusinessDays,
+	)
+	var i TenantTenantSetting
+	err := row.Scan(
+		&i.TenantID,
+		&i.AssignmentStrategy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Timezone,
+		&i.BusinessHoursStart,
+		&i.BusinessHoursEnd,
+		&i.BusinessDays,
+		&i.EmailFromName,
+		&i.EmailFromAddress,
+		&i.TaxRate,
+	)
+	return i, err
+}
+
+const upsertTenantEmailSender = `-- name: UpsertTenantEmailSender :one
+INSERT INTO tenant.tenant_settings (
+    tenant_id,
+    email_from_name,
+    email_from_address
+) VALUES (
+    $1, $2, $3
+)
+ON CONFLICT (tenant_id) DO UPDATE
+SET
+    email_from_name = EXCLUDED.email_from_name,
+    email_from_address = EXCLUDED.email_from_address
+RETURNING tenant_id, assignment_strategy, created_at, updated_at, timezone, business_hours_start, business_hours_end, business_days, email_from_name, email_from_address, tax_rate
+`
+
+type UpsertTenantEmailSenderParams struct {
+	TenantID         pgtype.UUID `json:"tenant_id"`
+	EmailFromName    pgtype.Text `json:"email_from_name"`
+	EmailFromAddress pgtype.Text `json:"email_from_address"`
+}
+
+func (q *Queries) UpsertTenantEmailSender(ctx context.Context, arg UpsertTenantEmailSenderParams) (TenantTenantSetting, error) {
+	row := q.db.QueryRow(ctx, upsertTenantEmailSender, arg.TenantID, arg.EmailFromName, arg.EmailFromAddress)
+	var i TenantTenantSetting
+	err := row.Scan(
+		&i.TenantID,
+		&i.AssignmentStrategy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Timezone,
+		&i.BusinessHoursStart,
+		&i.BusinessHoursEnd,
+		&i.BusinessDays,
+		&i.EmailFromName,
+		&i.EmailFromAddress,
+		&i.TaxRate,
+	)
+	return i, err
+}
+
+const upsertTenantTaxRate = `-- name: UpsertTenantTaxRate :one
+INSERT INTO tenant.tenant_settings (tenant_id, tax_rate)
+VALUES ($1, $2)
+ON CONFLICT (tenant_id) DO UPDATE
+SET tax_rate = EXCLUDED.tax_rate
+RETURNING tenant_id, assignment_strategy, created_at, updated_at, timezone, business_hours_start, business_hours_end, business_days, email_from_name, email_from_address, tax_rate
+`
+
+type UpsertTenantTaxRateParams struct {
+	TenantID pgtype.UUID   `json:"tenant_id"`
+	TaxRate  money.Decimal `json:"tax_rate"`
+}
+
+func (q *Queries) UpsertTenantTaxRate(ctx context.Context, arg UpsertTenantTaxRateParams) (TenantTenantSetting, error) {
+	row := q.db.QueryRow(ctx, upsertTenantTaxRate, arg.TenantID, arg.TaxRate)
+	var i TenantTenantSetting
+	err := row.Scan(
+		&i.TenantID,
+		&i.AssignmentStrategy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Timezone,
+		&i.BusinessHoursStart,
+		&i.BusinessHoursEnd,
+		&i.BusinessDays,
+		&i.EmailFromName,
+		&i.EmailFromAddress,
+		&i.TaxRate,
+	)
+	return i, err
+}
@@ -0,0 +1,285 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Ticket list sort keys accepted by TicketFilter.SortBy.
+const (
+	TicketSortCreatedAt = "created_at"
+	TicketSortUpdatedAt = "updated_at"
+	TicketSortPriority  = "priority"
+	TicketSortDueAt     = "due_at"
+)
+
+// ticketSortKey is the expression a ticket list is ordered by and the type
+// its text form is cast back to when it comes from a cursor, with a check
+// that a cursor's value casts. NULLs are folded into values that sort last in
+// either direction.
+type ticketSortKey struct {
+	asc   string
+	desc  string
+	cast  string
+	valid func(string) bool
+}
+
+var ticketSortKeys = map[string]ticketSortKey{
+	TicketSortUpdatedAt: {"COALESCE(t.updated_at, t.created_at)", "COALESCE(t.updated_at, t.created_at)", "timestamptz", validTimestamptzText},
+	TicketSortPriority:  {"COALESCE(t.priority, 'MEDIUM')", "COALESCE(t.priority, 'MEDIUM')", "ticket.ticket_priority", validPriorityText},
+	TicketSortDueAt:     {"COALESCE(t.resolution_due_at, 'infinity')", "COALESCE(t.resolution_due_at, '-infinity')", "timestamptz", validTimestamptzText},
+}
+
+// ValidTicketCursorKey reports whether key, the sort value of a client's
+// cursor, is one a list sorted by sortBy can resume from. Lists ordered by
+// created_at resume from the cursor's created_at and ignore the key.
+func ValidTicketCursorKey(sortBy, key string) bool {
+	sortKey, ok := ticketSortKeys[sortBy]
+	if !ok {
+		return true
+	}
+	return sortKey.valid(key)
+}
+
+// validTimestamptzText accepts timestamptz values as Postgres writes them
+// with the ISO date style.
+func validTimestamptzText(s string) bool {
+	if s == "infinity" || s == "-infinity" {
+		return true
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05.999999-07", "2006-01-02 15:04:05.999999-07:00", "2006-01-02 15:04:05.999999-07:00:00"} {
+		if _, err := time.Parse(layout, s); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func validPriorityText(s string) bool {
+	switch s {
+	case "LOW", "MEDIUM", "HIGH", "URGENT", "CRITICAL":
+		return true
+	}
+	return false
+}
+
+// TicketFilter narrows and orders a ticket list. Zero values are ignored.
+// UserID scopes the list to the tickets of the user's tenants and CustomerID
+// to the customer's own tickets; callers set exactly one of them. The list
+// resumes after the cursor row, whose sort value is CursorKey when the list is
+// not ordered by created_at.
+type TicketFilter struct {
+	UserID          pgtype.UUID
+	CustomerID      pgtype.UUID
+	Statuses        []string
+	Priorities      []string
+	AssignedTo      pgtype.UUID
+	TenantID        pgtype.UUID
+	Unassigned      bool
+	CreatedFrom     pgtype.Timestamptz
+	CreatedTo       pgtype.Timestamptz
+	UpdatedFrom     pgtype.Timestamptz
+	UpdatedTo       pgtype.Timestamptz
+	SortBy          string
+	SortAsc         bool
+	CursorKey       string
+	CursorCreatedAt pgtype.Timestamptz
+	CursorID        pgtype.UUID
+	Limit           int32
+}
+
+type FilteredTicketRow struct {
+	ID                 pgtype.UUID        `json:"id"`
+	TenantID           pgtype.UUID        `json:"tenant_id"`
+	CustomerID         pgtype.UUID        `json:"customer_id"`
+	AssignedTo         pgtype.UUID        `json:"assigned_to"`
+	Title              string             `json:"title"`
+	Description        pgtype.Text        `json:"description"`
+	Status             string             `json:"status"`
+	Priority           string             `json:"priority"`
+	CreatedAt          pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt          pgtype.Timestamptz `json:"updated_at"`
+	ClosedAt           pgtype.Timestamptz `json:"closed_at"`
+	FirstResponseDueAt pgtype.Timestamptz `json:"first_response_due_at"`
+	ResolutionDueAt    pgtype.Timestamptz `json:"resolution_due_at"`
+	SlaStatus          pgtype.Text        `json:"sla_status"`
+	CustomerUsername   pgtype.Text        `json:"customer_username"`
+	CustomerFirstName  pgtype.Text        `json:"customer_first_name"`
+	CustomerLastName   pgtype.Text        `json:"customer_last_name"`
+	AssignedUsername   pgtype.Text        `json:"assigned_username"`
+	SortKey            string             `json:"-"`
+}
+
+const filteredTicketsFrom = `
+FROM
+    ticket.tickets AS t
+LEFT JOIN
+    users AS u ON t.assigned_to = u.id
+LEFT JOIN
+    customers AS c ON t.customer_id = c.id
+`
+
+// where builds the WHERE clause of the filter, including the cursor condition
+// when withCursor is set. Values are always passed as arguments, never
+// interpolated.
+func (f TicketFilter) where(withCursor bool) (string, []interface{}) {
+	conditions := []string{}
+	args := []interface{}{}
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if f.UserID.Valid {
+		add("t.tenant_id IN (SELECT tu.tenant_id FROM tenant.tenant_users AS tu WHERE tu.user_id = $%d)", f.UserID)
+	}
+	if f.CustomerID.Valid {
+		add("t.customer_id = $%d", f.CustomerID)
+	}
+	if len(f.Statuses) > 0 {
+		add("t.status::text = ANY($%d::text[])", f.Statuses)
+	}
+	if len(f.Priorities) > 0 {
+		add("t.priority::text = ANY($%d::text[])", f.Priorities)
+	}
+	if f.AssignedTo.Valid {
+		add("t.assigned_to = $%d", f.AssignedTo)
+	}
+	if f.TenantID.Valid {
+		add("t.tenant_id = $%d", f.TenantID)
+	}
+	if f.Unassigned {
+		conditions = append(conditions, "t.assigned_to IS NULL")
+	}
+	if f.CreatedFrom.Valid {
+		add("t.created_at >= $%d", f.CreatedFrom)
+	}
+	if f.CreatedTo.Valid {
+		add("t.created_at < $%d", f.CreatedTo)
+	}
+	if f.UpdatedFrom.Valid {
+		add("t.updated_at >= $%d", f.UpdatedFrom)
+	}
+	if f.UpdatedTo.Valid {
+		add("t.updated_at < $%d", f.UpdatedTo)
+	}
+	if withCursor && f.CursorID.Valid {
+		comparison := "<"
+		if f.SortAsc {
+			comparison = ">"
+		}
+		args = append(args, f.CursorCreatedAt, f.CursorID)
+		row := fmt.Sprintf("(t.created_at, t.id) %s ($%d, $%d::uuid)", comparison, len(args)-1, len(args))
+		if key, ok := ticketSortKeys[f.SortBy]; ok {
+			args = append(args, f.CursorKey)
+			row = fmt.Sprintf("(%s, t.created_at, t.id) %s ($%d::%s, $%d, $%d::uuid)",
+				f.sortExpression(), comparison, len(args), key.cast, len(args)-2, len(args)-1)
+		}
+		conditions = append(conditions, row)
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return "WHERE\n    " + strings.Join(conditions, "\n    AND ") + "\n", args
+}
+
+func (f TicketFilter) sortExpression() string {
+	key, ok := ticketSortKeys[f.SortBy]
+	if !ok {
+		return "t.created_at"
+	}
+	if f.SortAsc {
+		return key.asc
+	}
+	return key.desc
+}
+
+func (f TicketFilter) orderBy() string {
+	direction := "DESC"
+	if f.SortAsc {
+		direction = "ASC"
+	}
+	if _, ok := ticketSortKeys[f.SortBy]; !ok {
+		return fmt.Sprintf("ORDER BY\n    t.created_at %s, t.id %s\n", direction, direction)
+	}
+	return fmt.Sprintf("ORDER BY\n    %s %s, t.created_at %s, t.id %s\n", f.sortExpression(), direction, direction, direction)
+}
+
+func (q *Queries) ListTicketsFiltered(ctx context.Context, f TicketFilter) ([]FilteredTicketRow, error) {
+	where, args := f.where(true)
+	args = append(args, f.Limit)
+	query := `SELECT
+    t.id,
+    t.tenant_id,
+    t.customer_id,
+    t.assigned_to,
+    t.title,
+    t.description,
+    t.status,
+    t.priority,
+    t.created_at,
+    t.updated_at,
+    t.closed_at,
+    t.first_response_due_at,
+    t.resolution_due_at,
+    t.sla_status,
+    c.username AS customer_username,
+    c.first_name AS customer_first_name,
+    c.last_name AS customer_last_name,
+    u.username AS assigned_username,
+    (` + f.sortExpression() + `)::text AS sort_key` + filteredTicketsFrom + where + f.orderBy() +
+		fmt.Sprintf("LIMIT $%d", len(args))
+
+	rows, err := q.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []FilteredTicketRow{}
+	for rows.Next() {
+		var i FilteredTicketRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.CustomerID,
+			&i.AssignedTo,
+			&i.Title,
+			&i.Description,
+			&i.Status,
+			&i.Priority,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ClosedAt,
+			&i.FirstResponseDueAt,
+			&i.ResolutionDueAt,
+			&i.SlaStatus,
+			&i.CustomerUsername,
+			&i.CustomerFirstName,
+			&i.CustomerLastName,
+			&i.AssignedUsername,
+			&i.SortKey,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// CountTicketsFiltered returns the number of tickets matching the filter,
+// ignoring its sort and cursor.
+func (q *Queries) CountTicketsFiltered(ctx context.Context, f TicketFilter) (int64, error) {
+	where, args := f.where(false)
+	row := q.db.QueryRow(ctx, "SELECT COUNT(*)"+filteredTicketsFrom+where, args...)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
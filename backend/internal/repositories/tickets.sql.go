@@ -11,14 +11,33 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countCommentsByTicketID = `-- name: CountCommentsByTicketID :one
+SELECT COUNT(*) FROM ticket.comments
+WHERE ticket_id = $1
+  AND ($2::boolean OR visibility = 'PUBLIC')
+`
+
+type CountCommentsByTicketIDParams struct {
+	TicketID        pgtype.UUID `json:"ticket_id"`
+	IncludeInternal bool        `json:"include_internal"`
+}
+
+func (q *Queries) CountCommentsByTicketID(ctx context.Context, arg CountCommentsByTicketIDParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countCommentsByTicketID, arg.TicketID, arg.IncludeInternal)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createComment = `-- name: CreateComment :one
 INSERT INTO ticket.comments (
     ticket_id,
     author_type,
     author_id,
-    comment
+    comment,
+    visibility
 ) VALUES (
-    $1, $2, $3, $4
+    $1, $2, $3, $4, $5
 )
 RETURNING id
 `
@@ -28,6 +47,7 @@ type CreateCommentParams struct {
 	AuthorType string      `json:"author_type"`
 	AuthorID   pgtype.UUID `json:"author_id"`
 	Comment    string      `json:"comment"`
+	Visibility string      `json:"visibility"`
 }
 
 func (q *Queries) CreateComment(ctx context.Context, arg CreateCommentParams) (pgtype.UUID, error) {
@@ -36,6 +56,7 @@ func (q *Queries) CreateComment(ctx context.Context, arg CreateCommentParams) (p
 		arg.AuthorType,
 		arg.AuthorID,
 		arg.Comment,
+		arg.Visibility,
 	)
 	var id pgtype.UUID
 	err := row.Scan(&id)
@@ -50,9 +71,10 @@ INSERT INTO ticket.tickets (
     title,
     description,
     status,
-    priority
+    priority,
+    category
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7
+    $1, $2, $3, $4, $5, $6, $7, $8
 )
 RETURNING id
 `
@@ -65,6 +87,7 @@ type CreateTicketParams struct {
 	Description pgtype.Text `json:"description"`
 	Status      string      `json:"status"`
 	Priority    string      `json:"priority"`
+	Category    pgtype.Text `json:"category"`
 }
 
 func (q *Queries) CreateTicket(ctx context.Context, arg CreateTicketParams) (pgtype.UUID, error) {
@@ -76,12 +99,41 @@ func (q *Queries) CreateTicket(ctx context.Context, arg CreateTicketParams) (pgt
 		arg.Description,
 		arg.Status,
 		arg.Priority,
+		arg.Category,
 	)
 	var id pgtype.UUID
 	err := row.Scan(&id)
 	return id, err
 }
 
+const createTicketAssignment = `-- name: CreateTicketAssignment :exec
+INSERT INTO ticket.ticket_assignments (
+    ticket_id,
+    technician_id,
+    strategy,
+    reason
+) VALUES (
+    $1, $2, $3, $4
+)
+`
+
+type CreateTicketAssignmentParams struct {
+	TicketID     pgtype.UUID `json:"ticket_id"`
+	TechnicianID pgtype.UUID `json:"technician_id"`
+	Strategy     string      `json:"strategy"`
+	Reason       string      `json:"reason"`
+}
+
+func (q *Queries) CreateTicketAssignment(ctx context.Context, arg CreateTicketAssignmentParams) error {
+	_, err := q.db.Exec(ctx, createTicketAssignment,
+		arg.TicketID,
+		arg.TechnicianID,
+		arg.Strategy,
+		arg.Reason,
+	)
+	return err
+}
+
 const deleteTicket = `-- name: DeleteTicket :exec
 DELETE FROM ticket.tickets
 WHERE id = $1
@@ -92,8 +144,28 @@ func (q *Queries) DeleteTicket(ctx context.Context, id pgtype.UUID) error {
 	return err
 }
 
+const getCommentByID = `-- name: GetCommentByID :one
+SELECT id, ticket_id, author_type, author_id, comment, created_at, visibility FROM ticket.comments
+WHERE id = $1
+`
+
+func (q *Queries) GetCommentByID(ctx context.Context, id pgtype.UUID) (TicketComment, error) {
+	row := q.db.QueryRow(ctx, getCommentByID, id)
+	var i TicketComment
+	err := row.Scan(
+		&i.ID,
+		&i.TicketID,
+		&i.AuthorType,
+		&i.AuthorID,
+		&i.Comment,
+		&i.CreatedAt,
+		&i.Visibility,
+	)
+	return i, err
+}
+
 const getTicketByID = `-- name: GetTicketByID :one
-SELECT id, tenant_id, customer_id, assigned_to, title, description, status, priority, created_at, updated_at, closed_at FROM ticket.tickets
+SELECT id, tenant_id, customer_id, assigned_to, title, description, status, priority, created_at, updated_at, closed_at, category, first_response_due_at, resolution_due_at, first_responded_at, sla_status FROM ticket.tickets
 WHERE id = $1
 `
 
@@ -112,25 +184,36 @@ func (q *Queries) GetTicketByID(ctx context.Context, id pgtype.UUID) (TicketTick
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.ClosedAt,
+		&i.Category,
+		&i.FirstResponseDueAt,
+		&i.ResolutionDueAt,
+		&i.FirstRespondedAt,
+		&i.SlaStatus,
 	)
 	return i, err
 }
 
 const listCommentsByTicketID = `-- name: ListCommentsByTicketID :many
 SELECT 
-    c.id, c.ticket_id, c.author_type, c.author_id, c.comment, c.created_at,
-    u.username
+    c.id, c.ticket_id, c.author_type, c.author_id, c.comment, c.created_at, c.visibility,
+    COALESCE(u.username, cu.username) AS username,
+    COALESCE(u.role::text, 'Customer')::text AS user_role
 FROM ticket.comments c
-LEFT JOIN users u ON c.user_id = u.id
+LEFT JOIN users u ON c.author_type = 'USER' AND c.author_id = u.id
+LEFT JOIN customers cu ON c.author_type = 'CUSTOMER' AND c.author_id = cu.id
 WHERE c.ticket_id = $1
-ORDER BY c.created_at DESC
-LIMIT $2 OFFSET $3
+  AND ($2::boolean OR c.visibility = 'PUBLIC')
+  AND ($3::timestamptz IS NULL OR (c.created_at, c.id) < ($3, $4::uuid))
+ORDER BY c.created_at DESC, c.id DESC
+LIMIT $5
 `
 
 type ListCommentsByTicketIDParams struct {
-	TicketID pgtype.UUID `json:"ticket_id"`
-	Limit    int32       `json:"limit"`
-	Offset   int32       `json:"offset"`
+	TicketID        pgtype.UUID        `json:"ticket_id"`
+	IncludeInternal bool               `json:"include_internal"`
+	CursorCreatedAt pgtype.Timestamptz `json:"cursor_created_at"`
+	CursorID        pgtype.UUID        `json:"cursor_id"`
+	Size            int32              `json:"size"`
 }
 
 type ListCommentsByTicketIDRow struct {
@@ -140,11 +223,19 @@ type ListCommentsByTicketIDRow struct {
 	AuthorID   pgtype.UUID        `json:"author_id"`
 	Comment    string             `json:"comment"`
 	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+	Visibility string             `json:"visibility"`
 	Username   pgtype.Text        `json:"username"`
+	UserRole   string             `json:"user_role"`
 }
 
 func (q *Queries) ListCommentsByTicketID(ctx context.Context, arg ListCommentsByTicketIDParams) ([]ListCommentsByTicketIDRow, error) {
-	rows, err := q.db.Query(ctx, listCommentsByTicketID, arg.TicketID, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, listCommentsByTicketID,
+		arg.TicketID,
+		arg.IncludeInternal,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Size,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -159,7 +250,9 @@ func (q *Queries) ListCommentsByTicketID(ctx context.Context, arg ListCommentsBy
 			&i.AuthorID,
 			&i.Comment,
 			&i.CreatedAt,
+			&i.Visibility,
 			&i.Username,
+			&i.UserRole,
 		); err != nil {
 			return nil, err
 		}
@@ -171,40 +264,130 @@ func (q *Queries) ListCommentsByTicketID(ctx context.Context, arg ListCommentsBy
 	return items, nil
 }
 
-const listTicketsByAssignedTo = `-- name: ListTicketsByAssignedTo :many
-SELECT id, tenant_id, customer_id, assigned_to, title, description, status, priority, created_at, updated_at, closed_at FROM ticket.tickets
-WHERE assigned_to = $1 
-ORDER BY created_at DESC
-LIMIT $2 OFFSET $3
+const listSkilledTechnicianLoadsByTenantID = `-- name: ListSkilledTechnicianLoadsByTenantID :many
+SELECT
+    u.id,
+    COUNT(t.id) AS open_tickets
+FROM users AS u
+JOIN tenant.tenant_users AS tu ON u.id = tu.user_id
+JOIN technician_skills AS s ON s.user_id = u.id
+LEFT JOIN ticket.tickets AS t
+    ON t.assigned_to = u.id AND t.status IN ('OPEN', 'IN_PROGRESS', 'REOPENED')
+WHERE tu.tenant_id = $1 AND u.role = 'Technician' AND u.deleted_at IS NULL AND s.category = $2
+GROUP BY u.id
+ORDER BY open_tickets ASC, u.id
 `
 
-type ListTicketsByAssignedToParams struct {
-	AssignedTo pgtype.UUID `json:"assigned_to"`
-	Limit      int32       `json:"limit"`
-	Offset     int32       `json:"offset"`
+type ListSkilledTechnicianLoadsByTenantIDParams struct {
+	TenantID pgtype.UUID `json:"tenant_id"`
+	Category string      `json:"category"`
+}
+
+type ListSkilledTechnicianLoadsByTenantIDRow struct {
+	ID          pgtype.UUID `json:"id"`
+	OpenTickets int64       `json:"open_tickets"`
 }
 
-func (q *Queries) ListTicketsByAssignedTo(ctx context.Context, arg ListTicketsByAssignedToParams) ([]TicketTicket, error) {
-	rows, err := q.db.Query(ctx, listTicketsByAssignedTo, arg.AssignedTo, arg.Limit, arg.Offset)
+func (q *Queries) ListSkilledTechnicianLoadsByTenantID(ctx context.Context, arg ListSkilledTechnicianLoadsByTenantIDParams) ([]ListSkilledTechnicianLoadsByTenantIDRow, error) {
+	rows, err := q.db.Query(ctx, listSkilledTechnicianLoadsByTenantID, arg.TenantID, arg.Category)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []TicketTicket{}
+	items := []ListSkilledTechnicianLoadsByTenantIDRow{}
 	for rows.Next() {
-		var i TicketTicket
+		var i ListSkilledTechnicianLoadsByTenantIDRow
+		if err := rows.Scan(&i.ID, &i.OpenTickets); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTechnicianLoadsByTenantID = `-- name: ListTechnicianLoadsByTenantID :many
+SELECT
+    u.id,
+    COUNT(t.id) AS open_tickets
+FROM users AS u
+JOIN tenant.tenant_users AS tu ON u.id = tu.user_id
+LEFT JOIN ticket.tickets AS t
+    ON t.assigned_to = u.id AND t.status IN ('OPEN', 'IN_PROGRESS', 'REOPENED')
+WHERE tu.tenant_id = $1 AND u.role = 'Technician' AND u.deleted_at IS NULL
+GROUP BY u.id
+ORDER BY open_tickets ASC, u.id
+`
+
+type ListTechnicianLoadsByTenantIDRow struct {
+	ID          pgtype.UUID `json:"id"`
+	OpenTickets int64       `json:"open_tickets"`
+}
+
+func (q *Queries) ListTechnicianLoadsByTenantID(ctx context.Context, tenantID pgtype.UUID) ([]ListTechnicianLoadsByTenantIDRow, error) {
+	rows, err := q.db.Query(ctx, listTechnicianLoadsByTenantID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListTechnicianLoadsByTenantIDRow{}
+	for rows.Next() {
+		var i ListTechnicianLoadsByTenantIDRow
+		if err := rows.Scan(&i.ID, &i.OpenTickets); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTicketAssignmentsByTicketID = `-- name: ListTicketAssignmentsByTicketID :many
+SELECT
+    a.id,
+    a.ticket_id,
+    a.technician_id,
+    a.strategy,
+    a.reason,
+    a.created_at,
+    u.username AS technician_username
+FROM ticket.ticket_assignments AS a
+LEFT JOIN users AS u ON a.technician_id = u.id
+WHERE a.ticket_id = $1
+ORDER BY a.created_at DESC
+`
+
+type ListTicketAssignmentsByTicketIDRow struct {
+	ID                 pgtype.UUID        `json:"id"`
+	TicketID           pgtype.UUID        `json:"ticket_id"`
+	TechnicianID       pgtype.UUID        `json:"technician_id"`
+	Strategy           string             `json:"strategy"`
+	Reason             string             `json:"reason"`
+	CreatedAt          pgtype.Timestamptz `json:"created_at"`
+	TechnicianUsername pgtype.Text        `json:"technician_username"`
+}
+
+func (q *Queries) ListTicketAssignmentsByTicketID(ctx context.Context, ticketID pgtype.UUID) ([]ListTicketAssignmentsByTicketIDRow, error) {
+	rows, err := q.db.Query(ctx, listTicketAssignmentsByTicketID, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListTicketAssignmentsByTicketIDRow{}
+	for rows.Next() {
+		var i ListTicketAssignmentsByTicketIDRow
 		if err := rows.Scan(
 			&i.ID,
-			&i.TenantID,
-			&i.CustomerID,
-			&i.AssignedTo,
-			&i.Title,
-			&i.Description,
-			&i.Status,
-			&i.Priority,
+			&i.TicketID,
+			&i.TechnicianID,
+			&i.Strategy,
+			&i.Reason,
 			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.ClosedAt,
+			&i.TechnicianUsername,
 		); err != nil {
 			return nil, err
 		}
@@ -292,6 +475,9 @@ SELECT
     t.created_at,
     t.updated_at,
     t.closed_at,
+    t.first_response_due_at,
+    t.resolution_due_at,
+    t.sla_status,
     c.username AS customer_username,
     u.username AS assigned_username
 FROM
@@ -314,19 +500,22 @@ type ListTicketsByCustomerIdParams struct {
 }
 
 type ListTicketsByCustomerIdRow struct {
-	ID               pgtype.UUID        `json:"id"`
-	TenantID         pgtype.UUID        `json:"tenant_id"`
-	CustomerID       pgtype.UUID        `json:"customer_id"`
-	AssignedTo       pgtype.UUID        `json:"assigned_to"`
-	Title            string             `json:"title"`
-	Description      pgtype.Text        `json:"description"`
-	Status           string             `json:"status"`
-	Priority         string             `json:"priority"`
-	CreatedAt        pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt        pgtype.Timestamptz `json:"updated_at"`
-	ClosedAt         pgtype.Timestamptz `json:"closed_at"`
-	CustomerUsername pgtype.Text        `json:"customer_username"`
-	AssignedUsername pgtype.Text        `json:"assigned_username"`
+	ID                 pgtype.UUID        `json:"id"`
+	TenantID           pgtype.UUID        `json:"tenant_id"`
+	CustomerID         pgtype.UUID        `json:"customer_id"`
+	AssignedTo         pgtype.UUID        `json:"assigned_to"`
+	Title              string             `json:"title"`
+	Description        pgtype.Text        `json:"description"`
+	Status             string             `json:"status"`
+	Priority           string             `json:"priority"`
+	CreatedAt          pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt          pgtype.Timestamptz `json:"updated_at"`
+	ClosedAt           pgtype.Timestamptz `json:"closed_at"`
+	FirstResponseDueAt pgtype.Timestamptz `json:"first_response_due_at"`
+	ResolutionDueAt    pgtype.Timestamptz `json:"resolution_due_at"`
+	SlaStatus          pgtype.Text        `json:"sla_status"`
+	CustomerUsername   pgtype.Text        `json:"customer_username"`
+	AssignedUsername   pgtype.Text        `json:"assigned_username"`
 }
 
 func (q *Queries) ListTicketsByCustomerId(ctx context.Context, arg ListTicketsByCustomerIdParams) ([]ListTicketsByCustomerIdRow, error) {
@@ -350,6 +539,9 @@ func (q *Queries) ListTicketsByCustomerId(ctx context.Context, arg ListTicketsBy
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.ClosedAt,
+			&i.FirstResponseDueAt,
+			&i.ResolutionDueAt,
+			&i.SlaStatus,
 			&i.CustomerUsername,
 			&i.AssignedUsername,
 		); err != nil {
@@ -364,7 +556,7 @@ func (q *Queries) ListTicketsByCustomerId(ctx context.Context, arg ListTicketsBy
 }
 
 const listTicketsByPriority = `-- name: ListTicketsByPriority :many
-SELECT id, tenant_id, customer_id, assigned_to, title, description, status, priority, created_at, updated_at, closed_at FROM ticket.tickets
+SELECT id, tenant_id, customer_id, assigned_to, title, description, status, priority, created_at, updated_at, closed_at, category, first_response_due_at, resolution_due_at, first_responded_at, sla_status FROM ticket.tickets
 WHERE tenant_id = $1 AND priority = $2
 ORDER BY created_at DESC
 LIMIT $3
@@ -397,6 +589,11 @@ func (q *Queries) ListTicketsByPriority(ctx context.Context, arg ListTicketsByPr
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.ClosedAt,
+			&i.Category,
+			&i.FirstResponseDueAt,
+			&i.ResolutionDueAt,
+			&i.FirstRespondedAt,
+			&i.SlaStatus,
 		); err != nil {
 			return nil, err
 		}
@@ -409,7 +606,7 @@ func (q *Queries) ListTicketsByPriority(ctx context.Context, arg ListTicketsByPr
 }
 
 const listTicketsByStatus = `-- name: ListTicketsByStatus :many
-SELECT id, tenant_id, customer_id, assigned_to, title, description, status, priority, created_at, updated_at, closed_at FROM ticket.tickets
+SELECT id, tenant_id, customer_id, assigned_to, title, description, status, priority, created_at, updated_at, closed_at, category, first_response_due_at, resolution_due_at, first_responded_at, sla_status FROM ticket.tickets
 WHERE tenant_id = $1 AND status = $2 
 ORDER BY created_at DESC
 LIMIT $3
@@ -442,51 +639,11 @@ func (q *Queries) ListTicketsByStatus(ctx context.Context, arg ListTicketsByStat
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.ClosedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
-}
-
-const listTicketsByTenantID = `-- name: ListTicketsByTenantID :many
-SELECT id, tenant_id, customer_id, assigned_to, title, description, status, priority, created_at, updated_at, closed_at FROM ticket.tickets
-WHERE tenant_id = $1 
-ORDER BY created_at DESC
-LIMIT $2 OFFSET $3
-`
-
-type ListTicketsByTenantIDParams struct {
-	TenantID pgtype.UUID `json:"tenant_id"`
-	Limit    int32       `json:"limit"`
-	Offset   int32       `json:"offset"`
-}
-
-func (q *Queries) ListTicketsByTenantID(ctx context.Context, arg ListTicketsByTenantIDParams) ([]TicketTicket, error) {
-	rows, err := q.db.Query(ctx, listTicketsByTenantID, arg.TenantID, arg.Limit, arg.Offset)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	items := []TicketTicket{}
-	for rows.Next() {
-		var i TicketTicket
-		if err := rows.Scan(
-			&i.ID,
-			&i.TenantID,
-			&i.CustomerID,
-			&i.AssignedTo,
-			&i.Title,
-			&i.Description,
-			&i.Status,
-			&i.Priority,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.ClosedAt,
+			&i.Category,
+			&i.FirstResponseDueAt,
+			&i.ResolutionDueAt,
+			&i.FirstRespondedAt,
+			&i.SlaStatus,
 		); err != nil {
 			return nil, err
 		}
@@ -511,6 +668,9 @@ SELECT
     t.created_at,
     t.updated_at,
     t.closed_at,
+    t.first_response_due_at,
+    t.resolution_due_at,
+    t.sla_status,
     c.first_name AS customer_first_name,
     c.last_name AS customer_last_name,
     u.username AS assigned_username
@@ -536,20 +696,23 @@ type ListTicketsByUserIdParams struct {
 }
 
 type ListTicketsByUserIdRow struct {
-	ID                pgtype.UUID        `json:"id"`
-	TenantID          pgtype.UUID        `json:"tenant_id"`
-	CustomerID        pgtype.UUID        `json:"customer_id"`
-	AssignedTo        pgtype.UUID        `json:"assigned_to"`
-	Title             string             `json:"title"`
-	Description       pgtype.Text        `json:"description"`
-	Status            string             `json:"status"`
-	Priority          string             `json:"priority"`
-	CreatedAt         pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt         pgtype.Timestamptz `json:"updated_at"`
-	ClosedAt          pgtype.Timestamptz `json:"closed_at"`
-	CustomerFirstName string             `json:"customer_first_name"`
-	CustomerLastName  string             `json:"customer_last_name"`
-	AssignedUsername  pgtype.Text        `json:"assigned_username"`
+	ID                 pgtype.UUID        `json:"id"`
+	TenantID           pgtype.UUID        `json:"tenant_id"`
+	CustomerID         pgtype.UUID        `json:"customer_id"`
+	AssignedTo         pgtype.UUID        `json:"assigned_to"`
+	Title              string             `json:"title"`
+	Description        pgtype.Text        `json:"description"`
+	Status             string             `json:"status"`
+	Priority           string             `json:"priority"`
+	CreatedAt          pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt          pgtype.Timestamptz `json:"updated_at"`
+	ClosedAt           pgtype.Timestamptz `json:"closed_at"`
+	FirstResponseDueAt pgtype.Timestamptz `json:"first_response_due_at"`
+	ResolutionDueAt    pgtype.Timestamptz `json:"resolution_due_at"`
+	SlaStatus          pgtype.Text        `json:"sla_status"`
+	CustomerFirstName  string             `json:"customer_first_name"`
+	CustomerLastName   string             `json:"customer_last_name"`
+	AssignedUsername   pgtype.Text        `json:"assigned_username"`
 }
 
 func (q *Queries) ListTicketsByUserId(ctx context.Context, arg ListTicketsByUserIdParams) ([]ListTicketsByUserIdRow, error) {
@@ -573,6 +736,9 @@ func (q *Queries) ListTicketsByUserId(ctx context.Context, arg ListTicketsByUser
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.ClosedAt,
+			&i.FirstResponseDueAt,
+			&i.ResolutionDueAt,
+			&i.SlaStatus,
 			&i.CustomerFirstName,
 			&i.CustomerLastName,
 			&i.AssignedUsername,
@@ -587,18 +753,279 @@ func (q *Queries) ListTicketsByUserId(ctx context.Context, arg ListTicketsByUser
 	return items, nil
 }
 
+const listTicketsForSLACheck = `-- name: ListTicketsForSLACheck :many
+SELECT
+    id,
+    tenant_id,
+    assigned_to,
+    title,
+    status,
+    sla_status,
+    created_at,
+    first_response_due_at,
+    resolution_due_at,
+    first_responded_at
+FROM ticket.tickets
+WHERE status IN ('OPEN', 'IN_PROGRESS', 'REOPENED')
+  AND resolution_due_at IS NOT NULL
+  AND (sla_status IS NULL OR sla_status <> 'BREACHED')
+`
+
+type ListTicketsForSLACheckRow struct {
+	ID                 pgtype.UUID        `json:"id"`
+	TenantID           pgtype.UUID        `json:"tenant_id"`
+	AssignedTo         pgtype.UUID        `json:"assigned_to"`
+	Title              string             `json:"title"`
+	Status             string             `json:"status"`
+	SlaStatus          pgtype.Text        `json:"sla_status"`
+	CreatedAt          pgtype.Timestamptz `json:"created_at"`
+	FirstResponseDueAt pgtype.Timestamptz `json:"first_response_due_at"`
+	ResolutionDueAt    pgtype.Timestamptz `json:"resolution_due_at"`
+	FirstRespondedAt   pgtype.Timestamptz `json:"first_responded_at"`
+}
+
+func (q *Queries) ListTicketsForSLACheck(ctx context.Context) ([]ListTicketsForSLACheckRow, error) {
+	rows, err := q.db.Query(ctx, listTicketsForSLACheck)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListTicketsForSLACheckRow{}
+	for rows.Next() {
+		var i ListTicketsForSLACheckRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.AssignedTo,
+			&i.Title,
+			&i.Status,
+			&i.SlaStatus,
+			&i.CreatedAt,
+			&i.FirstResponseDueAt,
+			&i.ResolutionDueAt,
+			&i.FirstRespondedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markTicketFirstResponse = `-- name: MarkTicketFirstResponse :exec
+UPDATE ticket.tickets
+SET first_responded_at = timezone('UTC', now())
+WHERE id = $1 AND first_responded_at IS NULL
+`
+
+func (q *Queries) MarkTicketFirstResponse(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, markTicketFirstResponse, id)
+	return err
+}
+
+const searchTickets = `-- name: SearchTickets :many
+WITH search AS (
+    SELECT websearch_to_tsquery('english', $1::text) AS q
+),
+comment_matches AS (
+    SELECT DISTINCT ON (cm.ticket_id)
+        cm.ticket_id,
+        cm.id AS comment_id,
+        cm.comment,
+        ts_rank(to_tsvector('english', cm.comment), search.q) AS rank
+    FROM ticket.comments AS cm, search
+    WHERE to_tsvector('english', cm.comment) @@ search.q
+      AND (NOT $2::bool OR cm.visibility = 'PUBLIC')
+    ORDER BY cm.ticket_id, rank DESC
+),
+ranked AS (
+    SELECT
+        t.id,
+        t.tenant_id,
+        t.customer_id,
+        t.title,
+        t.status,
+        t.priority,
+        t.created_at,
+        (COALESCE(ts_rank(ticket.search_document(t.title, t.description), search.q), 0) + COALESCE(cm.rank, 0) * 0.5)::real AS rank,
+        ts_headline('english', ticket.html_escape(t.title || ' ' || COALESCE(t.description, '')), search.q,
+            'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=30, MinWords=10') AS snippet,
+        cm.comment_id AS matched_comment_id,
+        (CASE WHEN cm.comment IS NOT NULL THEN ts_headline('english', ticket.html_escape(cm.comment), search.q,
+            'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=30, MinWords=10') END)::text AS comment_snippet
+    FROM
+        ticket.tickets AS t
+    CROSS JOIN
+        search
+    LEFT JOIN
+        comment_matches AS cm ON cm.ticket_id = t.id
+    WHERE
+        (ticket.search_document(t.title, t.description) @@ search.q OR cm.ticket_id IS NOT NULL)
+        AND (
+            ($2::bool AND t.customer_id = $3)
+            OR (NOT $2::bool AND t.tenant_id IN (
+                SELECT tu.tenant_id FROM tenant.tenant_users AS tu WHERE tu.user_id = $3
+            ))
+        )
+        AND ($4::uuid IS NULL OR t.tenant_id = $4)
+)
+SELECT * FROM ranked
+WHERE $5::real IS NULL
+   OR (ranked.rank, ranked.created_at, ranked.id) < ($5, $6::timestamptz, $7::uuid)
+ORDER BY
+    ranked.rank DESC, ranked.created_at DESC, ranked.id DESC
+LIMIT $8
+`
+
+type SearchTicketsParams struct {
+	Query           string             `json:"query"`
+	IsCustomer      bool               `json:"is_customer"`
+	ActorID         pgtype.UUID        `json:"actor_id"`
+	TenantID        pgtype.UUID        `json:"tenant_id"`
+	CursorRank      pgtype.Float4      `json:"cursor_rank"`
+	CursorCreatedAt pgtype.Timestamptz `json:"cursor_created_at"`
+	CursorID        pgtype.UUID        `json:"cursor_id"`
+	Size            int32              `json:"size"`
+}
+
+type SearchTicketsRow struct {
+	ID               pgtype.UUID        `json:"id"`
+	TenantID         pgtype.UUID        `json:"tenant_id"`
+	CustomerID       pgtype.UUID        `json:"customer_id"`
+	Title            string             `json:"title"`
+	Status           string             `json:"status"`
+	Priority         string             `json:"priority"`
+	CreatedAt        pgtype.Timestamptz `json:"created_at"`
+	Rank             float32            `json:"rank"`
+	Snippet          string             `json:"snippet"`
+	MatchedCommentID pgtype.UUID        `json:"matched_comment_id"`
+	CommentSnippet   pgtype.Text        `json:"comment_snippet"`
+}
+
+func (q *Queries) SearchTickets(ctx context.Context, arg SearchTicketsParams) ([]SearchTicketsRow, error) {
+	rows, err := q.db.Query(ctx, searchTickets,
+		arg.Query,
+		arg.IsCustomer,
+		arg.ActorID,
+		arg.TenantID,
+		arg.CursorRank,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Size,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SearchTicketsRow{}
+	for rows.Next() {
+		var i SearchTicketsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.CustomerID,
+			&i.Title,
+			&i.Status,
+			&i.Priority,
+			&i.CreatedAt,
+			&i.Rank,
+			&i.Snippet,
+			&i.MatchedCommentID,
+			&i.CommentSnippet,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setTicketSLADeadlines = `-- name: SetTicketSLADeadlines :exec
+UPDATE ticket.tickets
+SET
+    first_response_due_at = $2,
+    resolution_due_at = $3,
+    sla_status = $4
+WHERE id = $1
+`
+
+type SetTicketSLADeadlinesParams struct {
+	ID                 pgtype.UUID        `json:"id"`
+	FirstResponseDueAt pgtype.Timestamptz `json:"first_response_due_at"`
+	ResolutionDueAt    pgtype.Timestamptz `json:"resolution_due_at"`
+	SlaStatus          pgtype.Text        `json:"sla_status"`
+}
+
+func (q *Queries) SetTicketSLADeadlines(ctx context.Context, arg SetTicketSLADeadlinesParams) error {
+	_, err := q.db.Exec(ctx, setTicketSLADeadlines,
+		arg.ID,
+		arg.FirstResponseDueAt,
+		arg.ResolutionDueAt,
+		arg.SlaStatus,
+	)
+	return err
+}
+
+const transitionTicketStatus = `-- name: TransitionTicketStatus :one
+UPDATE ticket.tickets
+SET
+    status = $1,
+    updated_at = timezone('UTC', now()),
+    closed_at = CASE
+        WHEN $1 = 'RESOLVED' OR $1 = 'CLOSED' THEN timezone('UTC', now())
+        WHEN $1 = 'REOPENED' THEN NULL
+        ELSE closed_at
+    END
+WHERE id = $2 AND status = $3
+RETURNING id, tenant_id, customer_id, assigned_to, title, description, status, priority, created_at, updated_at, closed_at, category, first_response_due_at, resolution_due_at, first_responded_at, sla_status
+`
+
+type TransitionTicketStatusParams struct {
+	ToStatus   string      `json:"to_status"`
+	ID         pgtype.UUID `json:"id"`
+	FromStatus string      `json:"from_status"`
+}
+
+func (q *Queries) TransitionTicketStatus(ctx context.Context, arg TransitionTicketStatusParams) (TicketTicket, error) {
+	row := q.db.QueryRow(ctx, transitionTicketStatus, arg.ToStatus, arg.ID, arg.FromStatus)
+	var i TicketTicket
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.CustomerID,
+		&i.AssignedTo,
+		&i.Title,
+		&i.Description,
+		&i.Status,
+		&i.Priority,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ClosedAt,
+		&i.Category,
+		&i.FirstResponseDueAt,
+		&i.ResolutionDueAt,
+		&i.FirstRespondedAt,
+		&i.SlaStatus,
+	)
+	return i, err
+}
+
 const updateTicket = `-- name: UpdateTicket :one
 UPDATE ticket.tickets
 SET
     assigned_to = COALESCE($2, assigned_to),
     title = COALESCE($3, title),
     description = COALESCE($4, description),
-    status = COALESCE($5, status),
-    priority = COALESCE($6, priority),
-    updated_at = timezone('UTC', now()),
-    closed_at = CASE WHEN $5 = 'RESOLVED' OR $5 = 'CLOSED' THEN timezone('UTC', now()) ELSE closed_at END
+    priority = COALESCE($5, priority),
+    updated_at = timezone('UTC', now())
 WHERE id = $1 
-RETURNING id, tenant_id, customer_id, assigned_to, title, description, status, priority, created_at, updated_at, closed_at
+RETURNING id, tenant_id, customer_id, assigned_to, title, description, status, priority, created_at, updated_at, closed_at, category, first_response_due_at, resolution_due_at, first_responded_at, sla_status
 `
 
 type UpdateTicketParams struct {
@@ -606,7 +1033,6 @@ type UpdateTicketParams struct {
 	AssignedTo  pgtype.UUID `json:"assigned_to"`
 	Title       string      `json:"title"`
 	Description pgtype.Text `json:"description"`
-	Status      string      `json:"status"`
 	Priority    string      `json:"priority"`
 }
 
@@ -616,7 +1042,6 @@ func (q *Queries) UpdateTicket(ctx context.Context, arg UpdateTicketParams) (Tic
 		arg.AssignedTo,
 		arg.Title,
 		arg.Description,
-		arg.Status,
 		arg.Priority,
 	)
 	var i TicketTicket
@@ -632,6 +1057,27 @@ func (q *Queries) UpdateTicket(ctx context.Context, arg UpdateTicketParams) (Tic
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.ClosedAt,
+		&i.Category,
+		&i.FirstResponseDueAt,
+		&i.ResolutionDueAt,
+		&i.FirstRespondedAt,
+		&i.SlaStatus,
 	)
 	return i, err
 }
+
+const updateTicketSLAStatus = `-- name: UpdateTicketSLAStatus :exec
+UPDATE ticket.tickets
+SET sla_status = $2
+WHERE id = $1
+`
+
+type UpdateTicketSLAStatusParams struct {
+	ID        pgtype.UUID `json:"id"`
+	SlaStatus pgtype.Text `json:"sla_status"`
+}
+
+func (q *Queries) UpdateTicketSLAStatus(ctx context.Context, arg UpdateTicketSLAStatusParams) error {
+	_, err := q.db.Exec(ctx, updateTicketSLAStatus, arg.ID, arg.SlaStatus)
+	return err
+}
@@ -38,6 +38,22 @@ func (q *Queries) ActivateUser(ctx context.Context, id pgtype.UUID) (User, error
 	return i, err
 }
 
+const addTechnicianSkill = `-- name: AddTechnicianSkill :exec
+INSERT INTO technician_skills (user_id, category)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING
+`
+
+type AddTechnicianSkillParams struct {
+	UserID   pgtype.UUID `json:"user_id"`
+	Category string      `json:"category"`
+}
+
+func (q *Queries) AddTechnicianSkill(ctx context.Context, arg AddTechnicianSkillParams) error {
+	_, err := q.db.Exec(ctx, addTechnicianSkill, arg.UserID, arg.Category)
+	return err
+}
+
 const changeUserPassword = `-- name: ChangeUserPassword :one
 UPDATE users
 SET password   = $2,
@@ -122,6 +138,16 @@ func (q *Queries) DeactivateUser(ctx context.Context, id pgtype.UUID) (User, err
 	return i, err
 }
 
+const deleteTechnicianSkills = `-- name: DeleteTechnicianSkills :exec
+DELETE FROM technician_skills
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteTechnicianSkills(ctx context.Context, userID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteTechnicianSkills, userID)
+	return err
+}
+
 const deleteUser = `-- name: DeleteUser :exec
 DELETE FROM users
 WHERE id = $1
@@ -332,6 +358,32 @@ func (q *Queries) ListAllUsers(ctx context.Context, arg ListAllUsersParams) ([]U
 	return items, nil
 }
 
+const listTechnicianSkills = `-- name: ListTechnicianSkills :many
+SELECT category FROM technician_skills
+WHERE user_id = $1
+ORDER BY category
+`
+
+func (q *Queries) ListTechnicianSkills(ctx context.Context, userID pgtype.UUID) ([]string, error) {
+	rows, err := q.db.Query(ctx, listTechnicianSkills, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var category string
+		if err := rows.Scan(&category); err != nil {
+			return nil, err
+		}
+		items = append(items, category)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listUsers = `-- name: ListUsers :many
 SELECT id, username, email, password, role, is_active, is_verified, created_at, updated_at, deleted_at FROM users
 WHERE deleted_at IS NULL
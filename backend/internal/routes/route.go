@@ -1,14 +1,23 @@
 package routes
 
 import (
+	ws "backend/internal/controllers/v1/ws"
+	"backend/internal/money"
 	v1_routes "backend/internal/routes/v1"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
 )
 
-func RegisterRoutes() *gin.Engine {
+func RegisterRoutes(hub *ws.Hub) *gin.Engine {
+	// Decimal amounts are checked by the usual numeric tags (gt, gte, lte).
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterCustomTypeFunc(money.ValidationValue, money.Decimal{})
+	}
+
 	gin_handler := gin.Default()
-	v1_routes.V1RoutesRegister(gin_handler)
+	v1_routes.V1RoutesRegister(gin_handler, hub)
 
 	return gin_handler
 
@@ -0,0 +1,19 @@
+package v1_routes
+
+import (
+	v1_controllers "backend/internal/controllers/v1"
+	"backend/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// attachmentRoutes serves downloads of the local attachment storage. The
+// signed URL authorizes the request, so there is no auth middleware.
+func attachmentRoutes(r *gin.RouterGroup) {
+
+	attachment := r.Group("/attachments")
+	attachment.Use(middleware.DBErrorHandler())
+
+	attachmentController := v1_controllers.NewAttachmentControllerV1()
+	attachment.GET("/download", attachmentController.DownloadAttachment)
+}
@@ -0,0 +1,20 @@
+package v1_routes
+
+import (
+	v1_controllers "backend/internal/controllers/v1"
+	"backend/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// inboundRoutes receive emails forwarded by the mail provider, authenticated
+// by a shared secret rather than a user token.
+func inboundRoutes(r *gin.RouterGroup) {
+
+	inbound := r.Group("/inbound")
+	inbound.Use(middleware.DBErrorHandler())
+	inbound.Use(middleware.InboundSecretMiddleware())
+
+	inboundEmailController := v1_controllers.NewInboundEmailControllerV1()
+	inbound.POST("/email", inboundEmailController.ReceiveEmail)
+}
@@ -12,15 +12,27 @@ func invoiceRoutes(r *gin.RouterGroup) {
 	invoice := r.Group("/invoice")
 	invoice.Use(middleware.ValidationErrorHandler())
 	invoice.Use(middleware.DBErrorHandler())
+	invoice.Use(middleware.AuthMiddleware())
 
 	invoiceController := v1_controllers.NewInvoiceControllerV1()
-	invoice.POST("", invoiceController.CreateInvoice)
+	invoice.POST("", middleware.RoleMiddleware("Admin", "Technician"), invoiceController.CreateInvoice)
 	// invoice.PUT("", invoiceController.UpdateInvoice)
 	// invoice.DELETE("/:id", invoiceController.DeleteInvoice)
-	// invoice.GET("/:id", invoiceController.GetInvoiceByID)
-	// invoice.GET("", invoiceController.ListAllInvoices)
-	// invoice.POST("/payment", invoiceController.CreatePayment)
-	// invoice.GET("/payment/:id", invoiceController.GetPaymentByID)
-	// invoice.GET("/payments", invoiceController.ListAllPayments)
+	invoice.GET("/:id", invoiceController.GetInvoice)
+	invoice.GET("/:id/pdf", invoiceController.GetInvoicePDF)
+	invoice.GET("", middleware.RoleMiddleware("Admin", "Technician"), middleware.PaginationMiddleware(), invoiceController.ListInvoices)
+	invoice.GET("/:id/payments", middleware.PaginationMiddleware(), invoiceController.ListPayments)
+	invoice.POST("/payment", middleware.RoleMiddleware("Admin", "Technician"), invoiceController.CreatePayment)
+	invoice.GET("/payment/:id", invoiceController.GetPayment)
+	invoice.PUT("/payment/:id/status", middleware.RoleMiddleware("Admin", "Technician"), invoiceController.UpdatePaymentStatus)
+	invoice.GET("/:id/refunds", middleware.PaginationMiddleware(), invoiceController.ListRefunds)
+	invoice.POST("/payment/:id/refunds", middleware.RoleMiddleware("Admin", "Technician"), invoiceController.CreateRefund)
+	invoice.GET("/refund/:id", invoiceController.GetRefund)
+	invoice.GET("/refund/:id/pdf", invoiceController.GetRefundPDF)
+	invoice.GET("/:id/credit-notes", middleware.PaginationMiddleware(), invoiceController.ListCreditNotes)
+	invoice.POST("/credit-note", middleware.RoleMiddleware("Admin", "Technician"), invoiceController.CreateCreditNote)
+	invoice.POST("/credit-note/:id/apply", middleware.RoleMiddleware("Admin", "Technician"), invoiceController.ApplyCreditNote)
+	invoice.GET("/credit-note/:id", invoiceController.GetCreditNote)
+	invoice.GET("/credit-note/:id/pdf", invoiceController.GetCreditNotePDF)
 
 }
@@ -0,0 +1,24 @@
+package v1_routes
+
+import (
+	v1_controllers "backend/internal/controllers/v1"
+	ws "backend/internal/controllers/v1/ws"
+	"backend/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func notificationRoutes(r *gin.RouterGroup, hub *ws.Hub) {
+
+	notification := r.Group("/notifications")
+	notification.Use(middleware.DBErrorHandler())
+	notification.Use(middleware.AuthMiddleware())
+
+	notificationController := v1_controllers.NewNotificationControllerV1()
+	notification.GET("", middleware.PaginationMiddleware(), notificationController.ListNotifications)
+	notification.POST("/read-all", notificationController.MarkAllNotificationsRead)
+	notification.POST("/:id/read", notificationController.MarkNotificationRead)
+	notification.GET("/stream", func(c *gin.Context) {
+		ws.ServeUserSSE(hub, c)
+	})
+}
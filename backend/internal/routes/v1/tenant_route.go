@@ -23,6 +23,17 @@ func tenantRoutes(r *gin.RouterGroup) {
 	adminTenant.PUT("", tenantController.UpdateTenant)
 	adminTenant.DELETE("", tenantController.DeleteTenant)
 	adminTenant.POST("add-to", tenantController.AddUserToTenant)
+	adminTenant.PUT("assignment-strategy", tenantController.SetAssignmentStrategy)
+	adminTenant.PUT("business-hours", tenantController.SetBusinessHours)
+	adminTenant.PUT("sla-policy", tenantController.SetSLAPolicy)
+	tenant.GET("/:id/sla-policies", middleware.RoleMiddleware("Admin", "Technician"), tenantController.ListSLAPolicies)
+	adminTenant.PUT("email-sender", tenantController.SetEmailSender)
+	adminTenant.PUT("tax-rate", tenantController.SetTaxRate)
+	adminTenant.GET("/:id/email-deliveries", middleware.PaginationMiddleware(), tenantController.ListEmailDeliveries)
+	adminTenant.POST("escalation-rules", tenantController.CreateEscalationRule)
+	adminTenant.PUT("/:id/escalation-rules/:ruleId", tenantController.SetEscalationRuleActive)
+	adminTenant.DELETE("/:id/escalation-rules/:ruleId", tenantController.DeleteEscalationRule)
+	tenant.GET("/:id/escalation-rules", middleware.RoleMiddleware("Admin", "Technician"), tenantController.ListEscalationRules)
 	tenant.GET("all", middleware.PaginationMiddleware(), middleware.RoleMiddleware("Customer"), tenantController.AllTenants)
 
 	// User routes (authenticated users can see their own tenants)
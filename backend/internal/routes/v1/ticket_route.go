@@ -15,15 +15,30 @@ func ticketRoutes(r *gin.RouterGroup, hub *ws.Hub) {
 	ticket.Use(middleware.DBErrorHandler())
 	ticket.Use(middleware.AuthMiddleware())
 	ticketController := v1_controllers.NewTicketControllerV1()
+	attachmentController := v1_controllers.NewAttachmentControllerV1()
 	ticket.POST("", middleware.RoleMiddleware("Customer"), ticketController.CreateTicket)
 	ticket.PUT("/:id", middleware.RoleMiddleware("Admin"), ticketController.UpdateTicket)
 	ticket.DELETE("/:id", middleware.RoleMiddleware("Admin"), ticketController.DeleteTicket)
 	ticket.GET("/user", middleware.PaginationMiddleware(), middleware.RoleMiddleware("Admin", "Technician"), ticketController.ListTicketsByUserId)
+	ticket.GET("/search", middleware.PaginationMiddleware(), ticketController.SearchTickets)
 	ticket.GET("/:id", ticketController.GetTicket)
-	ticket.GET("/:id/comments", ticketController.ListCommentsByTicketID)
+	ticket.GET("/:id/comments", middleware.PaginationMiddleware(), ticketController.ListCommentsByTicketID)
+	ticket.POST("/:id/comments", ticketController.CreateComment)
+	ticket.GET("/:id/transitions", ticketController.ListTicketTransitions)
+	ticket.POST("/:id/transition", ticketController.TransitionTicket)
+	ticket.GET("/:id/assignments", middleware.RoleMiddleware("Admin", "Technician"), ticketController.ListTicketAssignments)
+	ticket.GET("/:id/history", middleware.PaginationMiddleware(), middleware.RoleMiddleware("Admin", "Technician"), ticketController.ListTicketHistory)
+	ticket.GET("/:id/presence", ticketController.GetTicketPresence)
+	ticket.POST("/:id/attachments", attachmentController.UploadAttachment)
+	ticket.GET("/:id/attachments", attachmentController.ListAttachments)
+	ticket.GET("/:id/attachments/:attachmentId", attachmentController.GetAttachment)
+	ticket.DELETE("/:id/attachments/:attachmentId", attachmentController.DeleteAttachment)
 	ticket.GET("/customer", middleware.PaginationMiddleware(), middleware.RoleMiddleware("Customer"), ticketController.ListTicketsByCustomerId)
 
 	r.GET("/ws/ticket/:ticketId", func(c *gin.Context) {
 		ws.ServeWs(hub, c)
 	})
+	r.GET("/ws/user", func(c *gin.Context) {
+		ws.ServeUserWs(hub, c)
+	})
 }
@@ -26,5 +26,13 @@ func userRoutes(r *gin.RouterGroup) {
 	user.GET("/technicians", userController.GetTechnicians)
 	user.PUT("", userController.UpdateUser)
 	user.DELETE("/:id", userController.DeleteUser)
+	authUser.PUT("/technician/:id/skills", userController.SetTechnicianSkills)
+
+	// Notification preferences of the caller, staff user or customer
+	preferences := user.Group("/preferences")
+	preferences.Use(middleware.AuthMiddleware())
+	notificationController := v1_controllers.NewNotificationControllerV1()
+	preferences.GET("", notificationController.GetPreferences)
+	preferences.PUT("", notificationController.UpdatePreferences)
 
 }
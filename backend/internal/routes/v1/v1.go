@@ -13,7 +13,7 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func V1RoutesRegister(r *gin.Engine) {
+func V1RoutesRegister(r *gin.Engine, hub *ws.Hub) {
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:3000"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
@@ -22,9 +22,6 @@ func V1RoutesRegister(r *gin.Engine) {
 		MaxAge:           12 * time.Hour,
 	}))
 
-	hub := ws.NewHub()
-	go hub.Run()
-
 	store := ratelimit.RedisStore(&ratelimit.RedisOptions{
 		RedisClient: redis.Rdb,
 		Rate:        time.Minute,
@@ -54,5 +51,8 @@ func V1RoutesRegister(r *gin.Engine) {
 	tenantRoutes(v1)
 	ticketRoutes(v1, hub)
 	invoiceRoutes(v1)
+	attachmentRoutes(v1)
+	notificationRoutes(v1, hub)
+	inboundRoutes(v1)
 
 }
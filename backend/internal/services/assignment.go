@@ -0,0 +1,190 @@
+package services
+
+import (
+	"backend/internal/redis"
+	"backend/internal/repositories"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	AssignmentRandom     = "RANDOM"
+	AssignmentRoundRobin = "ROUND_ROBIN"
+	AssignmentLeastOpen  = "LEAST_OPEN"
+	AssignmentSkillMatch = "SKILL_MATCH"
+	AssignmentManual     = "MANUAL"
+	AssignmentEscalation = "ESCALATION"
+)
+
+// AssignmentRequest describes the ticket a technician is being picked for.
+type AssignmentRequest struct {
+	TenantID pgtype.UUID
+	Category string
+}
+
+// Assignment is the outcome of an assignment strategy. TechnicianID is not
+// valid when the tenant has no technician to assign.
+type Assignment struct {
+	TechnicianID pgtype.UUID
+	Strategy     string
+	Reason       string
+}
+
+// AssignmentStrategy picks the technician a new ticket is assigned to.
+type AssignmentStrategy interface {
+	Name() string
+	Assign(ctx context.Context, req AssignmentRequest) (Assignment, error)
+}
+
+// assignmentStrategyForTenant returns the strategy configured for the tenant,
+// falling back to random assignment when none is configured.
+func assignmentStrategyForTenant(ctx context.Context, queries *repositories.Queries, tenantID pgtype.UUID) (AssignmentStrategy, error) {
+	settings, err := queries.GetTenantSettings(ctx, tenantID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &randomStrategy{queries: queries}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant settings: %w", err)
+	}
+	return newAssignmentStrategy(settings.AssignmentStrategy, queries), nil
+}
+
+func newAssignmentStrategy(name string, queries *repositories.Queries) AssignmentStrategy {
+	switch name {
+	case AssignmentRoundRobin:
+		return &roundRobinStrategy{queries: queries}
+	case AssignmentLeastOpen:
+		return &leastOpenStrategy{queries: queries}
+	case AssignmentSkillMatch:
+		return &skillMatchStrategy{queries: queries, fallback: &leastOpenStrategy{queries: queries}}
+	default:
+		return &randomStrategy{queries: queries}
+	}
+}
+
+type randomStrategy struct {
+	queries *repositories.Queries
+}
+
+func (s *randomStrategy) Name() string { return AssignmentRandom }
+
+func (s *randomStrategy) Assign(ctx context.Context, req AssignmentRequest) (Assignment, error) {
+	technicianIDs, err := s.queries.GetTechnicianIDsFromTenantID(ctx, req.TenantID)
+	if err != nil {
+		return Assignment{}, fmt.Errorf("failed to get technicians for tenant: %w", err)
+	}
+	if len(technicianIDs) == 0 {
+		return Assignment{Strategy: s.Name(), Reason: "no technicians in tenant"}, nil
+	}
+	return Assignment{
+		TechnicianID: technicianIDs[rand.Intn(len(technicianIDs))],
+		Strategy:     s.Name(),
+		Reason:       fmt.Sprintf("picked at random from %d technicians", len(technicianIDs)),
+	}, nil
+}
+
+// roundRobinStrategy cycles through the tenant's technicians, keeping the
+// cursor in Redis so every replica shares it.
+type roundRobinStrategy struct {
+	queries *repositories.Queries
+}
+
+func (s *roundRobinStrategy) Name() string { return AssignmentRoundRobin }
+
+func (s *roundRobinStrategy) Assign(ctx context.Context, req AssignmentRequest) (Assignment, error) {
+	technicianIDs, err := s.queries.GetTechnicianIDsFromTenantID(ctx, req.TenantID)
+	if err != nil {
+		return Assignment{}, fmt.Errorf("failed to get technicians for tenant: %w", err)
+	}
+	if len(technicianIDs) == 0 {
+		return Assignment{Strategy: s.Name(), Reason: "no technicians in tenant"}, nil
+	}
+
+	key := fmt.Sprintf("assignment:round_robin:%s", req.TenantID.String())
+	turn, err := redis.Rdb.Incr(ctx, key).Result()
+	if err != nil {
+		return Assignment{}, fmt.Errorf("failed to advance round robin cursor: %w", err)
+	}
+	index := int((turn - 1) % int64(len(technicianIDs)))
+	return Assignment{
+		TechnicianID: technicianIDs[index],
+		Strategy:     s.Name(),
+		Reason:       fmt.Sprintf("round robin turn %d of %d technicians", index+1, len(technicianIDs)),
+	}, nil
+}
+
+// leastOpenStrategy picks the technician with the fewest open tickets.
+type leastOpenStrategy struct {
+	queries *repositories.Queries
+}
+
+func (s *leastOpenStrategy) Name() string { return AssignmentLeastOpen }
+
+func (s *leastOpenStrategy) Assign(ctx context.Context, req AssignmentRequest) (Assignment, error) {
+	loads, err := s.queries.ListTechnicianLoadsByTenantID(ctx, req.TenantID)
+	if err != nil {
+		return Assignment{}, fmt.Errorf("failed to get technician loads: %w", err)
+	}
+	if len(loads) == 0 {
+		return Assignment{Strategy: s.Name(), Reason: "no technicians in tenant"}, nil
+	}
+	return Assignment{
+		TechnicianID: loads[0].ID,
+		Strategy:     s.Name(),
+		Reason:       fmt.Sprintf("fewest open tickets (%d)", loads[0].OpenTickets),
+	}, nil
+}
+
+// skillMatchStrategy picks the least loaded technician with a skill matching
+// the ticket category, and defers to fallback when nobody matches.
+type skillMatchStrategy struct {
+	queries  *repositories.Queries
+	fallback AssignmentStrategy
+}
+
+func (s *skillMatchStrategy) Name() string { return AssignmentSkillMatch }
+
+func (s *skillMatchStrategy) Assign(ctx context.Context, req AssignmentRequest) (Assignment, error) {
+	if req.Category != "" {
+		loads, err := s.queries.ListSkilledTechnicianLoadsByTenantID(ctx, repositories.ListSkilledTechnicianLoadsByTenantIDParams{
+			TenantID: req.TenantID,
+			Category: req.Category,
+		})
+		if err != nil {
+			return Assignment{}, fmt.Errorf("failed to get skilled technicians: %w", err)
+		}
+		if len(loads) > 0 {
+			return Assignment{
+				TechnicianID: loads[0].ID,
+				Strategy:     s.Name(),
+				Reason:       fmt.Sprintf("skilled in %q with fewest open tickets (%d)", req.Category, loads[0].OpenTickets),
+			}, nil
+		}
+	}
+
+	assignment, err := s.fallback.Assign(ctx, req)
+	if err != nil {
+		return Assignment{}, err
+	}
+	assignment.Reason = fmt.Sprintf("no technician skilled in %q, fell back to %s: %s", req.Category, assignment.Strategy, assignment.Reason)
+	assignment.Strategy = s.Name()
+	return assignment, nil
+}
+
+func recordAssignment(ctx context.Context, queries *repositories.Queries, ticketID pgtype.UUID, assignment Assignment) error {
+	params := repositories.CreateTicketAssignmentParams{
+		TicketID:     ticketID,
+		TechnicianID: assignment.TechnicianID,
+		Strategy:     assignment.Strategy,
+		Reason:       assignment.Reason,
+	}
+	if err := queries.CreateTicketAssignment(ctx, params); err != nil {
+		return fmt.Errorf("failed to record ticket assignment: %w", err)
+	}
+	return nil
+}
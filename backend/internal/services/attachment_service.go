@@ -0,0 +1,275 @@
+package services
+
+import (
+	"backend/internal/repositories"
+	"backend/internal/storage"
+	"backend/utils"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	TicketEventAttached = "ATTACHED"
+
+	AttachmentMaxBytes  = 10 << 20
+	AttachmentURLExpiry = 5 * time.Minute
+)
+
+// allowedAttachmentTypes are the content types accepted for attachments, as
+// sniffed from the file rather than taken from the client.
+var allowedAttachmentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+	"application/zip": true,
+	"text/plain":      true,
+}
+
+type AttachmentService struct {
+	queries  *repositories.Queries
+	storage  storage.Storage
+	notifier Notifier
+}
+
+func NewAttachmentService() *AttachmentService {
+	return &AttachmentService{
+		queries:  repositories.GetDB(),
+		storage:  storage.Store,
+		notifier: notifier,
+	}
+}
+
+// AttachmentDownload is a signed URL to an attachment and when it expires.
+type AttachmentDownload struct {
+	Attachment repositories.TicketAttachment `json:"attachment"`
+	URL        string                        `json:"url"`
+	ExpiresAt  time.Time                     `json:"expires_at"`
+}
+
+// UploadAttachment stores a file on a ticket, optionally linked to one of the
+// ticket's comments, and records it in the ticket history.
+func (s *AttachmentService) UploadAttachment(ctx context.Context, ticketID pgtype.UUID, actor Actor, commentID string, file *multipart.FileHeader) (repositories.TicketAttachment, error) {
+	ticket, err := getTicketForActor(ctx, s.queries, ticketID, actor)
+	if err != nil {
+		return repositories.TicketAttachment{}, err
+	}
+
+	commentUUID, err := parseUUID(commentID)
+	if err != nil {
+		return repositories.TicketAttachment{}, utils.NewHTTPError(http.StatusBadRequest, "invalid comment_id")
+	}
+	var comment repositories.TicketComment
+	if commentUUID.Valid {
+		comment, err = s.queries.GetCommentByID(ctx, commentUUID)
+		if errors.Is(err, pgx.ErrNoRows) || (err == nil && (comment.TicketID != ticket.ID || !canSeeComment(actor, comment))) {
+			return repositories.TicketAttachment{}, utils.NewHTTPError(http.StatusBadRequest, "comment does not belong to this ticket")
+		}
+		if err != nil {
+			return repositories.TicketAttachment{}, fmt.Errorf("failed to get comment: %w", err)
+		}
+	}
+
+	body, err := file.Open()
+	if err != nil {
+		return repositories.TicketAttachment{}, fmt.Errorf("failed to open upload: %w", err)
+	}
+	defer body.Close()
+
+	return s.storeAttachment(ctx, ticket, actor, comment, file.Filename, file.Size, body)
+}
+
+// storeAttachment checks, stores and records a file on a ticket, linked to
+// the comment unless its ID is invalid, once the caller has established the
+// actor may attach to them.
+func (s *AttachmentService) storeAttachment(ctx context.Context, ticket repositories.TicketTicket, actor Actor, comment repositories.TicketComment, name string, size int64, body io.Reader) (repositories.TicketAttachment, error) {
+	uploaderUUID, err := parseUUID(actor.ID)
+	if err != nil {
+		return repositories.TicketAttachment{}, fmt.Errorf("invalid uploader id: %w", err)
+	}
+
+	if size <= 0 {
+		return repositories.TicketAttachment{}, utils.NewHTTPError(http.StatusBadRequest, "file is empty")
+	}
+	if size > AttachmentMaxBytes {
+		return repositories.TicketAttachment{}, utils.NewHTTPError(http.StatusRequestEntityTooLarge, fmt.Sprintf("file exceeds the %d MB limit", AttachmentMaxBytes>>20))
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(body, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return repositories.TicketAttachment{}, fmt.Errorf("failed to read upload: %w", err)
+	}
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(head[:n]))
+	if !allowedAttachmentTypes[contentType] {
+		return repositories.TicketAttachment{}, utils.NewHTTPError(http.StatusUnsupportedMediaType, fmt.Sprintf("file type %s is not allowed", contentType))
+	}
+
+	fileName := attachmentFileName(name)
+	attachmentID := uuid.New()
+	key := fmt.Sprintf("tickets/%s/%s%s", ticket.ID.String(), attachmentID, strings.ToLower(filepath.Ext(fileName)))
+
+	if err := s.storage.Put(ctx, key, io.MultiReader(bytes.NewReader(head[:n]), body), size, contentType); err != nil {
+		return repositories.TicketAttachment{}, fmt.Errorf("failed to store attachment: %w", err)
+	}
+
+	uploaderType := "USER"
+	if actor.IsCustomer() {
+		uploaderType = "CUSTOMER"
+	}
+	params := repositories.CreateAttachmentParams{
+		ID:           pgtype.UUID{Bytes: attachmentID, Valid: true},
+		TicketID:     ticket.ID,
+		CommentID:    comment.ID,
+		UploaderType: uploaderType,
+		UploaderID:   uploaderUUID,
+		FileName:     fileName,
+		ContentType:  contentType,
+		SizeBytes:    size,
+		StorageKey:   key,
+	}
+
+	internal := comment.Visibility == CommentInternal
+	var attachment repositories.TicketAttachment
+	err = withTx(ctx, s.queries, func(qtx *repositories.Queries) error {
+		attachment, err = qtx.CreateAttachment(ctx, params)
+		if err != nil {
+			return fmt.Errorf("failed to create attachment: %w", err)
+		}
+		return recordTicketEvents(ctx, qtx, ticket.ID, ticket.TenantID, actor, ticketChange{EventType: TicketEventAttached, Field: "attachment", NewValue: fileName})
+	})
+	if err != nil {
+		deleteStoredFiles(ctx, s.storage, key)
+		return repositories.TicketAttachment{}, err
+	}
+	var event interface{} = attachment
+	if internal {
+		event = StaffOnlyEvent{Data: attachment}
+	}
+	s.notifier.NotifyTicket(ticket.ID.String(), RealtimeAttachmentAdded, event)
+	return attachment, nil
+}
+
+// ListAttachments returns the files attached to a ticket and its comments,
+// newest first.
+func (s *AttachmentService) ListAttachments(ctx context.Context, ticketID pgtype.UUID, actor Actor) ([]repositories.TicketAttachment, error) {
+	if _, err := getTicketForActor(ctx, s.queries, ticketID, actor); err != nil {
+		return nil, err
+	}
+	attachments, err := s.queries.ListAttachmentsByTicketID(ctx, repositories.ListAttachmentsByTicketIDParams{
+		TicketID:        ticketID,
+		IncludeInternal: !actor.IsCustomer(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attachments: %w", err)
+	}
+	return attachments, nil
+}
+
+// GetAttachmentDownload returns a short-lived signed URL to an attachment.
+func (s *AttachmentService) GetAttachmentDownload(ctx context.Context, ticketID, attachmentID pgtype.UUID, actor Actor) (AttachmentDownload, error) {
+	attachment, err := s.getAttachment(ctx, ticketID, attachmentID, actor)
+	if err != nil {
+		return AttachmentDownload{}, err
+	}
+	url, err := s.storage.SignedURL(ctx, attachment.StorageKey, attachment.FileName, AttachmentURLExpiry)
+	if err != nil {
+		return AttachmentDownload{}, fmt.Errorf("failed to sign attachment url: %w", err)
+	}
+	return AttachmentDownload{
+		Attachment: attachment,
+		URL:        url,
+		ExpiresAt:  time.Now().Add(AttachmentURLExpiry).UTC(),
+	}, nil
+}
+
+// DeleteAttachment removes an attachment. Only its uploader and admins may
+// delete it.
+func (s *AttachmentService) DeleteAttachment(ctx context.Context, ticketID, attachmentID pgtype.UUID, actor Actor) error {
+	attachment, err := s.getAttachment(ctx, ticketID, attachmentID, actor)
+	if err != nil {
+		return err
+	}
+	if attachment.UploaderID.String() != actor.ID && actor.Role != "Admin" {
+		return utils.NewHTTPError(http.StatusForbidden, "only the uploader or an admin can delete this attachment")
+	}
+	if err := s.queries.DeleteAttachment(ctx, attachment.ID); err != nil {
+		return fmt.Errorf("failed to delete attachment: %w", err)
+	}
+	deleteStoredFiles(ctx, s.storage, attachment.StorageKey)
+	return nil
+}
+
+func (s *AttachmentService) getAttachment(ctx context.Context, ticketID, attachmentID pgtype.UUID, actor Actor) (repositories.TicketAttachment, error) {
+	if _, err := getTicketForActor(ctx, s.queries, ticketID, actor); err != nil {
+		return repositories.TicketAttachment{}, err
+	}
+	attachment, err := s.queries.GetAttachmentByID(ctx, repositories.GetAttachmentByIDParams{
+		ID:       attachmentID,
+		TicketID: ticketID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repositories.TicketAttachment{}, utils.NewHTTPError(http.StatusNotFound, "attachment not found")
+	}
+	if err != nil {
+		return repositories.TicketAttachment{}, fmt.Errorf("failed to get attachment: %w", err)
+	}
+	if attachment.CommentID.Valid && actor.IsCustomer() {
+		comment, err := s.queries.GetCommentByID(ctx, attachment.CommentID)
+		if err != nil {
+			return repositories.TicketAttachment{}, fmt.Errorf("failed to get comment: %w", err)
+		}
+		if !canSeeComment(actor, comment) {
+			return repositories.TicketAttachment{}, utils.NewHTTPError(http.StatusNotFound, "attachment not found")
+		}
+	}
+	return attachment, nil
+}
+
+// canSeeComment reports whether the actor may see the comment: internal
+// notes are hidden from customers.
+func canSeeComment(actor Actor, comment repositories.TicketComment) bool {
+	return comment.Visibility != CommentInternal || !actor.IsCustomer()
+}
+
+// attachmentFileName keeps the base name of an uploaded file, trimmed to fit
+// the file_name column.
+func attachmentFileName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == "/" {
+		name = "attachment"
+	}
+	if len(name) > 255 {
+		ext := filepath.Ext(name)
+		if len(ext) > 20 {
+			ext = ""
+		}
+		name = strings.ToValidUTF8(name[:255-len(ext)], "") + ext
+	}
+	return name
+}
+
+// deleteStoredFiles removes files whose rows are gone. Failures only leave
+// orphaned files behind, so they are logged rather than returned.
+func deleteStoredFiles(ctx context.Context, store storage.Storage, keys ...string) {
+	for _, key := range keys {
+		if err := store.Delete(ctx, key); err != nil {
+			log.Printf("AttachmentService - Failed to delete stored file %s: %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"backend/internal/mail"
+	"backend/internal/repositories"
+	"backend/jobs"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// EmailQueue sends templated emails in the background.
+type EmailQueue interface {
+	EnqueueEmail(ctx context.Context, email jobs.Email) error
+}
+
+type noopEmailQueue struct{}
+
+func (noopEmailQueue) EnqueueEmail(ctx context.Context, email jobs.Email) error { return nil }
+
+// emailQueue drops emails until SetEmailQueue is called at startup, before
+// the services that send emails are built.
+var emailQueue EmailQueue = noopEmailQueue{}
+
+func SetEmailQueue(q EmailQueue) {
+	emailQueue = q
+}
+
+// notificationEmailTemplate renders every user notification.
+const notificationEmailTemplate = "notification"
+
+// notificationContent is what notification emails show of a notification's
+// data. Ticket notifications of every producer share these fields.
+type notificationContent struct {
+	TicketID string `json:"ticket_id"`
+	Title    string `json:"title"`
+	Message  string `json:"message"`
+}
+
+// emailNotification emails a notification to its recipient, from the sender
+// of the tenant of the ticket it is about.
+func emailNotification(ctx context.Context, queries *repositories.Queries, queue EmailQueue, userID pgtype.UUID, data json.RawMessage, sendAt time.Time) error {
+	recipient, err := queries.GetNotificationRecipient(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && recipient.Email == "") {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get notification recipient: %w", err)
+	}
+
+	var content notificationContent
+	if err := json.Unmarshal(data, &content); err != nil {
+		return fmt.Errorf("failed to decode notification: %w", err)
+	}
+	if content.Message == "" {
+		return nil
+	}
+
+	email := jobs.Email{
+		To:       recipient.Email,
+		ToName:   recipient.Name,
+		Template: notificationEmailTemplate,
+		Data: map[string]interface{}{
+			"Title":   content.Title,
+			"Message": content.Message,
+		},
+		SendAt: sendAt,
+	}
+	if ticketID, err := parseUUID(content.TicketID); err == nil && ticketID.Valid {
+		if ticket, err := queries.GetTicketByID(ctx, ticketID); err == nil {
+			email.TenantID = ticket.TenantID.String()
+			email.TicketID = ticket.ID.String()
+			email.Data["URL"] = ticketURL(content.TicketID)
+			email.Data["TicketToken"] = mail.TicketToken(email.TicketID)
+		}
+	}
+	return queue.EnqueueEmail(ctx, email)
+}
+
+// ticketURL links to the ticket in the web app at APP_URL.
+func ticketURL(ticketID string) string {
+	appURL := os.Getenv("APP_URL")
+	if appURL == "" {
+		appURL = "http://localhost:3000"
+	}
+	return strings.TrimRight(appURL, "/") + "/tickets/" + ticketID
+}
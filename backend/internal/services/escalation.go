@@ -0,0 +1,200 @@
+package services
+
+import (
+	"backend/internal/repositories"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	EscalationUntouched     = "UNTOUCHED"
+	EscalationReopenedCount = "REOPENED_COUNT"
+)
+
+// EscalationService applies the tenants' escalation rules to their tickets.
+type EscalationService struct {
+	queries  *repositories.Queries
+	notifier Notifier
+}
+
+// errAlreadyEscalated means the rule has already fired on the ticket, in
+// this run or in one overlapping it.
+var errAlreadyEscalated = errors.New("already escalated")
+
+func NewEscalationService(notifier Notifier) *EscalationService {
+	return &EscalationService{queries: repositories.GetDB(), notifier: notifier}
+}
+
+type EscalationEvent struct {
+	RuleID        string   `json:"rule_id"`
+	RuleName      string   `json:"rule_name"`
+	Actions       []string `json:"actions"`
+	NotifyUserIDs []string `json:"notify_user_ids,omitempty"`
+}
+
+// RunEscalations evaluates every active rule and escalates the tickets it
+// matches. A rule fires at most once per ticket. A rule or ticket that fails
+// is logged and skipped so it does not hold up the others; the failures are
+// returned together at the end. It returns the number of escalations made.
+func (s *EscalationService) RunEscalations(ctx context.Context) (int, error) {
+	rules, err := s.queries.ListActiveEscalationRules(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list escalation rules: %w", err)
+	}
+
+	escalated := 0
+	var errs []error
+	for _, rule := range rules {
+		tickets, err := s.matchingTickets(ctx, rule)
+		if err != nil {
+			log.Printf("EscalationService - Failed to match tickets for rule %s: %v", rule.ID.String(), err)
+			errs = append(errs, fmt.Errorf("rule %s: %w", rule.ID.String(), err))
+			continue
+		}
+		for _, ticket := range tickets {
+			err := s.escalate(ctx, rule, ticket)
+			if errors.Is(err, errAlreadyEscalated) {
+				continue
+			}
+			if err != nil {
+				log.Printf("EscalationService - Failed to escalate ticket %s by rule %s: %v", ticket.ID.String(), rule.ID.String(), err)
+				errs = append(errs, fmt.Errorf("rule %s on ticket %s: %w", rule.ID.String(), ticket.ID.String(), err))
+				continue
+			}
+			escalated++
+		}
+	}
+	return escalated, errors.Join(errs...)
+}
+
+func (s *EscalationService) matchingTickets(ctx context.Context, rule repositories.TicketEscalationRule) ([]repositories.TicketTicket, error) {
+	switch rule.ConditionType {
+	case EscalationUntouched:
+		tickets, err := s.queries.ListUntouchedTicketsForRule(ctx, repositories.ListUntouchedTicketsForRuleParams{
+			TenantID:      rule.TenantID,
+			MatchPriority: rule.MatchPriority,
+			Minutes:       rule.Threshold,
+			RuleID:        rule.ID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list untouched tickets: %w", err)
+		}
+		return tickets, nil
+	case EscalationReopenedCount:
+		tickets, err := s.queries.ListReopenedTicketsForRule(ctx, repositories.ListReopenedTicketsForRuleParams{
+			TenantID:      rule.TenantID,
+			MatchPriority: rule.MatchPriority,
+			ReopenCount:   rule.Threshold,
+			RuleID:        rule.ID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list reopened tickets: %w", err)
+		}
+		return tickets, nil
+	default:
+		return nil, nil
+	}
+}
+
+// escalate applies the rule's actions to the ticket and logs them against it,
+// on behalf of the System actor.
+func (s *EscalationService) escalate(ctx context.Context, rule repositories.TicketEscalationRule, ticket repositories.TicketTicket) error {
+	var adminIDs []pgtype.UUID
+	if rule.ReassignToAdmin || rule.NotifyAdmin {
+		var err error
+		adminIDs, err = s.queries.GetAdminIDsFromTenantID(ctx, ticket.TenantID)
+		if err != nil {
+			return fmt.Errorf("failed to get tenant admins: %w", err)
+		}
+	}
+
+	actions := []string{}
+	params := repositories.EscalateTicketParams{ID: ticket.ID}
+	if rule.SetPriority.Valid && string(rule.SetPriority.TicketTicketPriority) != ticket.Priority {
+		params.Priority = rule.SetPriority
+		actions = append(actions, fmt.Sprintf("SET_PRIORITY %s", rule.SetPriority.TicketTicketPriority))
+	}
+	if rule.ReassignToAdmin && len(adminIDs) > 0 && ticket.AssignedTo != adminIDs[0] {
+		params.AssignedTo = adminIDs[0]
+		actions = append(actions, fmt.Sprintf("REASSIGN %s", adminIDs[0].String()))
+	}
+	notify := []string{}
+	if rule.NotifyAdmin {
+		for _, id := range adminIDs {
+			notify = append(notify, id.String())
+		}
+		actions = append(actions, "NOTIFY_ADMIN")
+	}
+
+	updated := ticket
+	err := withTx(ctx, s.queries, func(qtx *repositories.Queries) error {
+		// The log is written first as the claim on the ticket: a run that
+		// loses the race leaves the ticket to the one that logged it.
+		logged, err := qtx.CreateEscalationLog(ctx, repositories.CreateEscalationLogParams{
+			RuleID:   rule.ID,
+			TicketID: ticket.ID,
+			Actions:  actions,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to log escalation: %w", err)
+		}
+		if logged == 0 {
+			return errAlreadyEscalated
+		}
+
+		if params.Priority.Valid || params.AssignedTo.Valid {
+			updated, err = qtx.EscalateTicket(ctx, params)
+			if err != nil {
+				return fmt.Errorf("failed to escalate ticket: %w", err)
+			}
+		}
+		if updated.Priority != ticket.Priority {
+			if err := applySLAPolicy(ctx, qtx, updated.ID, updated.TenantID, updated.Priority, updated.CreatedAt.Time); err != nil {
+				return err
+			}
+		}
+		if updated.AssignedTo != ticket.AssignedTo {
+			assignment := Assignment{
+				TechnicianID: updated.AssignedTo,
+				Strategy:     AssignmentEscalation,
+				Reason:       fmt.Sprintf("escalation rule %q", rule.Name),
+			}
+			if err := recordAssignment(ctx, qtx, updated.ID, assignment); err != nil {
+				return err
+			}
+		}
+
+		changes := append(diffTickets(ticket, updated), ticketChange{
+			EventType: TicketEventEscalated,
+			Field:     "escalation_rule",
+			NewValue:  fmt.Sprintf("%s: %s", rule.Name, strings.Join(actions, ", ")),
+		})
+		return recordTicketEvents(ctx, qtx, ticket.ID, ticket.TenantID, Actor{}, changes...)
+	})
+	if err != nil {
+		return err
+	}
+
+	if s.notifier != nil {
+		notifyTicketChange(s.notifier, ticket, updated)
+		s.notifier.NotifyTicket(ticket.ID.String(), "ticket.escalated", EscalationEvent{
+			RuleID:        rule.ID.String(),
+			RuleName:      rule.Name,
+			Actions:       actions,
+			NotifyUserIDs: notify,
+		})
+		for _, userID := range notify {
+			s.notifier.NotifyUser(userID, NotificationTicketEscalated, TicketNotification{
+				TicketID: ticket.ID.String(),
+				Title:    ticket.Title,
+				Message:  fmt.Sprintf("Escalated by rule %q", rule.Name),
+			})
+		}
+	}
+	return nil
+}
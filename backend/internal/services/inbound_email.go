@@ -0,0 +1,399 @@
+package services
+
+import (
+	"backend/internal/dto"
+	"backend/internal/mail"
+	"backend/internal/repositories"
+	"backend/jobs"
+	"backend/utils"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	InboundEmailProcessing    = "PROCESSING"
+	InboundEmailTicketCreated = "TICKET_CREATED"
+	InboundEmailCommented     = "COMMENTED"
+	InboundEmailIgnored       = "IGNORED"
+
+	// InboundEmailMaxBytes bounds the raw messages the gateway accepts.
+	InboundEmailMaxBytes = 25 << 20
+)
+
+// inboundTicketPriority is the priority of tickets opened by email, which
+// cannot say otherwise.
+const inboundTicketPriority = "MEDIUM"
+
+// InboundEmailService turns emails sent to a tenant's support address into
+// tickets, and replies to ticket emails into comments.
+type InboundEmailService struct {
+	queries     *repositories.Queries
+	tickets     *TicketService
+	attachments *AttachmentService
+	emailQueue  EmailQueue
+}
+
+func NewInboundEmailService() *InboundEmailService {
+	return &InboundEmailService{
+		queries:     repositories.GetDB(),
+		tickets:     NewTicketService(),
+		attachments: NewAttachmentService(),
+		emailQueue:  emailQueue,
+	}
+}
+
+// HandleEmail processes a raw email. envelopeTo are the SMTP recipients, when
+// known, which are tried before the message's own recipient headers.
+// Redelivered messages return the record of their first delivery. The record
+// is claimed by Message-ID before anything is done, so a redelivery that
+// arrives while the first is still being processed, or after it failed
+// halfway, cannot open a second ticket or comment.
+func (s *InboundEmailService) HandleEmail(ctx context.Context, raw []byte, envelopeTo []string) (repositories.InboundEmail, error) {
+	msg, err := mail.ParseInbound(raw)
+	if err != nil {
+		return repositories.InboundEmail{}, utils.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if msg.MessageID == "" {
+		sum := sha256.Sum256(raw)
+		msg.MessageID = fmt.Sprintf("<%s@inbound>", hex.EncodeToString(sum[:16]))
+	}
+
+	claim, err := s.queries.ClaimInboundEmail(ctx, repositories.ClaimInboundEmailParams{
+		MessageID: msg.MessageID,
+		Sender:    strings.ToLower(msg.From.Address),
+		Subject:   msg.Subject,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		existing, err := s.queries.GetInboundEmailByMessageID(ctx, msg.MessageID)
+		if err != nil {
+			return repositories.InboundEmail{}, fmt.Errorf("failed to get inbound email: %w", err)
+		}
+		if existing.Status == InboundEmailProcessing {
+			return repositories.InboundEmail{}, fmt.Errorf("inbound email %s is already being processed", msg.MessageID)
+		}
+		return existing, nil
+	}
+	if err != nil {
+		return repositories.InboundEmail{}, fmt.Errorf("failed to claim inbound email: %w", err)
+	}
+
+	record := repositories.CompleteInboundEmailParams{
+		ID:     claim.ID,
+		Status: InboundEmailIgnored,
+	}
+	if err := s.route(ctx, msg, envelopeTo, &record); err != nil {
+		// Nothing was created yet, so the claim is given up for the sender
+		// to retry; otherwise it stays and the retry finds it.
+		if !record.TicketID.Valid {
+			if err := s.queries.ReleaseInboundEmail(ctx, claim.ID); err != nil {
+				log.Printf("InboundEmailService - Failed to release %s: %v", msg.MessageID, err)
+			}
+		}
+		return repositories.InboundEmail{}, err
+	}
+	if record.Status == InboundEmailIgnored {
+		log.Printf("InboundEmailService - Ignored %s from %s: %s", msg.MessageID, claim.Sender, record.Reason.String)
+	}
+
+	inbound, err := s.queries.CompleteInboundEmail(ctx, record)
+	if err != nil {
+		return repositories.InboundEmail{}, fmt.Errorf("failed to record inbound email: %w", err)
+	}
+	return inbound, nil
+}
+
+// SMTPHandler adapts HandleEmail to the embedded SMTP listener. Messages the
+// gateway refuses are rejected for good; other failures are retried by the
+// sending server.
+func (s *InboundEmailService) SMTPHandler() mail.InboundHandler {
+	return func(ctx context.Context, from string, to []string, raw []byte) error {
+		_, err := s.HandleEmail(ctx, raw, to)
+		var httpErr *utils.HTTPError
+		if errors.As(err, &httpErr) && httpErr.Status < http.StatusInternalServerError {
+			return fmt.Errorf("%w: %s", mail.ErrRejected, httpErr.Message)
+		}
+		return err
+	}
+}
+
+// route comments on the ticket the email replies to, or opens a new one,
+// filling in the record of what was done.
+func (s *InboundEmailService) route(ctx context.Context, msg *mail.InboundMessage, envelopeTo []string, record *repositories.CompleteInboundEmailParams) error {
+	if msg.AutoGenerated {
+		record.Reason = makeText("auto-generated message")
+		return nil
+	}
+
+	tenant, err := s.findTenant(ctx, msg, envelopeTo)
+	if errors.Is(err, pgx.ErrNoRows) {
+		record.Reason = makeText("no tenant uses any of the recipient addresses")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	record.TenantID = tenant.ID
+
+	ticket, err := s.findTicket(ctx, msg, tenant.ID)
+	if err != nil {
+		return err
+	}
+	if ticket.ID.Valid {
+		return s.reply(ctx, msg, ticket, record)
+	}
+	return s.openTicket(ctx, msg, tenant, record)
+}
+
+// reply adds the email to its ticket as a comment by the sender, who must be
+// the ticket's customer or a member of its tenant.
+func (s *InboundEmailService) reply(ctx context.Context, msg *mail.InboundMessage, ticket repositories.TicketTicket, record *repositories.CompleteInboundEmailParams) error {
+	actor, ok, err := s.participant(ctx, ticket, strings.ToLower(msg.From.Address))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		record.Reason = makeText("sender is not a participant of the ticket")
+		return nil
+	}
+
+	content := mail.StripQuotedReply(msg.Text)
+	if content == "" && len(msg.Attachments) == 0 {
+		record.Reason = makeText("reply is empty")
+		return nil
+	}
+	if content == "" {
+		content = "(attachments only)"
+	}
+
+	commentID, err := s.tickets.CreateComment(ctx, ticket.ID.String(), content, CommentPublic, actor)
+	if err != nil {
+		return err
+	}
+	record.Status = InboundEmailCommented
+	record.TicketID = ticket.ID
+	record.CommentID = commentID
+	s.storeAttachments(ctx, msg, ticket, actor, repositories.TicketComment{ID: commentID, Visibility: CommentPublic})
+	return nil
+}
+
+// openTicket opens a ticket for the sender, registering them as a customer
+// if they are not one yet, and acknowledges it with an email whose subject
+// carries the ticket token so that replies are threaded.
+func (s *InboundEmailService) openTicket(ctx context.Context, msg *mail.InboundMessage, tenant repositories.TenantTenant, record *repositories.CompleteInboundEmailParams) error {
+	customer, err := s.findOrCreateCustomer(ctx, msg.From)
+	if err != nil {
+		return err
+	}
+	actor := Actor{ID: customer.ID.String(), Username: customer.Username, Role: "Customer"}
+
+	title := strings.TrimSpace(msg.Subject)
+	if title == "" {
+		title = "(no subject)"
+	}
+	title = truncate(title, 200)
+
+	ticketID, err := s.tickets.CreateTicket(ctx, actor, dto.CreateTicketDto{
+		TenantID:    tenant.ID.String(),
+		CustomerID:  customer.ID.String(),
+		Title:       title,
+		Description: msg.Text,
+		Priority:    inboundTicketPriority,
+	})
+	if err != nil {
+		return err
+	}
+	record.Status = InboundEmailTicketCreated
+	record.TicketID = ticketID
+	ticket, err := s.queries.GetTicketByID(ctx, ticketID)
+	if err != nil {
+		return fmt.Errorf("failed to get ticket: %w", err)
+	}
+	s.storeAttachments(ctx, msg, ticket, actor, repositories.TicketComment{})
+
+	err = s.emailQueue.EnqueueEmail(ctx, jobs.Email{
+		TenantID: tenant.ID.String(),
+		TicketID: ticket.ID.String(),
+		To:       customer.Email.String,
+		ToName:   customerName(customer),
+		Template: notificationEmailTemplate,
+		Data: map[string]interface{}{
+			"Title":       ticket.Title,
+			"Message":     "We received your request. Reply to this email to add to it.",
+			"URL":         ticketURL(ticket.ID.String()),
+			"TicketToken": mail.TicketToken(ticket.ID.String()),
+		},
+	})
+	if err != nil {
+		log.Printf("InboundEmailService - Failed to acknowledge ticket %s: %v", ticket.ID.String(), err)
+	}
+
+	return nil
+}
+
+// findTenant returns the tenant whose support address the email was sent to.
+func (s *InboundEmailService) findTenant(ctx context.Context, msg *mail.InboundMessage, envelopeTo []string) (repositories.TenantTenant, error) {
+	addresses := append([]string{}, envelopeTo...)
+	for _, recipient := range msg.Recipients {
+		addresses = append(addresses, recipient.Address)
+	}
+	for _, address := range addresses {
+		tenant, err := s.queries.GetTenantByEmail(ctx, strings.TrimSpace(address))
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return repositories.TenantTenant{}, fmt.Errorf("failed to get tenant by email: %w", err)
+		}
+		if tenant.IsActive.Valid && !tenant.IsActive.Bool {
+			continue
+		}
+		return tenant, nil
+	}
+	return repositories.TenantTenant{}, pgx.ErrNoRows
+}
+
+// findTicket returns the tenant's ticket the email replies to, by the ticket
+// token in its subject or else by the messages it references. The returned
+// ticket's ID is invalid when it replies to none.
+func (s *InboundEmailService) findTicket(ctx context.Context, msg *mail.InboundMessage, tenantID pgtype.UUID) (repositories.TicketTicket, error) {
+	var ticketID pgtype.UUID
+	if token := mail.TicketIDFromSubject(msg.Subject); token != "" {
+		ticketID, _ = parseUUID(token)
+	}
+	for _, reference := range msg.References {
+		if ticketID.Valid {
+			break
+		}
+		id, err := s.queries.GetTicketIDByMessageID(ctx, reference)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return repositories.TicketTicket{}, fmt.Errorf("failed to get ticket by message id: %w", err)
+		}
+		ticketID = id
+	}
+	if !ticketID.Valid {
+		return repositories.TicketTicket{}, nil
+	}
+
+	ticket, err := s.queries.GetTicketByID(ctx, ticketID)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && ticket.TenantID != tenantID) {
+		return repositories.TicketTicket{}, nil
+	}
+	if err != nil {
+		return repositories.TicketTicket{}, fmt.Errorf("failed to get ticket: %w", err)
+	}
+	return ticket, nil
+}
+
+// participant returns the actor for the ticket's customer or tenant member
+// with that email address.
+func (s *InboundEmailService) participant(ctx context.Context, ticket repositories.TicketTicket, email string) (Actor, bool, error) {
+	customer, err := s.queries.GetCustomerByEmail(ctx, makeText(email))
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return Actor{}, false, fmt.Errorf("failed to get customer by email: %w", err)
+	}
+	if err == nil && customer.ID == ticket.CustomerID {
+		return Actor{ID: customer.ID.String(), Username: customer.Username, Role: "Customer"}, true, nil
+	}
+
+	user, err := s.queries.GetUserByEmail(ctx, email)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Actor{}, false, nil
+	}
+	if err != nil {
+		return Actor{}, false, fmt.Errorf("failed to get user by email: %w", err)
+	}
+	isMember, err := s.queries.IsTenantMember(ctx, repositories.IsTenantMemberParams{
+		TenantID: ticket.TenantID,
+		UserID:   user.ID,
+	})
+	if err != nil {
+		return Actor{}, false, fmt.Errorf("failed to check tenant membership: %w", err)
+	}
+	if !isMember {
+		return Actor{}, false, nil
+	}
+	return Actor{ID: user.ID.String(), Username: user.Username, Role: string(user.Role)}, true, nil
+}
+
+// findOrCreateCustomer returns the customer with the sender's address,
+// registering one with an unusable random password if there is none.
+func (s *InboundEmailService) findOrCreateCustomer(ctx context.Context, from mail.Address) (repositories.Customer, error) {
+	email := strings.ToLower(from.Address)
+	customer, err := s.queries.GetCustomerByEmail(ctx, makeText(email))
+	if err == nil {
+		return customer, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return repositories.Customer{}, fmt.Errorf("failed to get customer by email: %w", err)
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return repositories.Customer{}, fmt.Errorf("failed to generate password: %w", err)
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(hex.EncodeToString(secret)), bcrypt.DefaultCost)
+	if err != nil {
+		return repositories.Customer{}, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	username := email
+	if at := strings.LastIndex(email, "@"); at > 0 {
+		username = email[:at]
+	}
+	firstName, lastName := username, ""
+	if name := strings.Fields(from.Name); len(name) > 0 {
+		firstName = name[0]
+		lastName = strings.Join(name[1:], " ")
+	}
+
+	customer, err = s.queries.CreateCustomer(ctx, repositories.CreateCustomerParams{
+		FirstName: truncate(firstName, 100),
+		LastName:  truncate(lastName, 100),
+		Username:  truncate(username, 100),
+		Email:     makeText(email),
+		Password:  string(hashedPassword),
+	})
+	if err != nil {
+		return repositories.Customer{}, fmt.Errorf("failed to create customer: %w", err)
+	}
+	return customer, nil
+}
+
+// storeAttachments attaches the email's files to the ticket. Files that are
+// refused, such as disallowed types, are logged and skipped so the rest of
+// the email is still processed.
+func (s *InboundEmailService) storeAttachments(ctx context.Context, msg *mail.InboundMessage, ticket repositories.TicketTicket, actor Actor, comment repositories.TicketComment) {
+	for _, attachment := range msg.Attachments {
+		_, err := s.attachments.storeAttachment(ctx, ticket, actor, comment, attachment.FileName, int64(len(attachment.Data)), bytes.NewReader(attachment.Data))
+		if err != nil {
+			log.Printf("InboundEmailService - Skipped attachment %s of %s: %v", attachment.FileName, msg.MessageID, err)
+		}
+	}
+}
+
+func customerName(customer repositories.Customer) string {
+	return strings.TrimSpace(customer.FirstName + " " + customer.LastName)
+}
+
+// truncate cuts a string to at most limit bytes without splitting a rune.
+func truncate(value string, limit int) string {
+	if len(value) <= limit {
+		return value
+	}
+	return strings.ToValidUTF8(value[:limit], "")
+}
@@ -0,0 +1,315 @@
+package services
+
+import (
+	"backend/internal/dto"
+	"backend/internal/money"
+	"backend/internal/repositories"
+	"backend/utils"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// CreateCreditNote issues a credit note against an invoice. The credit notes
+// of an invoice cannot come to more than the invoice. When the invoice is
+// still open, the credit note is applied to it straight away, up to what is
+// left to pay; the rest can be applied to later invoices with
+// ApplyCreditNote.
+func (s *InvoiceService) CreateCreditNote(ctx context.Context, actor Actor, creditNoteDto dto.CreateCreditNoteRequest) (repositories.InvoiceCreditNote, repositories.InvoiceInvoice, error) {
+	invoiceID, err := parseUUID(creditNoteDto.InvoiceID)
+	if err != nil {
+		return repositories.InvoiceCreditNote{}, repositories.InvoiceInvoice{}, fmt.Errorf("invalid invoice ID: %w", err)
+	}
+	invoice, err := s.GetInvoice(ctx, actor, invoiceID)
+	if err != nil {
+		return repositories.InvoiceCreditNote{}, repositories.InvoiceInvoice{}, err
+	}
+	currency := money.Currency(invoice.Currency)
+	if !currency.Fits(creditNoteDto.Amount) {
+		return repositories.InvoiceCreditNote{}, repositories.InvoiceInvoice{}, utils.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("amount has too many decimal places: %s amounts take %d", currency, currency.MinorUnits()))
+	}
+	actorUUID, err := parseUUID(actor.ID)
+	if err != nil {
+		return repositories.InvoiceCreditNote{}, repositories.InvoiceInvoice{}, fmt.Errorf("invalid actor id: %w", err)
+	}
+
+	var creditNote repositories.InvoiceCreditNote
+	var previous repositories.InvoiceInvoiceStatus
+	err = withTx(ctx, s.queries, func(qtx *repositories.Queries) error {
+		locked, err := qtx.GetInvoiceByIDForUpdate(ctx, invoice.ID)
+		if err != nil {
+			return fmt.Errorf("failed to lock invoice: %w", err)
+		}
+		previous = locked.Status.InvoiceInvoiceStatus
+		switch locked.Status.InvoiceInvoiceStatus {
+		case repositories.InvoiceInvoiceStatusCANCELLED, repositories.InvoiceInvoiceStatusREFUNDED:
+			return utils.NewHTTPError(http.StatusConflict, fmt.Sprintf("the invoice is %s and cannot be credited", locked.Status.InvoiceInvoiceStatus))
+		}
+
+		credited, err := qtx.SumCreditNotesByInvoiceID(ctx, locked.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get credit notes of invoice: %w", err)
+		}
+		if creditable := locked.Amount.Sub(credited); creditNoteDto.Amount.Cmp(creditable) > 0 {
+			return utils.NewHTTPError(http.StatusConflict, fmt.Sprintf("the credit note is more than the %s of the invoice not credited yet", money.Money{Amount: creditable, Currency: currency}))
+		}
+
+		ticket, err := qtx.GetTicketByID(ctx, locked.TicketID)
+		if err != nil {
+			return fmt.Errorf("failed to get ticket: %w", err)
+		}
+		number, err := nextDocumentNumber(ctx, qtx, ticket.TenantID, documentCreditNote, creditNoteNumberFormat)
+		if err != nil {
+			return err
+		}
+		creditNote, err = qtx.CreateCreditNote(ctx, repositories.CreateCreditNoteParams{
+			TenantID:   ticket.TenantID,
+			Number:     number,
+			InvoiceID:  locked.ID,
+			CustomerID: ticket.CustomerID,
+			Amount:     creditNoteDto.Amount,
+			Currency:   locked.Currency,
+			Reason:     makeText(creditNoteDto.Reason),
+			CreatedBy:  actorUUID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create credit note: %w", err)
+		}
+
+		invoice = locked
+		if locked.Status.InvoiceInvoiceStatus != repositories.InvoiceInvoiceStatusPENDING {
+			return nil
+		}
+		open, err := openAmount(ctx, qtx, locked)
+		if err != nil {
+			return err
+		}
+		if open.Sign() <= 0 {
+			return nil
+		}
+		amount := creditNote.Amount
+		if amount.Cmp(open) > 0 {
+			amount = open
+		}
+		creditNote, invoice, err = applyCreditNote(ctx, qtx, creditNote, locked, amount)
+		return err
+	})
+	if err != nil {
+		log.Printf("InvoiceService - Failed to credit invoice %s: %v", invoiceID.String(), err)
+		return repositories.InvoiceCreditNote{}, repositories.InvoiceInvoice{}, err
+	}
+
+	if err := s.pdfQueue.EnqueueCreditNotePDF(ctx, creditNote.ID.String()); err != nil {
+		params := recordEnqueueFailure(ctx, "credit note", creditNote.ID, err, func(ctx context.Context, params repositories.RecordInvoicePDFParams) error {
+			return s.queries.RecordCreditNotePDF(ctx, repositories.RecordCreditNotePDFParams(params))
+		})
+		creditNote.PdfStatus, creditNote.PdfError = params.PdfStatus, params.PdfError
+	}
+
+	amount := money.Money{Amount: creditNote.Amount, Currency: currency}
+	s.notifyCustomer(ctx, invoice, NotificationInvoiceCredited, fmt.Sprintf("A credit note of %s was issued for your invoice", amount))
+	s.notifyIfPaid(ctx, previous, invoice)
+	return creditNote, invoice, nil
+}
+
+// ApplyCreditNote applies what is left of a credit note to an open invoice of
+// the same customer, in the same currency. Without an amount it applies as
+// much as the credit note and the invoice allow. The credit note is locked
+// before the invoice, so applications of the same credit note to different
+// invoices queue up rather than spend it twice.
+func (s *InvoiceService) ApplyCreditNote(ctx context.Context, actor Actor, creditNoteID pgtype.UUID, applyDto dto.ApplyCreditNoteRequest) (repositories.InvoiceCreditNote, repositories.InvoiceInvoice, error) {
+	creditNote, err := s.GetCreditNote(ctx, actor, creditNoteID)
+	if err != nil {
+		return repositories.InvoiceCreditNote{}, repositories.InvoiceInvoice{}, err
+	}
+	invoiceID, err := parseUUID(applyDto.InvoiceID)
+	if err != nil {
+		return repositories.InvoiceCreditNote{}, repositories.InvoiceInvoice{}, fmt.Errorf("invalid invoice ID: %w", err)
+	}
+	invoice, err := s.GetInvoice(ctx, actor, invoiceID)
+	if err != nil {
+		return repositories.InvoiceCreditNote{}, repositories.InvoiceInvoice{}, err
+	}
+	currency := money.Currency(creditNote.Currency)
+	if invoice.Currency != creditNote.Currency {
+		return repositories.InvoiceCreditNote{}, repositories.InvoiceInvoice{}, utils.NewHTTPError(http.StatusConflict, fmt.Sprintf("the credit note is in %s and the invoice in %s", creditNote.Currency, invoice.Currency))
+	}
+	if !currency.Fits(applyDto.Amount) {
+		return repositories.InvoiceCreditNote{}, repositories.InvoiceInvoice{}, utils.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("amount has too many decimal places: %s amounts take %d", currency, currency.MinorUnits()))
+	}
+
+	var previous repositories.InvoiceInvoiceStatus
+	var applied money.Decimal
+	err = withTx(ctx, s.queries, func(qtx *repositories.Queries) error {
+		creditNote, err = qtx.GetCreditNoteByIDForUpdate(ctx, creditNote.ID)
+		if err != nil {
+			return fmt.Errorf("failed to lock credit note: %w", err)
+		}
+		locked, err := qtx.GetInvoiceByIDForUpdate(ctx, invoice.ID)
+		if err != nil {
+			return fmt.Errorf("failed to lock invoice: %w", err)
+		}
+		previous = locked.Status.InvoiceInvoiceStatus
+		if locked.Status.InvoiceInvoiceStatus != repositories.InvoiceInvoiceStatusPENDING {
+			return utils.NewHTTPError(http.StatusConflict, fmt.Sprintf("the invoice is %s and takes no more credit", locked.Status.InvoiceInvoiceStatus))
+		}
+
+		ticket, err := qtx.GetTicketByID(ctx, locked.TicketID)
+		if err != nil {
+			return fmt.Errorf("failed to get ticket: %w", err)
+		}
+		// A credit note without a customer is never applied: two unset
+		// customer IDs compare equal and would match any such ticket.
+		if ticket.TenantID != creditNote.TenantID || !creditNote.CustomerID.Valid || ticket.CustomerID != creditNote.CustomerID {
+			return utils.NewHTTPError(http.StatusConflict, "a credit note can only be applied to invoices of the same customer")
+		}
+
+		if creditNote.AmountRemaining.Sign() <= 0 {
+			return utils.NewHTTPError(http.StatusConflict, "the credit note has been used up")
+		}
+		open, err := openAmount(ctx, qtx, locked)
+		if err != nil {
+			return err
+		}
+		if open.Sign() <= 0 {
+			return utils.NewHTTPError(http.StatusConflict, "nothing is left to pay on the invoice")
+		}
+		amount := applyDto.Amount
+		if amount.IsZero() {
+			amount = creditNote.AmountRemaining
+			if amount.Cmp(open) > 0 {
+				amount = open
+			}
+		}
+		if amount.Cmp(creditNote.AmountRemaining) > 0 {
+			return utils.NewHTTPError(http.StatusConflict, fmt.Sprintf("the amount is more than the %s left on the credit note", money.Money{Amount: creditNote.AmountRemaining, Currency: currency}))
+		}
+		if amount.Cmp(open) > 0 {
+			return utils.NewHTTPError(http.StatusConflict, fmt.Sprintf("the amount is more than the %s left to pay", money.Money{Amount: open, Currency: currency}))
+		}
+
+		applied = amount
+		creditNote, invoice, err = applyCreditNote(ctx, qtx, creditNote, locked, amount)
+		return err
+	})
+	if err != nil {
+		log.Printf("InvoiceService - Failed to apply credit note %s: %v", creditNoteID.String(), err)
+		return repositories.InvoiceCreditNote{}, repositories.InvoiceInvoice{}, err
+	}
+
+	s.notifyCustomer(ctx, invoice, NotificationInvoiceCredited, fmt.Sprintf("A credit of %s was applied to your invoice", money.Money{Amount: applied, Currency: currency}))
+	s.notifyIfPaid(ctx, previous, invoice)
+	return creditNote, invoice, nil
+}
+
+// openAmount is what is left to pay on an invoice once the credit applied to
+// it and its completed and pending payments are taken off.
+func openAmount(ctx context.Context, qtx *repositories.Queries, invoice repositories.InvoiceInvoice) (money.Decimal, error) {
+	totals, err := qtx.GetInvoicePaymentTotals(ctx, invoice.ID)
+	if err != nil {
+		return money.Decimal{}, fmt.Errorf("failed to get invoice payments: %w", err)
+	}
+	return invoice.Amount.Sub(invoice.AmountCredited).Sub(totals.Completed).Sub(totals.Pending), nil
+}
+
+// applyCreditNote records amount of the credit note as applied to the
+// invoice and brings the invoice's balance up to date, marking it credited
+// or paid once nothing is left to pay. Both rows must already be locked.
+func applyCreditNote(ctx context.Context, qtx *repositories.Queries, creditNote repositories.InvoiceCreditNote, invoice repositories.InvoiceInvoice, amount money.Decimal) (repositories.InvoiceCreditNote, repositories.InvoiceInvoice, error) {
+	creditNote, err := qtx.AddCreditNoteApplied(ctx, repositories.AddCreditNoteAppliedParams{Amount: amount, ID: creditNote.ID})
+	if err != nil {
+		return repositories.InvoiceCreditNote{}, repositories.InvoiceInvoice{}, fmt.Errorf("failed to update credit note: %w", err)
+	}
+	_, err = qtx.CreateCreditNoteApplication(ctx, repositories.CreateCreditNoteApplicationParams{
+		CreditNoteID: creditNote.ID,
+		InvoiceID:    invoice.ID,
+		Amount:       amount,
+	})
+	if err != nil {
+		return repositories.InvoiceCreditNote{}, repositories.InvoiceInvoice{}, fmt.Errorf("failed to apply credit note: %w", err)
+	}
+	invoice, err = qtx.SyncInvoiceBalance(ctx, invoice.ID)
+	if err != nil {
+		return repositories.InvoiceCreditNote{}, repositories.InvoiceInvoice{}, fmt.Errorf("failed to update invoice: %w", err)
+	}
+	return creditNote, invoice, nil
+}
+
+// GetCreditNote returns a credit note issued against an invoice the actor has
+// access to.
+func (s *InvoiceService) GetCreditNote(ctx context.Context, actor Actor, creditNoteID pgtype.UUID) (repositories.InvoiceCreditNote, error) {
+	creditNote, err := s.queries.GetCreditNoteByID(ctx, creditNoteID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repositories.InvoiceCreditNote{}, utils.NewHTTPError(http.StatusNotFound, "credit note not found")
+	}
+	if err != nil {
+		return repositories.InvoiceCreditNote{}, fmt.Errorf("failed to get credit note: %w", err)
+	}
+	if _, err := s.GetInvoice(ctx, actor, creditNote.InvoiceID); err != nil {
+		return repositories.InvoiceCreditNote{}, err
+	}
+	return creditNote, nil
+}
+
+// GetCreditNoteApplications returns a credit note and the invoices it has
+// been applied to, oldest first.
+func (s *InvoiceService) GetCreditNoteApplications(ctx context.Context, actor Actor, creditNoteID pgtype.UUID) (repositories.InvoiceCreditNote, []repositories.InvoiceCreditNoteApplication, error) {
+	creditNote, err := s.GetCreditNote(ctx, actor, creditNoteID)
+	if err != nil {
+		return repositories.InvoiceCreditNote{}, nil, err
+	}
+	applications, err := s.queries.ListCreditNoteApplications(ctx, creditNote.ID)
+	if err != nil {
+		return repositories.InvoiceCreditNote{}, nil, fmt.Errorf("failed to list credit note applications: %w", err)
+	}
+	return creditNote, applications, nil
+}
+
+// GetCreditNotePDF returns the path of a credit note's generated PDF.
+func (s *InvoiceService) GetCreditNotePDF(ctx context.Context, actor Actor, creditNoteID pgtype.UUID) (string, error) {
+	creditNote, err := s.GetCreditNote(ctx, actor, creditNoteID)
+	if err != nil {
+		return "", err
+	}
+	return pdfPathFor(creditNote.PdfStatus, creditNote.PdfPath, "credit note")
+}
+
+// ListCreditNotes lists a page of the credit notes issued against an invoice
+// the actor has access to, newest first.
+func (s *InvoiceService) ListCreditNotes(ctx context.Context, actor Actor, invoiceID pgtype.UUID, page PageRequest) ([]repositories.InvoiceCreditNote, utils.Page, error) {
+	if _, err := s.GetInvoice(ctx, actor, invoiceID); err != nil {
+		return nil, utils.Page{}, err
+	}
+
+	cursorCreatedAt, cursorID, err := cursorParams(page.Cursor)
+	if err != nil {
+		return nil, utils.Page{}, err
+	}
+	creditNotes, err := s.queries.ListCreditNotesByInvoiceID(ctx, repositories.ListCreditNotesByInvoiceIDParams{
+		InvoiceID:       invoiceID,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		Size:            page.Size + 1,
+	})
+	if err != nil {
+		log.Printf("InvoiceService - Failed to list credit notes: %v", err)
+		return nil, utils.Page{}, fmt.Errorf("failed to list credit notes: %w", err)
+	}
+	creditNotes, pageInfo := pageOf(creditNotes, page.Size, func(n repositories.InvoiceCreditNote) utils.Cursor {
+		return rowCursor(n.CreatedAt, n.ID)
+	})
+
+	if page.IncludeTotal {
+		total, err := s.queries.CountCreditNotesByInvoiceID(ctx, invoiceID)
+		if err != nil {
+			return nil, utils.Page{}, fmt.Errorf("failed to count credit notes: %w", err)
+		}
+		pageInfo.Total = &total
+	}
+	return creditNotes, pageInfo, nil
+}
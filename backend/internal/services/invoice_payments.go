@@ -0,0 +1,186 @@
+package services
+
+import (
+	"backend/internal/dto"
+	"backend/internal/money"
+	"backend/internal/repositories"
+	"backend/utils"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// CreatePayment records a payment of an invoice of a ticket the actor has
+// access to. Payments can be partial, but together with those still pending
+// and the credit applied to the invoice they cannot come to more than it. The
+// invoice is locked while the payment is recorded and is marked paid in the
+// same transaction once it is covered.
+func (s *InvoiceService) CreatePayment(ctx context.Context, actor Actor, paymentDto dto.CreatePaymentRequest) (repositories.InvoicePayment, repositories.InvoiceInvoice, error) {
+	invoiceID, err := parseUUID(paymentDto.InvoiceID)
+	if err != nil {
+		return repositories.InvoicePayment{}, repositories.InvoiceInvoice{}, fmt.Errorf("invalid invoice ID: %w", err)
+	}
+	invoice, err := s.GetInvoice(ctx, actor, invoiceID)
+	if err != nil {
+		return repositories.InvoicePayment{}, repositories.InvoiceInvoice{}, err
+	}
+	currency := money.Currency(invoice.Currency)
+	if !currency.Fits(paymentDto.Amount) {
+		return repositories.InvoicePayment{}, repositories.InvoiceInvoice{}, utils.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("amount has too many decimal places: %s amounts take %d", currency, currency.MinorUnits()))
+	}
+	status := repositories.InvoicePaymentStatus(orDefault(paymentDto.Status, string(repositories.InvoicePaymentStatusCOMPLETED)))
+
+	var payment repositories.InvoicePayment
+	var previous repositories.InvoiceInvoiceStatus
+	err = withTx(ctx, s.queries, func(qtx *repositories.Queries) error {
+		locked, err := qtx.GetInvoiceByIDForUpdate(ctx, invoice.ID)
+		if err != nil {
+			return fmt.Errorf("failed to lock invoice: %w", err)
+		}
+		previous = locked.Status.InvoiceInvoiceStatus
+		if locked.Status.InvoiceInvoiceStatus != repositories.InvoiceInvoiceStatusPENDING {
+			return utils.NewHTTPError(http.StatusConflict, fmt.Sprintf("the invoice is %s and takes no more payments", locked.Status.InvoiceInvoiceStatus))
+		}
+
+		open, err := openAmount(ctx, qtx, locked)
+		if err != nil {
+			return err
+		}
+		if paymentDto.Amount.Cmp(open) > 0 {
+			return utils.NewHTTPError(http.StatusConflict, fmt.Sprintf("the payment is more than the %s left to pay", money.Money{Amount: open, Currency: currency}))
+		}
+
+		payment, err = qtx.CreatePayment(ctx, repositories.CreatePaymentParams{
+			InvoiceID:   locked.ID,
+			Amount:      paymentDto.Amount,
+			PaymentDate: pgtype.Timestamptz{Time: time.Now().UTC(), Valid: true},
+			Method:      repositories.NullInvoicePaymentMethod{InvoicePaymentMethod: repositories.InvoicePaymentMethod(paymentDto.Method), Valid: true},
+			Status:      repositories.NullInvoicePaymentStatus{InvoicePaymentStatus: status, Valid: true},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create payment: %w", err)
+		}
+		invoice, err = qtx.SyncInvoiceBalance(ctx, locked.ID)
+		if err != nil {
+			return fmt.Errorf("failed to update invoice: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("InvoiceService - Failed to record payment of invoice %s: %v", invoiceID.String(), err)
+		return repositories.InvoicePayment{}, repositories.InvoiceInvoice{}, err
+	}
+
+	s.notifyIfPaid(ctx, previous, invoice)
+	return payment, invoice, nil
+}
+
+// UpdatePaymentStatus settles a pending payment as completed or failed and
+// marks its invoice paid if that completes it.
+func (s *InvoiceService) UpdatePaymentStatus(ctx context.Context, actor Actor, paymentID pgtype.UUID, statusDto dto.UpdatePaymentStatusRequest) (repositories.InvoicePayment, repositories.InvoiceInvoice, error) {
+	payment, _, err := s.GetPayment(ctx, actor, paymentID)
+	if err != nil {
+		return repositories.InvoicePayment{}, repositories.InvoiceInvoice{}, err
+	}
+
+	var invoice repositories.InvoiceInvoice
+	var previous repositories.InvoiceInvoiceStatus
+	err = withTx(ctx, s.queries, func(qtx *repositories.Queries) error {
+		locked, err := qtx.GetInvoiceByIDForUpdate(ctx, payment.InvoiceID)
+		if err != nil {
+			return fmt.Errorf("failed to lock invoice: %w", err)
+		}
+		previous = locked.Status.InvoiceInvoiceStatus
+		payment, err = qtx.UpdatePendingPaymentStatus(ctx, repositories.UpdatePendingPaymentStatusParams{
+			ID:     payment.ID,
+			Status: repositories.NullInvoicePaymentStatus{InvoicePaymentStatus: repositories.InvoicePaymentStatus(statusDto.Status), Valid: true},
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return utils.NewHTTPError(http.StatusConflict, "only pending payments can be completed or failed")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update payment: %w", err)
+		}
+		invoice, err = qtx.SyncInvoiceBalance(ctx, payment.InvoiceID)
+		if err != nil {
+			return fmt.Errorf("failed to update invoice: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("InvoiceService - Failed to update payment %s: %v", paymentID.String(), err)
+		return repositories.InvoicePayment{}, repositories.InvoiceInvoice{}, err
+	}
+
+	s.notifyIfPaid(ctx, previous, invoice)
+	return payment, invoice, nil
+}
+
+// GetPayment returns a payment of an invoice the actor has access to, and
+// that invoice.
+func (s *InvoiceService) GetPayment(ctx context.Context, actor Actor, paymentID pgtype.UUID) (repositories.InvoicePayment, repositories.InvoiceInvoice, error) {
+	payment, err := s.queries.GetPaymentByID(ctx, paymentID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repositories.InvoicePayment{}, repositories.InvoiceInvoice{}, utils.NewHTTPError(http.StatusNotFound, "payment not found")
+	}
+	if err != nil {
+		return repositories.InvoicePayment{}, repositories.InvoiceInvoice{}, fmt.Errorf("failed to get payment: %w", err)
+	}
+	invoice, err := s.GetInvoice(ctx, actor, payment.InvoiceID)
+	if err != nil {
+		return repositories.InvoicePayment{}, repositories.InvoiceInvoice{}, err
+	}
+	return payment, invoice, nil
+}
+
+// ListPayments lists a page of the payments of an invoice the actor has
+// access to, newest first, along with the invoice.
+func (s *InvoiceService) ListPayments(ctx context.Context, actor Actor, invoiceID pgtype.UUID, page PageRequest) ([]repositories.InvoicePayment, repositories.InvoiceInvoice, utils.Page, error) {
+	invoice, err := s.GetInvoice(ctx, actor, invoiceID)
+	if err != nil {
+		return nil, repositories.InvoiceInvoice{}, utils.Page{}, err
+	}
+
+	cursorCreatedAt, cursorID, err := cursorParams(page.Cursor)
+	if err != nil {
+		return nil, repositories.InvoiceInvoice{}, utils.Page{}, err
+	}
+	payments, err := s.queries.ListPaymentsByInvoiceID(ctx, repositories.ListPaymentsByInvoiceIDParams{
+		InvoiceID:       invoiceID,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		Size:            page.Size + 1,
+	})
+	if err != nil {
+		log.Printf("InvoiceService - Failed to list payments: %v", err)
+		return nil, repositories.InvoiceInvoice{}, utils.Page{}, fmt.Errorf("failed to list payments: %w", err)
+	}
+	payments, pageInfo := pageOf(payments, page.Size, func(p repositories.InvoicePayment) utils.Cursor {
+		return rowCursor(p.CreatedAt, p.ID)
+	})
+
+	if page.IncludeTotal {
+		total, err := s.queries.CountPaymentsByInvoiceID(ctx, invoiceID)
+		if err != nil {
+			return nil, repositories.InvoiceInvoice{}, utils.Page{}, fmt.Errorf("failed to count payments: %w", err)
+		}
+		pageInfo.Total = &total
+	}
+	return payments, invoice, pageInfo, nil
+}
+
+// notifyIfPaid tells the customer when a payment has just paid their invoice
+// in full.
+func (s *InvoiceService) notifyIfPaid(ctx context.Context, previous repositories.InvoiceInvoiceStatus, invoice repositories.InvoiceInvoice) {
+	if previous == repositories.InvoiceInvoiceStatusPAID || invoice.Status.InvoiceInvoiceStatus != repositories.InvoiceInvoiceStatusPAID {
+		return
+	}
+	amount := money.Money{Amount: invoice.Amount, Currency: money.Currency(invoice.Currency)}
+	s.notifyCustomer(ctx, invoice, NotificationInvoicePaid, fmt.Sprintf("Your invoice of %s was paid in full", amount))
+}
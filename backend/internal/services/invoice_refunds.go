@@ -0,0 +1,199 @@
+package services
+
+import (
+	"backend/internal/dto"
+	"backend/internal/money"
+	"backend/internal/repositories"
+	"backend/utils"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Kinds of the documents numbered per tenant, and the formats of their
+// numbers.
+const (
+	documentRefund     = "REFUND"
+	documentCreditNote = "CREDIT_NOTE"
+
+	refundNumberFormat     = "RF-%06d"
+	creditNoteNumberFormat = "CN-%06d"
+)
+
+// nextDocumentNumber takes the next number of the tenant's series of kind.
+// The series row stays locked until the transaction ends, so numbers are
+// handed out without gaps or repeats.
+func nextDocumentNumber(ctx context.Context, qtx *repositories.Queries, tenantID pgtype.UUID, kind, format string) (string, error) {
+	n, err := qtx.NextDocumentNumber(ctx, repositories.NextDocumentNumberParams{TenantID: tenantID, Kind: kind})
+	if err != nil {
+		return "", fmt.Errorf("failed to number document: %w", err)
+	}
+	return fmt.Sprintf(format, n), nil
+}
+
+// CreateRefund refunds a completed payment of a paid invoice, in full or in
+// part. A payment can be refunded more than once until all of it has been
+// given back. The invoice is locked while the refund is recorded and is
+// marked refunded, or partially refunded, in the same transaction.
+func (s *InvoiceService) CreateRefund(ctx context.Context, actor Actor, paymentID pgtype.UUID, refundDto dto.CreateRefundRequest) (repositories.InvoiceRefund, repositories.InvoiceInvoice, error) {
+	payment, _, err := s.GetPayment(ctx, actor, paymentID)
+	if err != nil {
+		return repositories.InvoiceRefund{}, repositories.InvoiceInvoice{}, err
+	}
+	actorUUID, err := parseUUID(actor.ID)
+	if err != nil {
+		return repositories.InvoiceRefund{}, repositories.InvoiceInvoice{}, fmt.Errorf("invalid actor id: %w", err)
+	}
+
+	var refund repositories.InvoiceRefund
+	var invoice repositories.InvoiceInvoice
+	err = withTx(ctx, s.queries, func(qtx *repositories.Queries) error {
+		locked, err := qtx.GetInvoiceByIDForUpdate(ctx, payment.InvoiceID)
+		if err != nil {
+			return fmt.Errorf("failed to lock invoice: %w", err)
+		}
+		switch locked.Status.InvoiceInvoiceStatus {
+		case repositories.InvoiceInvoiceStatusPAID, repositories.InvoiceInvoiceStatusPARTIALLYREFUNDED:
+		case repositories.InvoiceInvoiceStatusPENDING:
+			return utils.NewHTTPError(http.StatusConflict, "the invoice is not paid yet; issue a credit note to lower what is due on it")
+		default:
+			return utils.NewHTTPError(http.StatusConflict, fmt.Sprintf("the invoice is %s and cannot be refunded", locked.Status.InvoiceInvoiceStatus))
+		}
+
+		payment, err = qtx.GetPaymentByID(ctx, payment.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get payment: %w", err)
+		}
+		if payment.Status.InvoicePaymentStatus != repositories.InvoicePaymentStatusCOMPLETED {
+			return utils.NewHTTPError(http.StatusConflict, "only completed payments can be refunded")
+		}
+
+		currency := money.Currency(locked.Currency)
+		refunded, err := qtx.SumRefundsByPaymentID(ctx, payment.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get refunds of payment: %w", err)
+		}
+		refundable := payment.Amount.Sub(refunded)
+		if refundable.Sign() <= 0 {
+			return utils.NewHTTPError(http.StatusConflict, "the payment has already been refunded in full")
+		}
+		amount := refundDto.Amount
+		if amount.IsZero() {
+			amount = refundable
+		}
+		if !currency.Fits(amount) {
+			return utils.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("amount has too many decimal places: %s amounts take %d", currency, currency.MinorUnits()))
+		}
+		if amount.Cmp(refundable) > 0 {
+			return utils.NewHTTPError(http.StatusConflict, fmt.Sprintf("the refund is more than the %s left to refund of the payment", money.Money{Amount: refundable, Currency: currency}))
+		}
+
+		ticket, err := qtx.GetTicketByID(ctx, locked.TicketID)
+		if err != nil {
+			return fmt.Errorf("failed to get ticket: %w", err)
+		}
+		number, err := nextDocumentNumber(ctx, qtx, ticket.TenantID, documentRefund, refundNumberFormat)
+		if err != nil {
+			return err
+		}
+		refund, err = qtx.CreateRefund(ctx, repositories.CreateRefundParams{
+			TenantID:  ticket.TenantID,
+			Number:    number,
+			PaymentID: payment.ID,
+			InvoiceID: locked.ID,
+			Amount:    amount,
+			Reason:    makeText(refundDto.Reason),
+			CreatedBy: actorUUID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create refund: %w", err)
+		}
+		invoice, err = qtx.SyncInvoiceRefunds(ctx, locked.ID)
+		if err != nil {
+			return fmt.Errorf("failed to update invoice: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("InvoiceService - Failed to refund payment %s: %v", paymentID.String(), err)
+		return repositories.InvoiceRefund{}, repositories.InvoiceInvoice{}, err
+	}
+
+	if err := s.pdfQueue.EnqueueRefundPDF(ctx, refund.ID.String()); err != nil {
+		params := recordEnqueueFailure(ctx, "refund", refund.ID, err, func(ctx context.Context, params repositories.RecordInvoicePDFParams) error {
+			return s.queries.RecordRefundPDF(ctx, repositories.RecordRefundPDFParams(params))
+		})
+		refund.PdfStatus, refund.PdfError = params.PdfStatus, params.PdfError
+	}
+
+	amount := money.Money{Amount: refund.Amount, Currency: money.Currency(invoice.Currency)}
+	s.notifyCustomer(ctx, invoice, NotificationInvoiceRefunded, fmt.Sprintf("A refund of %s was issued for your invoice", amount))
+	return refund, invoice, nil
+}
+
+// GetRefund returns a refund of an invoice the actor has access to, and that
+// invoice.
+func (s *InvoiceService) GetRefund(ctx context.Context, actor Actor, refundID pgtype.UUID) (repositories.InvoiceRefund, repositories.InvoiceInvoice, error) {
+	refund, err := s.queries.GetRefundByID(ctx, refundID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repositories.InvoiceRefund{}, repositories.InvoiceInvoice{}, utils.NewHTTPError(http.StatusNotFound, "refund not found")
+	}
+	if err != nil {
+		return repositories.InvoiceRefund{}, repositories.InvoiceInvoice{}, fmt.Errorf("failed to get refund: %w", err)
+	}
+	invoice, err := s.GetInvoice(ctx, actor, refund.InvoiceID)
+	if err != nil {
+		return repositories.InvoiceRefund{}, repositories.InvoiceInvoice{}, err
+	}
+	return refund, invoice, nil
+}
+
+// GetRefundPDF returns the path of a refund's generated PDF.
+func (s *InvoiceService) GetRefundPDF(ctx context.Context, actor Actor, refundID pgtype.UUID) (string, error) {
+	refund, _, err := s.GetRefund(ctx, actor, refundID)
+	if err != nil {
+		return "", err
+	}
+	return pdfPathFor(refund.PdfStatus, refund.PdfPath, "refund")
+}
+
+// ListRefunds lists a page of the refunds of an invoice the actor has access
+// to, newest first, along with the invoice.
+func (s *InvoiceService) ListRefunds(ctx context.Context, actor Actor, invoiceID pgtype.UUID, page PageRequest) ([]repositories.InvoiceRefund, repositories.InvoiceInvoice, utils.Page, error) {
+	invoice, err := s.GetInvoice(ctx, actor, invoiceID)
+	if err != nil {
+		return nil, repositories.InvoiceInvoice{}, utils.Page{}, err
+	}
+
+	cursorCreatedAt, cursorID, err := cursorParams(page.Cursor)
+	if err != nil {
+		return nil, repositories.InvoiceInvoice{}, utils.Page{}, err
+	}
+	refunds, err := s.queries.ListRefundsByInvoiceID(ctx, repositories.ListRefundsByInvoiceIDParams{
+		InvoiceID:       invoiceID,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		Size:            page.Size + 1,
+	})
+	if err != nil {
+		log.Printf("InvoiceService - Failed to list refunds: %v", err)
+		return nil, repositories.InvoiceInvoice{}, utils.Page{}, fmt.Errorf("failed to list refunds: %w", err)
+	}
+	refunds, pageInfo := pageOf(refunds, page.Size, func(r repositories.InvoiceRefund) utils.Cursor {
+		return rowCursor(r.CreatedAt, r.ID)
+	})
+
+	if page.IncludeTotal {
+		total, err := s.queries.CountRefundsByInvoiceID(ctx, invoiceID)
+		if err != nil {
+			return nil, repositories.InvoiceInvoice{}, utils.Page{}, fmt.Errorf("failed to count refunds: %w", err)
+		}
+		pageInfo.Total = &total
+	}
+	return refunds, invoice, pageInfo, nil
+}
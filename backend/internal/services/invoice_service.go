@@ -2,53 +2,278 @@ package services
 
 import (
 	"backend/internal/dto"
+	"backend/internal/money"
 	"backend/internal/repositories"
+	"backend/jobs"
+	"backend/utils"
 	"context"
+	"errors"
 	"fmt"
 	"log"
-	"strconv"
+	"net/http"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+// InvoicePDFQueue renders stored invoices, refunds and credit notes to PDF in
+// the background.
+type InvoicePDFQueue interface {
+	EnqueueInvoicePDF(ctx context.Context, invoiceID string) error
+	EnqueueRefundPDF(ctx context.Context, refundID string) error
+	EnqueueCreditNotePDF(ctx context.Context, creditNoteID string) error
+}
+
+type noopInvoicePDFQueue struct{}
+
+func (noopInvoicePDFQueue) EnqueueInvoicePDF(ctx context.Context, invoiceID string) error { return nil }
+func (noopInvoicePDFQueue) EnqueueRefundPDF(ctx context.Context, refundID string) error   { return nil }
+func (noopInvoicePDFQueue) EnqueueCreditNotePDF(ctx context.Context, creditNoteID string) error {
+	return nil
+}
+
+// invoicePDFQueue drops PDF jobs until SetInvoicePDFQueue is called at
+// startup, before the invoice service is built.
+var invoicePDFQueue InvoicePDFQueue = noopInvoicePDFQueue{}
+
+func SetInvoicePDFQueue(q InvoicePDFQueue) {
+	invoicePDFQueue = q
+}
+
 type InvoiceService struct {
-	queries *repositories.Queries
+	queries  *repositories.Queries
+	notifier Notifier
+	pdfQueue InvoicePDFQueue
 }
 
 func NewInvoiceService() *InvoiceService {
 	queries := repositories.GetDB()
-	return &InvoiceService{queries: queries}
+	return &InvoiceService{queries: queries, notifier: notifier, pdfQueue: invoicePDFQueue}
 }
 
-func (s *InvoiceService) CreateInvoice(ctx context.Context, invoiceDto dto.CreateInvoiceRequest) error {
+// InvoiceNotification is a notification about an invoice of one of the
+// customer's tickets.
+type InvoiceNotification struct {
+	InvoiceID string `json:"invoice_id"`
+	TicketID  string `json:"ticket_id"`
+	Title     string `json:"title"`
+	Message   string `json:"message"`
+}
+
+// CreateInvoice stores an invoice and its lines for a ticket of one of the
+// actor's tenants and enqueues the generation of its PDF. The totals are
+// worked out here from the lines; an invoice given only an amount gets a
+// single untaxed line for it.
+func (s *InvoiceService) CreateInvoice(ctx context.Context, actor Actor, invoiceDto dto.CreateInvoiceRequest) (repositories.InvoiceInvoice, []repositories.InvoiceInvoiceLine, error) {
 	ticketUUID, err := parseUUID(invoiceDto.TicketID)
 	if err != nil {
-		return fmt.Errorf("invalid ticket ID: %w", err)
+		return repositories.InvoiceInvoice{}, nil, fmt.Errorf("invalid ticket ID: %w", err)
 	}
-
-	// Convert float64 amount to pgtype.Numeric
-	amountNumeric := pgtype.Numeric{}
-	if err := amountNumeric.Scan(strconv.FormatFloat(invoiceDto.Amount, 'f', -1, 64)); err != nil {
-		return fmt.Errorf("failed to convert amount to pgtype.Numeric: %w", err)
+	ticket, err := getTicketForActor(ctx, s.queries, ticketUUID, actor)
+	if err != nil {
+		return repositories.InvoiceInvoice{}, nil, err
 	}
 
 	dueDate, err := time.Parse("2006-01-02", invoiceDto.DueDate)
 	if err != nil {
-		return fmt.Errorf("invalid due date format: %w", err)
+		return repositories.InvoiceInvoice{}, nil, utils.NewHTTPError(http.StatusBadRequest, "due_date must be a date in YYYY-MM-DD format")
+	}
+	currency, err := money.ParseCurrency(string(invoiceDto.Currency))
+	if err != nil {
+		return repositories.InvoiceInvoice{}, nil, utils.NewHTTPError(http.StatusBadRequest, "currency must be an ISO 4217 currency code")
 	}
 
-	newInvoice := repositories.CreateInvoiceParams{
-		TicketID: ticketUUID,
-		Amount:   amountNumeric,
-		Currency: invoiceDto.Currency,
-		DueDate:  pgtype.Date{Time: dueDate, Valid: true},
+	lines := invoiceDto.Lines
+	switch {
+	case len(lines) > 0 && !invoiceDto.Amount.IsZero():
+		return repositories.InvoiceInvoice{}, nil, utils.NewHTTPError(http.StatusBadRequest, "amount is worked out from the lines; send either amount or lines")
+	case len(lines) == 0 && invoiceDto.Amount.Sign() <= 0:
+		return repositories.InvoiceInvoice{}, nil, utils.NewHTTPError(http.StatusBadRequest, "an invoice needs an amount or at least one line")
+	case len(lines) == 0:
+		lines = []dto.InvoiceLineDto{{Description: "Services", Quantity: money.NewDecimal(1, 0), UnitPrice: invoiceDto.Amount, TaxRate: &money.Decimal{}}}
 	}
 
-	_, err = s.queries.CreateInvoice(ctx, newInvoice)
+	taxRate, err := taxRateForTenant(ctx, s.queries, ticket.TenantID)
+	if err != nil {
+		return repositories.InvoiceInvoice{}, nil, err
+	}
+	totals, err := computeInvoiceTotals(lines, taxRate, currency)
+	if err != nil {
+		return repositories.InvoiceInvoice{}, nil, err
+	}
+	if totals.Total.Sign() <= 0 {
+		return repositories.InvoiceInvoice{}, nil, utils.NewHTTPError(http.StatusBadRequest, "the invoice total must be more than zero")
+	}
+
+	var invoice repositories.InvoiceInvoice
+	var invoiceLines []repositories.InvoiceInvoiceLine
+	err = withTx(ctx, s.queries, func(qtx *repositories.Queries) error {
+		invoice, err = qtx.CreateInvoice(ctx, repositories.CreateInvoiceParams{
+			TicketID:      ticketUUID,
+			Amount:        totals.Total,
+			Currency:      string(currency),
+			Status:        repositories.NullInvoiceInvoiceStatus{InvoiceInvoiceStatus: repositories.InvoiceInvoiceStatusPENDING, Valid: true},
+			DueDate:       pgtype.Date{Time: dueDate, Valid: true},
+			Subtotal:      totals.Subtotal,
+			DiscountTotal: totals.Discount,
+			TaxTotal:      totals.Tax,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create invoice: %w", err)
+		}
+		for n, line := range totals.Lines {
+			created, err := qtx.CreateInvoiceLine(ctx, repositories.CreateInvoiceLineParams{
+				InvoiceID:   invoice.ID,
+				Position:    int32(n + 1),
+				Description: line.Description,
+				Quantity:    line.Quantity,
+				UnitPrice:   line.UnitPrice,
+				TaxRate:     line.TaxRate,
+				Discount:    line.Discount,
+				Subtotal:    line.Subtotal,
+				TaxAmount:   line.TaxAmount,
+				Total:       line.Total,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to create invoice line: %w", err)
+			}
+			invoiceLines = append(invoiceLines, created)
+		}
+		return nil
+	})
 	if err != nil {
 		log.Printf("InvoiceService - Failed to create invoice: %v", err)
-		return fmt.Errorf("failed to create invoice: %w", err)
+		return repositories.InvoiceInvoice{}, nil, err
 	}
-	return nil
+
+	if err := s.pdfQueue.EnqueueInvoicePDF(ctx, invoice.ID.String()); err != nil {
+		params := recordEnqueueFailure(ctx, "invoice", invoice.ID, err, s.queries.RecordInvoicePDF)
+		invoice.PdfStatus, invoice.PdfError = params.PdfStatus, params.PdfError
+	}
+
+	s.notifyCustomer(ctx, invoice, NotificationInvoiceCreated, fmt.Sprintf("A new invoice of %s was issued", money.Money{Amount: totals.Total, Currency: currency}))
+	return invoice, invoiceLines, nil
+}
+
+// GetInvoice returns an invoice of a ticket the actor has access to.
+func (s *InvoiceService) GetInvoice(ctx context.Context, actor Actor, invoiceID pgtype.UUID) (repositories.InvoiceInvoice, error) {
+	invoice, err := s.queries.GetInvoiceByID(ctx, invoiceID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repositories.InvoiceInvoice{}, utils.NewHTTPError(http.StatusNotFound, "invoice not found")
+	}
+	if err != nil {
+		return repositories.InvoiceInvoice{}, fmt.Errorf("failed to get invoice: %w", err)
+	}
+	if _, err := getTicketForActor(ctx, s.queries, invoice.TicketID, actor); err != nil {
+		return repositories.InvoiceInvoice{}, err
+	}
+	return invoice, nil
+}
+
+// GetInvoiceLines returns the lines of an invoice of a ticket the actor has
+// access to, in order.
+func (s *InvoiceService) GetInvoiceLines(ctx context.Context, actor Actor, invoiceID pgtype.UUID) (repositories.InvoiceInvoice, []repositories.InvoiceInvoiceLine, error) {
+	invoice, err := s.GetInvoice(ctx, actor, invoiceID)
+	if err != nil {
+		return repositories.InvoiceInvoice{}, nil, err
+	}
+	lines, err := s.queries.ListInvoiceLinesByInvoiceID(ctx, invoice.ID)
+	if err != nil {
+		return repositories.InvoiceInvoice{}, nil, fmt.Errorf("failed to list invoice lines: %w", err)
+	}
+	return invoice, lines, nil
+}
+
+// GetInvoicePDF returns the path of an invoice's generated PDF.
+func (s *InvoiceService) GetInvoicePDF(ctx context.Context, actor Actor, invoiceID pgtype.UUID) (string, error) {
+	invoice, err := s.GetInvoice(ctx, actor, invoiceID)
+	if err != nil {
+		return "", err
+	}
+	return pdfPathFor(invoice.PdfStatus, invoice.PdfPath, "invoice")
+}
+
+// pdfPathFor returns the path of a generated PDF of an invoice, refund or
+// credit note, or a conflict while it is pending or after it failed.
+func pdfPathFor(status string, path pgtype.Text, label string) (string, error) {
+	switch status {
+	case jobs.InvoicePDFGenerated:
+		return path.String, nil
+	case jobs.InvoicePDFFailed:
+		return "", utils.NewHTTPError(http.StatusConflict, fmt.Sprintf("the %s PDF could not be generated", label))
+	default:
+		return "", utils.NewHTTPError(http.StatusConflict, fmt.Sprintf("the %s PDF is not ready yet", label))
+	}
+}
+
+// recordEnqueueFailure stores with record that the PDF of an invoice, refund
+// or credit note could not be enqueued, and returns what it stored. The
+// document stands without its PDF, which can be generated again later, so the
+// failure is recorded on it rather than returned.
+func recordEnqueueFailure(ctx context.Context, kind string, id pgtype.UUID, err error, record func(context.Context, repositories.RecordInvoicePDFParams) error) repositories.RecordInvoicePDFParams {
+	log.Printf("InvoiceService - Failed to enqueue PDF of %s %s: %v", kind, id.String(), err)
+	params := repositories.RecordInvoicePDFParams{
+		PdfStatus: jobs.InvoicePDFFailed,
+		PdfError:  makeText(fmt.Sprintf("failed to enqueue: %v", err)),
+		ID:        id,
+	}
+	if err := record(ctx, params); err != nil {
+		log.Printf("InvoiceService - Failed to record PDF status of %s %s: %v", kind, id.String(), err)
+	}
+	return params
+}
+
+// notifyCustomer tells the customer of the invoiced ticket about the invoice.
+func (s *InvoiceService) notifyCustomer(ctx context.Context, invoice repositories.InvoiceInvoice, eventType, message string) {
+	ticket, err := s.queries.GetTicketByID(ctx, invoice.TicketID)
+	if err != nil {
+		log.Printf("InvoiceService - Failed to load ticket of invoice %s: %v", invoice.ID.String(), err)
+		return
+	}
+	if !ticket.CustomerID.Valid {
+		return
+	}
+	s.notifier.NotifyUser(ticket.CustomerID.String(), eventType, InvoiceNotification{
+		InvoiceID: invoice.ID.String(),
+		TicketID:  ticket.ID.String(),
+		Title:     ticket.Title,
+		Message:   message,
+	})
+}
+
+// ListInvoicesByTenantID lists a page of the tenant's invoices, newest first.
+func (s *InvoiceService) ListInvoicesByTenantID(ctx context.Context, userID, tenantID string, page PageRequest) ([]repositories.ListInvoicesByTenantIDRow, utils.Page, error) {
+	tenantUUID, err := requireTenantMember(ctx, s.queries, userID, tenantID)
+	if err != nil {
+		return nil, utils.Page{}, err
+	}
+
+	cursorCreatedAt, cursorID, err := cursorParams(page.Cursor)
+	if err != nil {
+		return nil, utils.Page{}, err
+	}
+	params := repositories.ListInvoicesByTenantIDParams{
+		TenantID:        tenantUUID,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		Size:            page.Size + 1,
+	}
+	invoices, err := s.queries.ListInvoicesByTenantID(ctx, params)
+	if err != nil {
+		log.Printf("InvoiceService - Failed to list invoices: %v", err)
+		return nil, utils.Page{}, fmt.Errorf("failed to list invoices: %w", err)
+	}
+	invoices, pageInfo := pageOf(invoices, page.Size, func(i repositories.ListInvoicesByTenantIDRow) utils.Cursor {
+		return rowCursor(i.CreatedAt, i.ID)
+	})
+
+	if page.IncludeTotal {
+		total, err := s.queries.CountInvoicesByTenantID(ctx, tenantUUID)
+		if err != nil {
+			return nil, utils.Page{}, fmt.Errorf("failed to count invoices: %w", err)
+		}
+		pageInfo.Total = &total
+	}
+	return invoices, pageInfo, nil
 }
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"backend/internal/dto"
+	"backend/internal/money"
+	"backend/internal/repositories"
+	"backend/utils"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// quantityPlaces and taxRatePlaces are the precision of the quantity and
+	// tax_rate columns; amounts take that of their currency.
+	quantityPlaces = 3
+	taxRatePlaces  = 2
+)
+
+// invoiceLine is an invoice line with its amounts worked out. Subtotal, tax
+// and total are each rounded to the minor unit of the invoice's currency.
+type invoiceLine struct {
+	Description string
+	Quantity    money.Decimal
+	UnitPrice   money.Decimal
+	TaxRate     money.Decimal
+	Discount    money.Decimal
+	Subtotal    money.Decimal
+	TaxAmount   money.Decimal
+	Total       money.Decimal
+}
+
+// invoiceTotals are the lines of an invoice and their sums.
+type invoiceTotals struct {
+	Lines    []invoiceLine
+	Subtotal money.Decimal
+	Discount money.Decimal
+	Tax      money.Decimal
+	Total    money.Decimal
+}
+
+// computeInvoiceTotals works out the amounts of each line and of the invoice.
+// A line's subtotal is its quantity times its unit price; tax is charged on
+// the subtotal less the line's discount, at the line's rate or, when it has
+// none, at defaultRate. Each line is rounded on its own so the lines of the
+// PDF add up to its totals.
+func computeInvoiceTotals(lines []dto.InvoiceLineDto, defaultRate money.Decimal, currency money.Currency) (invoiceTotals, error) {
+	var totals invoiceTotals
+	for n, line := range lines {
+		taxRate := defaultRate
+		if line.TaxRate != nil {
+			taxRate = *line.TaxRate
+		}
+		if line.Quantity.Places() > quantityPlaces || taxRate.Places() > taxRatePlaces {
+			return invoiceTotals{}, utils.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("line %d has too many decimal places: quantities take %d and tax rates %d", n+1, quantityPlaces, taxRatePlaces))
+		}
+		if !currency.Fits(line.UnitPrice) || !currency.Fits(line.Discount) {
+			return invoiceTotals{}, utils.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("line %d has too many decimal places: %s amounts take %d", n+1, currency, currency.MinorUnits()))
+		}
+
+		subtotal := currency.Round(line.Quantity.Mul(line.UnitPrice))
+		if line.Discount.Cmp(subtotal) > 0 {
+			return invoiceTotals{}, utils.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("the discount of line %d is more than its subtotal", n+1))
+		}
+		net := subtotal.Sub(line.Discount)
+		tax := currency.Round(net.Mul(taxRate).Shift(-2))
+		total := net.Add(tax)
+
+		totals.Lines = append(totals.Lines, invoiceLine{
+			Description: line.Description,
+			Quantity:    line.Quantity,
+			UnitPrice:   line.UnitPrice,
+			TaxRate:     taxRate,
+			Discount:    line.Discount,
+			Subtotal:    subtotal,
+			TaxAmount:   tax,
+			Total:       total,
+		})
+		totals.Subtotal = totals.Subtotal.Add(subtotal)
+		totals.Discount = totals.Discount.Add(line.Discount)
+		totals.Tax = totals.Tax.Add(tax)
+		totals.Total = totals.Total.Add(total)
+	}
+	return totals, nil
+}
+
+// taxRateForTenant is the tax rate, in percent, charged on the tenant's
+// invoice lines that do not carry their own. Tenants without settings charge
+// no tax.
+func taxRateForTenant(ctx context.Context, queries *repositories.Queries, tenantID pgtype.UUID) (money.Decimal, error) {
+	settings, err := queries.GetTenantSettings(ctx, tenantID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return money.Decimal{}, nil
+	}
+	if err != nil {
+		return money.Decimal{}, fmt.Errorf("failed to get tenant settings: %w", err)
+	}
+	return settings.TaxRate, nil
+}
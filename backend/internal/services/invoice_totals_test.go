@@ -0,0 +1,242 @@
+package services
+
+import (
+	"backend/internal/dto"
+	"backend/internal/money"
+	"backend/utils"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func decimal(t *testing.T, s string) money.Decimal {
+	t.Helper()
+	d, err := money.ParseDecimal(s)
+	if err != nil {
+		t.Fatalf("ParseDecimal(%q): %v", s, err)
+	}
+	return d
+}
+
+func TestComputeInvoiceTotals(t *testing.T) {
+	type line struct {
+		quantity, unitPrice, discount string
+		taxRate                       *string
+	}
+	type lineTotals struct {
+		taxRate, subtotal, tax, total string
+	}
+	rate := func(s string) *string { return &s }
+
+	tests := []struct {
+		name        string
+		currency    money.Currency
+		defaultRate string
+		lines       []line
+		want        []lineTotals
+		subtotal    string
+		discount    string
+		tax         string
+		total       string
+	}{
+		{
+			name:        "USD lines rounded to cents on their own",
+			currency:    "USD",
+			defaultRate: "0",
+			lines: []line{
+				{quantity: "3", unitPrice: "0.35", taxRate: rate("7.5")},
+				{quantity: "1.5", unitPrice: "9.99", taxRate: rate("7.5")},
+			},
+			want: []lineTotals{
+				// 1.05 × 7.5% = 0.07875
+				{taxRate: "7.5", subtotal: "1.05", tax: "0.08", total: "1.13"},
+				// 1.5 × 9.99 = 14.985, then 14.99 × 7.5% = 1.124250
+				{taxRate: "7.5", subtotal: "14.99", tax: "1.12", total: "16.11"},
+			},
+			subtotal: "16.04", discount: "0", tax: "1.2", total: "17.24",
+		},
+		{
+			name:        "JPY has no minor unit",
+			currency:    "JPY",
+			defaultRate: "10",
+			lines: []line{
+				{quantity: "0.5", unitPrice: "1285"},
+				{quantity: "2", unitPrice: "105", discount: "15"},
+			},
+			want: []lineTotals{
+				// 642.5 rounds up, then 64.3 rounds down
+				{taxRate: "10", subtotal: "643", tax: "64", total: "707"},
+				{taxRate: "10", subtotal: "210", tax: "20", total: "215"},
+			},
+			subtotal: "853", discount: "15", tax: "84", total: "922",
+		},
+		{
+			name:        "KWD keeps three places",
+			currency:    "KWD",
+			defaultRate: "5",
+			lines: []line{
+				{quantity: "1.25", unitPrice: "3.333"},
+			},
+			want: []lineTotals{
+				// 4.16625, then 4.166 × 5% = 0.2083
+				{taxRate: "5", subtotal: "4.166", tax: "0.208", total: "4.374"},
+			},
+			subtotal: "4.166", discount: "0", tax: "0.208", total: "4.374",
+		},
+		{
+			name:        "tenant rate applies to lines without their own",
+			currency:    "USD",
+			defaultRate: "20",
+			lines: []line{
+				{quantity: "1", unitPrice: "10.00"},
+				{quantity: "1", unitPrice: "10.00", taxRate: rate("0")},
+			},
+			want: []lineTotals{
+				{taxRate: "20", subtotal: "10", tax: "2", total: "12"},
+				{taxRate: "0", subtotal: "10", tax: "0", total: "10"},
+			},
+			subtotal: "20", discount: "0", tax: "2", total: "22",
+		},
+		{
+			name:        "discount equal to the subtotal",
+			currency:    "USD",
+			defaultRate: "10",
+			lines: []line{
+				{quantity: "2", unitPrice: "5", discount: "10"},
+			},
+			want: []lineTotals{
+				{taxRate: "10", subtotal: "10", tax: "0", total: "0"},
+			},
+			subtotal: "10", discount: "10", tax: "0", total: "0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var lines []dto.InvoiceLineDto
+			for _, l := range tt.lines {
+				line := dto.InvoiceLineDto{
+					Description: "item",
+					Quantity:    decimal(t, l.quantity),
+					UnitPrice:   decimal(t, l.unitPrice),
+				}
+				if l.discount != "" {
+					line.Discount = decimal(t, l.discount)
+				}
+				if l.taxRate != nil {
+					taxRate := decimal(t, *l.taxRate)
+					line.TaxRate = &taxRate
+				}
+				lines = append(lines, line)
+			}
+
+			totals, err := computeInvoiceTotals(lines, decimal(t, tt.defaultRate), tt.currency)
+			if err != nil {
+				t.Fatalf("computeInvoiceTotals: %v", err)
+			}
+			if len(totals.Lines) != len(tt.want) {
+				t.Fatalf("got %d lines, want %d", len(totals.Lines), len(tt.want))
+			}
+
+			var sum money.Decimal
+			for n, want := range tt.want {
+				got := totals.Lines[n]
+				if got.TaxRate.Cmp(decimal(t, want.taxRate)) != 0 ||
+					got.Subtotal.Cmp(decimal(t, want.subtotal)) != 0 ||
+					got.TaxAmount.Cmp(decimal(t, want.tax)) != 0 ||
+					got.Total.Cmp(decimal(t, want.total)) != 0 {
+					t.Errorf("line %d = rate %s, subtotal %s, tax %s, total %s; want rate %s, subtotal %s, tax %s, total %s",
+						n+1, got.TaxRate, got.Subtotal, got.TaxAmount, got.Total, want.taxRate, want.subtotal, want.tax, want.total)
+				}
+				if !tt.currency.Fits(got.Subtotal) || !tt.currency.Fits(got.TaxAmount) || !tt.currency.Fits(got.Total) {
+					t.Errorf("line %d has amounts finer than the %s minor unit", n+1, tt.currency)
+				}
+				if got.Subtotal.Sub(got.Discount).Add(got.TaxAmount).Cmp(got.Total) != 0 {
+					t.Errorf("line %d: subtotal - discount + tax != total", n+1)
+				}
+				sum = sum.Add(got.Total)
+			}
+
+			if sum.Cmp(totals.Total) != 0 {
+				t.Errorf("line totals add up to %s, invoice total is %s", sum, totals.Total)
+			}
+			if totals.Subtotal.Sub(totals.Discount).Add(totals.Tax).Cmp(totals.Total) != 0 {
+				t.Errorf("subtotal - discount + tax != total")
+			}
+			for _, check := range []struct {
+				name      string
+				got, want money.Decimal
+			}{
+				{"subtotal", totals.Subtotal, decimal(t, tt.subtotal)},
+				{"discount", totals.Discount, decimal(t, tt.discount)},
+				{"tax", totals.Tax, decimal(t, tt.tax)},
+				{"total", totals.Total, decimal(t, tt.total)},
+			} {
+				if check.got.Cmp(check.want) != 0 {
+					t.Errorf("%s = %s, want %s", check.name, check.got, check.want)
+				}
+			}
+		})
+	}
+}
+
+func TestComputeInvoiceTotalsRejects(t *testing.T) {
+	rate := func(s string) *money.Decimal {
+		d := decimal(t, s)
+		return &d
+	}
+
+	tests := []struct {
+		name        string
+		currency    money.Currency
+		defaultRate string
+		line        dto.InvoiceLineDto
+	}{
+		{
+			name:        "discount larger than the subtotal",
+			currency:    "USD",
+			defaultRate: "0",
+			line:        dto.InvoiceLineDto{Quantity: decimal(t, "2"), UnitPrice: decimal(t, "5"), Discount: decimal(t, "10.01")},
+		},
+		{
+			name:        "quantity with more than three places",
+			currency:    "USD",
+			defaultRate: "0",
+			line:        dto.InvoiceLineDto{Quantity: decimal(t, "1.0005"), UnitPrice: decimal(t, "5")},
+		},
+		{
+			name:        "tax rate with more than two places",
+			currency:    "USD",
+			defaultRate: "0",
+			line:        dto.InvoiceLineDto{Quantity: decimal(t, "1"), UnitPrice: decimal(t, "5"), TaxRate: rate("7.125")},
+		},
+		{
+			name:        "tenant rate with more than two places",
+			currency:    "USD",
+			defaultRate: "7.125",
+			line:        dto.InvoiceLineDto{Quantity: decimal(t, "1"), UnitPrice: decimal(t, "5")},
+		},
+		{
+			name:        "unit price finer than the minor unit",
+			currency:    "JPY",
+			defaultRate: "0",
+			line:        dto.InvoiceLineDto{Quantity: decimal(t, "1"), UnitPrice: decimal(t, "100.5")},
+		},
+		{
+			name:        "discount finer than the minor unit",
+			currency:    "USD",
+			defaultRate: "0",
+			line:        dto.InvoiceLineDto{Quantity: decimal(t, "1"), UnitPrice: decimal(t, "5"), Discount: decimal(t, "0.005")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := computeInvoiceTotals([]dto.InvoiceLineDto{tt.line}, decimal(t, tt.defaultRate), tt.currency)
+			var httpErr *utils.HTTPError
+			if !errors.As(err, &httpErr) || httpErr.Status != http.StatusBadRequest {
+				t.Errorf("got %v, want a 400 HTTPError", err)
+			}
+		})
+	}
+}
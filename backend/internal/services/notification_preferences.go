@@ -0,0 +1,227 @@
+package services
+
+import (
+	"backend/internal/dto"
+	"backend/internal/repositories"
+	"backend/utils"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Notification preference categories. Each notification type belongs to one.
+const (
+	NotificationCategoryAssigned     = "ASSIGNED"
+	NotificationCategoryComment      = "COMMENT"
+	NotificationCategoryStatusChange = "STATUS_CHANGE"
+	NotificationCategorySLA          = "SLA"
+	NotificationCategoryInvoice      = "INVOICE"
+)
+
+var notificationCategoryOrder = []string{
+	NotificationCategoryAssigned,
+	NotificationCategoryComment,
+	NotificationCategoryStatusChange,
+	NotificationCategorySLA,
+	NotificationCategoryInvoice,
+}
+
+var notificationCategories = map[string]string{
+	NotificationTicketAssigned:      NotificationCategoryAssigned,
+	NotificationCustomerReplied:     NotificationCategoryComment,
+	NotificationTicketReplied:       NotificationCategoryComment,
+	NotificationTicketStatusChanged: NotificationCategoryStatusChange,
+	NotificationSLAAtRisk:           NotificationCategorySLA,
+	NotificationSLABreached:         NotificationCategorySLA,
+	NotificationTicketEscalated:     NotificationCategorySLA,
+	NotificationInvoiceCreated:      NotificationCategoryInvoice,
+	NotificationInvoicePaid:         NotificationCategoryInvoice,
+	NotificationInvoiceRefunded:     NotificationCategoryInvoice,
+	NotificationInvoiceCredited:     NotificationCategoryInvoice,
+}
+
+// NotificationChannels are the channels a category of notifications is
+// delivered on.
+type NotificationChannels struct {
+	Category  string `json:"category"`
+	InApp     bool   `json:"in_app"`
+	Email     bool   `json:"email"`
+	Websocket bool   `json:"websocket"`
+}
+
+// NotificationPreferences are a user's channels for every category and their
+// quiet hours, if any.
+type NotificationPreferences struct {
+	Timezone        string                 `json:"timezone"`
+	QuietHoursStart *int16                 `json:"quiet_hours_start"`
+	QuietHoursEnd   *int16                 `json:"quiet_hours_end"`
+	Categories      []NotificationChannels `json:"categories"`
+}
+
+// GetPreferences returns the user's notification preferences, with every
+// channel enabled for categories they never changed.
+func (s *NotificationService) GetPreferences(ctx context.Context, userID string) (NotificationPreferences, error) {
+	userUUID, err := parseUUID(userID)
+	if err != nil {
+		return NotificationPreferences{}, fmt.Errorf("invalid user ID: %w", err)
+	}
+	return getNotificationPreferences(ctx, s.queries, userUUID)
+}
+
+// UpdatePreferences replaces the user's timezone and quiet hours and the
+// channels of the categories given. Omitting both quiet hours turns them off.
+func (s *NotificationService) UpdatePreferences(ctx context.Context, userID string, prefsDto dto.UpdateNotificationPreferencesDto) (NotificationPreferences, error) {
+	userUUID, err := parseUUID(userID)
+	if err != nil {
+		return NotificationPreferences{}, fmt.Errorf("invalid user ID: %w", err)
+	}
+	timezone := orDefault(prefsDto.Timezone, "UTC")
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return NotificationPreferences{}, utils.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("unknown timezone %q", timezone))
+	}
+	if (prefsDto.QuietHoursStart == nil) != (prefsDto.QuietHoursEnd == nil) {
+		return NotificationPreferences{}, utils.NewHTTPError(http.StatusBadRequest, "quiet hours need both a start and an end")
+	}
+	if prefsDto.QuietHoursStart != nil && *prefsDto.QuietHoursStart == *prefsDto.QuietHoursEnd {
+		return NotificationPreferences{}, utils.NewHTTPError(http.StatusBadRequest, "quiet hours must start and end at different hours")
+	}
+
+	err = withTx(ctx, s.queries, func(qtx *repositories.Queries) error {
+		_, err := qtx.UpsertNotificationSettings(ctx, repositories.UpsertNotificationSettingsParams{
+			UserID:          userUUID,
+			Timezone:        timezone,
+			QuietHoursStart: makeInt2(prefsDto.QuietHoursStart),
+			QuietHoursEnd:   makeInt2(prefsDto.QuietHoursEnd),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update notification settings: %w", err)
+		}
+		for _, channels := range prefsDto.Categories {
+			_, err := qtx.UpsertNotificationPreference(ctx, repositories.UpsertNotificationPreferenceParams{
+				UserID:    userUUID,
+				Category:  channels.Category,
+				InApp:     *channels.InApp,
+				Email:     *channels.Email,
+				Websocket: *channels.Websocket,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to update notification preference: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("NotificationService - Failed to update preferences: %v", err)
+		return NotificationPreferences{}, err
+	}
+	return getNotificationPreferences(ctx, s.queries, userUUID)
+}
+
+func getNotificationPreferences(ctx context.Context, queries *repositories.Queries, userID pgtype.UUID) (NotificationPreferences, error) {
+	prefs := NotificationPreferences{Timezone: "UTC"}
+	settings, err := queries.GetNotificationSettings(ctx, userID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return NotificationPreferences{}, fmt.Errorf("failed to get notification settings: %w", err)
+	}
+	if err == nil {
+		prefs.Timezone = settings.Timezone
+		if settings.QuietHoursStart.Valid && settings.QuietHoursEnd.Valid {
+			prefs.QuietHoursStart = &settings.QuietHoursStart.Int16
+			prefs.QuietHoursEnd = &settings.QuietHoursEnd.Int16
+		}
+	}
+
+	stored, err := queries.ListNotificationPreferences(ctx, userID)
+	if err != nil {
+		return NotificationPreferences{}, fmt.Errorf("failed to list notification preferences: %w", err)
+	}
+	byCategory := make(map[string]repositories.NotificationPreference, len(stored))
+	for _, pref := range stored {
+		byCategory[pref.Category] = pref
+	}
+	for _, category := range notificationCategoryOrder {
+		channels := NotificationChannels{Category: category, InApp: true, Email: true, Websocket: true}
+		if pref, ok := byCategory[category]; ok {
+			channels.InApp, channels.Email, channels.Websocket = pref.InApp, pref.Email, pref.Websocket
+		}
+		prefs.Categories = append(prefs.Categories, channels)
+	}
+	return prefs, nil
+}
+
+// notificationChannels are where a notification goes. emailAt, when set,
+// holds the email back until then.
+type notificationChannels struct {
+	inApp     bool
+	email     bool
+	websocket bool
+	emailAt   time.Time
+}
+
+// notificationChannelsFor decides where a notification goes: the channels the
+// user enabled for its category. During their quiet hours the email is held
+// back until the quiet hours end and the live websocket push is skipped; the
+// notification still reaches the in-app inbox. Notifications outside any
+// category go everywhere.
+func notificationChannelsFor(ctx context.Context, queries *repositories.Queries, userID pgtype.UUID, eventType string, now time.Time) notificationChannels {
+	all := notificationChannels{inApp: true, email: true, websocket: true}
+	category, ok := notificationCategories[eventType]
+	if !ok {
+		return all
+	}
+	settings, err := queries.GetNotificationDispatchSettings(ctx, repositories.GetNotificationDispatchSettingsParams{
+		UserID:   userID,
+		Category: category,
+	})
+	if err != nil {
+		log.Printf("NotificationService - Failed to get notification preferences of %s: %v", userID.String(), err)
+		return all
+	}
+
+	channels := notificationChannels{inApp: settings.InApp, email: settings.Email, websocket: settings.Websocket}
+	if end, ok := quietHoursEnd(now, settings.Timezone, settings.QuietHoursStart, settings.QuietHoursEnd); ok {
+		channels.emailAt = end
+		channels.websocket = false
+	}
+	return channels
+}
+
+// quietHoursEnd reports whether now falls between the start and end hour in
+// the timezone, where a start after the end spans midnight, and if so when
+// the quiet hours end.
+func quietHoursEnd(now time.Time, timezone string, start, end pgtype.Int2) (time.Time, bool) {
+	if !start.Valid || !end.Valid {
+		return time.Time{}, false
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	local := now.In(loc)
+	hour := int16(local.Hour())
+	quiet := hour >= start.Int16 || hour < end.Int16
+	if start.Int16 < end.Int16 {
+		quiet = hour >= start.Int16 && hour < end.Int16
+	}
+	if !quiet {
+		return time.Time{}, false
+	}
+	until := time.Date(local.Year(), local.Month(), local.Day(), int(end.Int16), 0, 0, 0, loc)
+	if !until.After(local) {
+		until = until.AddDate(0, 0, 1)
+	}
+	return until, true
+}
+
+func makeInt2(v *int16) pgtype.Int2 {
+	if v == nil {
+		return pgtype.Int2{}
+	}
+	return pgtype.Int2{Int16: *v, Valid: true}
+}
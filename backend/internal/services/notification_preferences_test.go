@@ -0,0 +1,51 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestQuietHoursEnd(t *testing.T) {
+	hour := func(h int16) pgtype.Int2 { return pgtype.Int2{Int16: h, Valid: true} }
+
+	tests := []struct {
+		name       string
+		now        string
+		timezone   string
+		start, end pgtype.Int2
+		want       string
+	}{
+		{"outside quiet hours", "2025-03-10T12:00:00Z", "UTC", hour(22), hour(7), ""},
+		{"before midnight ends the next morning", "2025-03-10T23:30:00Z", "UTC", hour(22), hour(7), "2025-03-11T07:00:00Z"},
+		{"after midnight ends the same morning", "2025-03-10T02:15:00Z", "UTC", hour(22), hour(7), "2025-03-10T07:00:00Z"},
+		{"daytime quiet hours", "2025-03-10T13:00:00Z", "UTC", hour(12), hour(14), "2025-03-10T14:00:00Z"},
+		{"end hour itself is not quiet", "2025-03-10T07:00:00Z", "UTC", hour(22), hour(7), ""},
+		{"user's timezone", "2025-03-10T04:00:00Z", "America/New_York", hour(22), hour(7), "2025-03-10T07:00:00-04:00"},
+		{"no quiet hours", "2025-03-10T23:30:00Z", "UTC", pgtype.Int2{}, pgtype.Int2{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now, err := time.Parse(time.RFC3339, tt.now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, ok := quietHoursEnd(now, tt.timezone, tt.start, tt.end)
+			if tt.want == "" {
+				if ok {
+					t.Errorf("quietHoursEnd = %s, want outside quiet hours", got)
+				}
+				return
+			}
+			want, err := time.Parse(time.RFC3339, tt.want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ok || !got.Equal(want) {
+				t.Errorf("quietHoursEnd = %s, %v, want %s", got, ok, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"backend/internal/repositories"
+	"backend/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type NotificationService struct {
+	queries *repositories.Queries
+}
+
+func NewNotificationService() *NotificationService {
+	return &NotificationService{queries: repositories.GetDB()}
+}
+
+// ListNotifications lists a page of the user's notifications, newest first,
+// along with how many are unread.
+func (s *NotificationService) ListNotifications(ctx context.Context, userID string, unreadOnly bool, page PageRequest) ([]repositories.Notification, int64, utils.Page, error) {
+	userUUID, err := parseUUID(userID)
+	if err != nil {
+		return nil, 0, utils.Page{}, fmt.Errorf("invalid user ID: %w", err)
+	}
+
+	cursorCreatedAt, cursorID, err := cursorParams(page.Cursor)
+	if err != nil {
+		return nil, 0, utils.Page{}, err
+	}
+	notifications, err := s.queries.ListNotificationsByUserID(ctx, repositories.ListNotificationsByUserIDParams{
+		UserID:          userUUID,
+		UnreadOnly:      unreadOnly,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		Size:            page.Size + 1,
+	})
+	if err != nil {
+		return nil, 0, utils.Page{}, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	notifications, pageInfo := pageOf(notifications, page.Size, func(n repositories.Notification) utils.Cursor {
+		return rowCursor(n.CreatedAt, n.ID)
+	})
+
+	unread, err := s.queries.CountUnreadNotifications(ctx, userUUID)
+	if err != nil {
+		return nil, 0, utils.Page{}, fmt.Errorf("failed to count unread notifications: %w", err)
+	}
+	if page.IncludeTotal {
+		total, err := s.queries.CountNotificationsByUserID(ctx, repositories.CountNotificationsByUserIDParams{
+			UserID:     userUUID,
+			UnreadOnly: unreadOnly,
+		})
+		if err != nil {
+			return nil, 0, utils.Page{}, fmt.Errorf("failed to count notifications: %w", err)
+		}
+		pageInfo.Total = &total
+	}
+	return notifications, unread, pageInfo, nil
+}
+
+// MarkNotificationRead marks one of the user's notifications as read. Marking
+// it again keeps the original read time.
+func (s *NotificationService) MarkNotificationRead(ctx context.Context, userID string, notificationID pgtype.UUID) (repositories.Notification, error) {
+	userUUID, err := parseUUID(userID)
+	if err != nil {
+		return repositories.Notification{}, fmt.Errorf("invalid user ID: %w", err)
+	}
+	notification, err := s.queries.MarkNotificationRead(ctx, repositories.MarkNotificationReadParams{
+		ID:     notificationID,
+		UserID: userUUID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repositories.Notification{}, utils.NewHTTPError(http.StatusNotFound, "notification not found")
+	}
+	if err != nil {
+		return repositories.Notification{}, fmt.Errorf("failed to mark notification as read: %w", err)
+	}
+	return notification, nil
+}
+
+// MarkAllNotificationsRead marks every unread notification of the user as
+// read and returns how many there were.
+func (s *NotificationService) MarkAllNotificationsRead(ctx context.Context, userID string) (int64, error) {
+	userUUID, err := parseUUID(userID)
+	if err != nil {
+		return 0, fmt.Errorf("invalid user ID: %w", err)
+	}
+	count, err := s.queries.MarkAllNotificationsRead(ctx, userUUID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark notifications as read: %w", err)
+	}
+	return count, nil
+}
+
+// inboxNotifier dispatches user notifications on the channels the user
+// wants: it stores them in the inbox, so users who were offline still find
+// them there, pushes them over the realtime notifier and emails them. The
+// pushed event carries the stored notification, id and read state included.
+type inboxNotifier struct {
+	Notifier
+	queries *repositories.Queries
+	emails  EmailQueue
+}
+
+// NewInboxNotifier wraps a realtime notifier so that user notifications are
+// also persisted and emailed, according to the users' preferences.
+func NewInboxNotifier(n Notifier) Notifier {
+	return &inboxNotifier{Notifier: n, queries: repositories.GetDB(), emails: emailQueue}
+}
+
+func (n *inboxNotifier) NotifyUser(userID, eventType string, data interface{}) {
+	ctx := context.Background()
+	userUUID, err := parseUUID(userID)
+	if err != nil || !userUUID.Valid {
+		log.Printf("NotificationService - Invalid recipient %q of %s notification", userID, eventType)
+		return
+	}
+	payload, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("NotificationService - Failed to encode %s notification: %v", eventType, err)
+		return
+	}
+
+	channels := notificationChannelsFor(ctx, n.queries, userUUID, eventType, time.Now())
+	live := data
+	if channels.inApp {
+		notification, err := n.queries.CreateNotification(ctx, repositories.CreateNotificationParams{
+			UserID: userUUID,
+			Type:   eventType,
+			Data:   payload,
+		})
+		if err != nil {
+			log.Printf("NotificationService - Failed to store %s notification for %s: %v", eventType, userID, err)
+		} else {
+			live = notification
+		}
+	}
+	if channels.websocket {
+		n.Notifier.NotifyUser(userID, eventType, live)
+	}
+	if channels.email {
+		if err := emailNotification(ctx, n.queries, n.emails, userUUID, payload, channels.emailAt); err != nil {
+			log.Printf("NotificationService - Failed to email %s notification to %s: %v", eventType, userID, err)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"backend/utils"
+	"net/http"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// PageRequest is the keyset pagination requested by a client.
+type PageRequest struct {
+	Cursor       utils.Cursor
+	Size         int32
+	IncludeTotal bool
+}
+
+// cursorParams converts the cursor to query parameters; both are NULL on the
+// first page.
+func cursorParams(cursor utils.Cursor) (pgtype.Timestamptz, pgtype.UUID, error) {
+	if cursor.IsZero() {
+		return pgtype.Timestamptz{}, pgtype.UUID{}, nil
+	}
+	id, err := parseUUID(cursor.ID)
+	if err != nil {
+		return pgtype.Timestamptz{}, pgtype.UUID{}, utils.NewHTTPError(http.StatusBadRequest, "invalid cursor")
+	}
+	return pgtype.Timestamptz{Time: cursor.CreatedAt, Valid: true}, id, nil
+}
+
+// checkCursorSort refuses a cursor taken from a list in another order, as its
+// position means nothing in this one.
+func checkCursorSort(cursor utils.Cursor, sort, order string) error {
+	if !cursor.IsZero() && (cursor.Sort != sort || cursor.Order != order) {
+		return utils.NewHTTPError(http.StatusBadRequest, "the cursor is from a list with another sort or order; start again from the first page")
+	}
+	return nil
+}
+
+// pageOf trims the extra row fetched to detect a following page and builds the
+// next cursor from the last row kept. Queries fetch size+1 rows.
+func pageOf[T any](rows []T, size int32, cursorOf func(T) utils.Cursor) ([]T, utils.Page) {
+	if int32(len(rows)) <= size {
+		return rows, utils.Page{}
+	}
+	rows = rows[:size]
+	return rows, utils.Page{
+		NextCursor: utils.EncodeCursor(cursorOf(rows[len(rows)-1])),
+		HasMore:    true,
+	}
+}
+
+func rowCursor(createdAt pgtype.Timestamptz, id pgtype.UUID) utils.Cursor {
+	return utils.Cursor{CreatedAt: createdAt.Time, ID: id.String()}
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"backend/internal/repositories"
+	"backend/jobs"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Event types of the ticket room protocol. Clients send comment, typing and
+// read_receipt; the server sends the rest.
+const (
+	RealtimeComment         = "comment"
+	RealtimeTyping          = "typing"
+	RealtimePresence        = "presence"
+	RealtimeTicketUpdated   = "ticket.updated"
+	RealtimeTicketAssigned  = "ticket.assigned"
+	RealtimeAttachmentAdded = "attachment.added"
+	RealtimeReadReceipt     = "read_receipt"
+)
+
+// Notification types of a user's personal stream.
+const (
+	NotificationTicketAssigned      = "ticket.assigned"
+	NotificationCustomerReplied     = "ticket.customer_replied"
+	NotificationTicketReplied       = "ticket.replied"
+	NotificationTicketStatusChanged = "ticket.status_changed"
+	NotificationSLAAtRisk           = jobs.EventSLAAtRisk
+	NotificationSLABreached         = jobs.EventSLABreached
+	NotificationTicketEscalated     = "ticket.escalated"
+	NotificationInvoiceCreated      = "invoice.created"
+	NotificationInvoicePaid         = "invoice.paid"
+	NotificationInvoiceRefunded     = "invoice.refunded"
+	NotificationInvoiceCredited     = "invoice.credited"
+)
+
+// Comment visibilities. Internal comments are notes between the tenant's
+// staff that the ticket's customer never sees.
+const (
+	CommentPublic   = "PUBLIC"
+	CommentInternal = "INTERNAL"
+)
+
+// CommentEvent is the data of a comment event: a saved comment, whoever
+// wrote it and however it was posted.
+type CommentEvent struct {
+	ID         string
+	AuthorID   string
+	Username   string
+	Role       string
+	Content    string
+	Visibility string
+	CreatedAt  time.Time
+}
+
+// StaffOnlyEvent wraps the data of a ticket event that must not reach the
+// ticket's customer, such as a file attached to an internal note.
+type StaffOnlyEvent struct {
+	Data interface{}
+}
+
+// TicketNotifier pushes events to clients watching a ticket.
+type TicketNotifier interface {
+	NotifyTicket(ticketID, eventType string, data interface{})
+}
+
+// UserNotifier pushes notifications to a user's personal stream.
+type UserNotifier interface {
+	NotifyUser(userID, eventType string, data interface{})
+}
+
+type Notifier interface {
+	TicketNotifier
+	UserNotifier
+}
+
+type noopNotifier struct{}
+
+func (noopNotifier) NotifyTicket(ticketID, eventType string, data interface{}) {}
+
+func (noopNotifier) NotifyUser(userID, eventType string, data interface{}) {}
+
+// notifier is used by services built with their default constructors. It
+// drops events until SetNotifier is called at startup.
+var notifier Notifier = noopNotifier{}
+
+func SetNotifier(n Notifier) {
+	notifier = n
+}
+
+// PresenceUser is someone currently viewing a ticket.
+type PresenceUser struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+// PresenceTracker reports who is viewing a ticket across all instances.
+type PresenceTracker interface {
+	TicketPresence(ctx context.Context, ticketID string) ([]PresenceUser, error)
+}
+
+type noopPresenceTracker struct{}
+
+func (noopPresenceTracker) TicketPresence(ctx context.Context, ticketID string) ([]PresenceUser, error) {
+	return []PresenceUser{}, nil
+}
+
+var presenceTracker PresenceTracker = noopPresenceTracker{}
+
+func SetPresenceTracker(tracker PresenceTracker) {
+	presenceTracker = tracker
+}
+
+// TicketUpdatedEvent carries the ticket after a change and the fields that
+// changed.
+type TicketUpdatedEvent struct {
+	Ticket  repositories.TicketTicket `json:"ticket"`
+	Changes []ticketChange            `json:"changes"`
+}
+
+type TicketAssignedEvent struct {
+	TicketID           pgtype.UUID `json:"ticket_id"`
+	AssignedTo         pgtype.UUID `json:"assigned_to"`
+	PreviousAssignedTo pgtype.UUID `json:"previous_assigned_to"`
+}
+
+// TicketNotification is a notification about one of the user's tickets.
+type TicketNotification struct {
+	TicketID string `json:"ticket_id"`
+	Title    string `json:"title"`
+	Message  string `json:"message"`
+}
+
+// notifyTicketChange pushes ticket.updated, and ticket.assigned when the
+// assignee changed, to the ticket room, tells a new assignee and tells the
+// customer when the status changed.
+func notifyTicketChange(notifier Notifier, before, after repositories.TicketTicket) {
+	changes := diffTickets(before, after)
+	if len(changes) == 0 {
+		return
+	}
+	ticketID := after.ID.String()
+	notifier.NotifyTicket(ticketID, RealtimeTicketUpdated, TicketUpdatedEvent{Ticket: after, Changes: changes})
+	if before.AssignedTo != after.AssignedTo {
+		notifier.NotifyTicket(ticketID, RealtimeTicketAssigned, TicketAssignedEvent{
+			TicketID:           after.ID,
+			AssignedTo:         after.AssignedTo,
+			PreviousAssignedTo: before.AssignedTo,
+		})
+		notifyAssignee(notifier, after.ID, after.AssignedTo, after.Title)
+	}
+	if before.Status != after.Status && after.CustomerID.Valid {
+		notifier.NotifyUser(after.CustomerID.String(), NotificationTicketStatusChanged, TicketNotification{
+			TicketID: ticketID,
+			Title:    after.Title,
+			Message:  fmt.Sprintf("The ticket status changed to %s", after.Status),
+		})
+	}
+}
+
+func notifyAssignee(notifier Notifier, ticketID, assignedTo pgtype.UUID, title string) {
+	if !assignedTo.Valid {
+		return
+	}
+	notifier.NotifyUser(assignedTo.String(), NotificationTicketAssigned, TicketNotification{
+		TicketID: ticketID.String(),
+		Title:    title,
+		Message:  "A ticket was assigned to you",
+	})
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"backend/internal/repositories"
+	"backend/jobs"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// businessHours is the tenant's working week used for business-hours SLAs.
+type businessHours struct {
+	location *time.Location
+	start    int
+	end      int
+	days     []int16
+}
+
+func defaultBusinessHours() businessHours {
+	return businessHours{location: time.UTC, start: 9, end: 17, days: []int16{1, 2, 3, 4, 5}}
+}
+
+func businessHoursForTenant(ctx context.Context, queries *repositories.Queries, tenantID pgtype.UUID) (businessHours, error) {
+	settings, err := queries.GetTenantSettings(ctx, tenantID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return defaultBusinessHours(), nil
+	}
+	if err != nil {
+		return businessHours{}, fmt.Errorf("failed to get tenant settings: %w", err)
+	}
+	location, err := time.LoadLocation(settings.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+	return businessHours{
+		location: location,
+		start:    int(settings.BusinessHoursStart),
+		end:      int(settings.BusinessHoursEnd),
+		days:     settings.BusinessDays,
+	}, nil
+}
+
+// addMinutes returns the time the given number of working minutes after from.
+// Weekdays are numbered as in time.Weekday (0 is Sunday).
+func (b businessHours) addMinutes(from time.Time, minutes int) time.Time {
+	if len(b.days) == 0 || b.end <= b.start {
+		return from.Add(time.Duration(minutes) * time.Minute)
+	}
+
+	remaining := time.Duration(minutes) * time.Minute
+	current := from.In(b.location)
+	for remaining > 0 {
+		day := time.Date(current.Year(), current.Month(), current.Day(), 0, 0, 0, 0, b.location)
+		open := day.Add(time.Duration(b.start) * time.Hour)
+		closing := day.Add(time.Duration(b.end) * time.Hour)
+
+		if !slices.Contains(b.days, int16(current.Weekday())) || !current.Before(closing) {
+			current = day.AddDate(0, 0, 1)
+			continue
+		}
+		if current.Before(open) {
+			current = open
+		}
+		available := closing.Sub(current)
+		if remaining <= available {
+			return current.Add(remaining).UTC()
+		}
+		remaining -= available
+		current = day.AddDate(0, 0, 1)
+	}
+	return current.UTC()
+}
+
+// applySLAPolicy sets the first response and resolution deadlines of a ticket
+// from the tenant's policy for its priority. Tickets whose priority has no
+// policy are left without deadlines.
+func applySLAPolicy(ctx context.Context, queries *repositories.Queries, ticketID, tenantID pgtype.UUID, priority string, from time.Time) error {
+	policy, err := queries.GetSLAPolicy(ctx, repositories.GetSLAPolicyParams{
+		TenantID: tenantID,
+		Priority: repositories.TicketTicketPriority(priority),
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get sla policy: %w", err)
+	}
+
+	hours := businessHours{}
+	if policy.BusinessHoursOnly {
+		hours, err = businessHoursForTenant(ctx, queries, tenantID)
+		if err != nil {
+			return err
+		}
+	}
+
+	params := repositories.SetTicketSLADeadlinesParams{
+		ID:                 ticketID,
+		FirstResponseDueAt: pgtype.Timestamptz{Time: hours.addMinutes(from, int(policy.FirstResponseMinutes)), Valid: true},
+		ResolutionDueAt:    pgtype.Timestamptz{Time: hours.addMinutes(from, int(policy.ResolutionMinutes)), Valid: true},
+		SlaStatus:          makeText(jobs.SLAOnTrack),
+	}
+	if err := queries.SetTicketSLADeadlines(ctx, params); err != nil {
+		return fmt.Errorf("failed to set sla deadlines: %w", err)
+	}
+	return nil
+}
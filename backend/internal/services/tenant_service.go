@@ -3,11 +3,16 @@ package services
 import (
 	"backend/internal/dto"
 	"backend/internal/repositories"
+	"backend/utils"
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
@@ -105,19 +110,277 @@ func (s *TenantService) GetUserTenants(ctx context.Context, userID string) ([]re
 	return tenants, nil
 }
 
-func (s *TenantService) GetAllTenants(ctx context.Context, page int32, size int32) ([]repositories.TenantTenant, error) {
-	if page <= 0 {
-		page = 1
+func (s *TenantService) GetAllTenants(ctx context.Context, page PageRequest) ([]repositories.TenantTenant, utils.Page, error) {
+	cursorCreatedAt, cursorID, err := cursorParams(page.Cursor)
+	if err != nil {
+		return nil, utils.Page{}, err
 	}
-	offset := (page - 1) * size
 	params := repositories.ListTenantsParams{
-		Limit:  size,
-		Offset: offset,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		Size:            page.Size + 1,
 	}
 	tenants, err := s.queries.ListTenants(ctx, params)
 	if err != nil {
 		log.Printf("TenantService - Failed to get all tenants: %v", err)
-		return nil, fmt.Errorf("failed to get all tenants: %w", err)
+		return nil, utils.Page{}, fmt.Errorf("failed to get all tenants: %w", err)
 	}
-	return tenants, nil
+	tenants, pageInfo := pageOf(tenants, page.Size, func(t repositories.TenantTenant) utils.Cursor {
+		return rowCursor(t.CreatedAt, t.ID)
+	})
+
+	if page.IncludeTotal {
+		total, err := s.queries.CountTenants(ctx)
+		if err != nil {
+			return nil, utils.Page{}, fmt.Errorf("failed to count tenants: %w", err)
+		}
+		pageInfo.Total = &total
+	}
+	return tenants, pageInfo, nil
+}
+
+// SetAssignmentStrategy selects how new tickets of the tenant are assigned to
+// technicians. Only members of the tenant may change it.
+func (s *TenantService) SetAssignmentStrategy(ctx context.Context, userID string, strategyDto dto.SetAssignmentStrategyDto) (repositories.TenantTenantSetting, error) {
+	parsedTenantID, err := requireTenantMember(ctx, s.queries, userID, strategyDto.TenantID)
+	if err != nil {
+		return repositories.TenantTenantSetting{}, err
+	}
+
+	settings, err := s.queries.UpsertTenantAssignmentStrategy(ctx, repositories.UpsertTenantAssignmentStrategyParams{
+		TenantID:           parsedTenantID,
+		AssignmentStrategy: strategyDto.Strategy,
+	})
+	if err != nil {
+		log.Printf("TenantService - Failed to set assignment strategy: %v", err)
+		return repositories.TenantTenantSetting{}, fmt.Errorf("failed to set assignment strategy: %w", err)
+	}
+	return settings, nil
+}
+
+// SetBusinessHours sets the working week business-hours SLA policies of the
+// tenant are measured against.
+func (s *TenantService) SetBusinessHours(ctx context.Context, userID string, hoursDto dto.SetBusinessHoursDto) (repositories.TenantTenantSetting, error) {
+	parsedTenantID, err := requireTenantMember(ctx, s.queries, userID, hoursDto.TenantID)
+	if err != nil {
+		return repositories.TenantTenantSetting{}, err
+	}
+	if _, err := time.LoadLocation(hoursDto.Timezone); err != nil {
+		return repositories.TenantTenantSetting{}, utils.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("unknown timezone %q", hoursDto.Timezone))
+	}
+	if hoursDto.End <= hoursDto.Start {
+		return repositories.TenantTenantSetting{}, utils.NewHTTPError(http.StatusBadRequest, "business hours must end after they start")
+	}
+
+	settings, err := s.queries.UpsertTenantBusinessHours(ctx, repositories.UpsertTenantBusinessHoursParams{
+		TenantID:           parsedTenantID,
+		Timezone:           hoursDto.Timezone,
+		BusinessHoursStart: hoursDto.Start,
+		BusinessHoursEnd:   hoursDto.End,
+		BusinessDays:       hoursDto.Days,
+	})
+	if err != nil {
+		log.Printf("TenantService - Failed to set business hours: %v", err)
+		return repositories.TenantTenantSetting{}, fmt.Errorf("failed to set business hours: %w", err)
+	}
+	return settings, nil
+}
+
+// SetEmailSender sets the sender name and address of the tenant's emails.
+func (s *TenantService) SetEmailSender(ctx context.Context, userID string, senderDto dto.SetEmailSenderDto) (repositories.TenantTenantSetting, error) {
+	parsedTenantID, err := requireTenantMember(ctx, s.queries, userID, senderDto.TenantID)
+	if err != nil {
+		return repositories.TenantTenantSetting{}, err
+	}
+
+	settings, err := s.queries.UpsertTenantEmailSender(ctx, repositories.UpsertTenantEmailSenderParams{
+		TenantID:         parsedTenantID,
+		EmailFromName:    makeText(senderDto.FromName),
+		EmailFromAddress: makeText(senderDto.FromAddress),
+	})
+	if err != nil {
+		log.Printf("TenantService - Failed to set email sender: %v", err)
+		return repositories.TenantTenantSetting{}, fmt.Errorf("failed to set email sender: %w", err)
+	}
+	return settings, nil
+}
+
+// SetTaxRate sets the tax rate charged on the tenant's invoice lines that do
+// not carry their own. Invoices already issued keep the rate they were
+// issued with.
+func (s *TenantService) SetTaxRate(ctx context.Context, userID string, taxDto dto.SetTaxRateDto) (repositories.TenantTenantSetting, error) {
+	parsedTenantID, err := requireTenantMember(ctx, s.queries, userID, taxDto.TenantID)
+	if err != nil {
+		return repositories.TenantTenantSetting{}, err
+	}
+	if taxDto.TaxRate.Places() > taxRatePlaces {
+		return repositories.TenantTenantSetting{}, utils.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("tax_rate takes at most %d decimal places", taxRatePlaces))
+	}
+
+	settings, err := s.queries.UpsertTenantTaxRate(ctx, repositories.UpsertTenantTaxRateParams{
+		TenantID: parsedTenantID,
+		TaxRate:  taxDto.TaxRate,
+	})
+	if err != nil {
+		log.Printf("TenantService - Failed to set tax rate: %v", err)
+		return repositories.TenantTenantSetting{}, fmt.Errorf("failed to set tax rate: %w", err)
+	}
+	return settings, nil
+}
+
+// ListEmailDeliveries lists a page of the tenant's email delivery log, newest
+// first.
+func (s *TenantService) ListEmailDeliveries(ctx context.Context, userID, tenantID string, page PageRequest) ([]repositories.EmailDelivery, utils.Page, error) {
+	parsedTenantID, err := requireTenantMember(ctx, s.queries, userID, tenantID)
+	if err != nil {
+		return nil, utils.Page{}, err
+	}
+
+	cursorCreatedAt, cursorID, err := cursorParams(page.Cursor)
+	if err != nil {
+		return nil, utils.Page{}, err
+	}
+	deliveries, err := s.queries.ListEmailDeliveriesByTenantID(ctx, repositories.ListEmailDeliveriesByTenantIDParams{
+		TenantID:        parsedTenantID,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		Size:            page.Size + 1,
+	})
+	if err != nil {
+		return nil, utils.Page{}, fmt.Errorf("failed to list email deliveries: %w", err)
+	}
+	deliveries, pageInfo := pageOf(deliveries, page.Size, func(d repositories.EmailDelivery) utils.Cursor {
+		return rowCursor(d.CreatedAt, d.ID)
+	})
+	return deliveries, pageInfo, nil
+}
+
+// SetSLAPolicy sets the first response and resolution targets for tickets of
+// the given priority. Existing tickets keep their deadlines.
+func (s *TenantService) SetSLAPolicy(ctx context.Context, userID string, policyDto dto.SetSLAPolicyDto) (repositories.TicketSlaPolicy, error) {
+	parsedTenantID, err := requireTenantMember(ctx, s.queries, userID, policyDto.TenantID)
+	if err != nil {
+		return repositories.TicketSlaPolicy{}, err
+	}
+	if policyDto.ResolutionMinutes < policyDto.FirstResponseMinutes {
+		return repositories.TicketSlaPolicy{}, utils.NewHTTPError(http.StatusBadRequest, "resolution target cannot be shorter than first response target")
+	}
+
+	policy, err := s.queries.UpsertSLAPolicy(ctx, repositories.UpsertSLAPolicyParams{
+		TenantID:             parsedTenantID,
+		Priority:             repositories.TicketTicketPriority(policyDto.Priority),
+		FirstResponseMinutes: policyDto.FirstResponseMinutes,
+		ResolutionMinutes:    policyDto.ResolutionMinutes,
+		BusinessHoursOnly:    policyDto.BusinessHoursOnly,
+	})
+	if err != nil {
+		log.Printf("TenantService - Failed to set sla policy: %v", err)
+		return repositories.TicketSlaPolicy{}, fmt.Errorf("failed to set sla policy: %w", err)
+	}
+	return policy, nil
+}
+
+func (s *TenantService) ListSLAPolicies(ctx context.Context, userID, tenantID string) ([]repositories.TicketSlaPolicy, error) {
+	parsedTenantID, err := requireTenantMember(ctx, s.queries, userID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	policies, err := s.queries.ListSLAPoliciesByTenantID(ctx, parsedTenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sla policies: %w", err)
+	}
+	return policies, nil
+}
+
+func makePriority(priority string) repositories.NullTicketTicketPriority {
+	if priority == "" {
+		return repositories.NullTicketTicketPriority{}
+	}
+	return repositories.NullTicketTicketPriority{TicketTicketPriority: repositories.TicketTicketPriority(priority), Valid: true}
+}
+
+// CreateEscalationRule adds an escalation rule evaluated by the periodic
+// escalation check.
+func (s *TenantService) CreateEscalationRule(ctx context.Context, userID string, ruleDto dto.CreateEscalationRuleDto) (repositories.TicketEscalationRule, error) {
+	parsedTenantID, err := requireTenantMember(ctx, s.queries, userID, ruleDto.TenantID)
+	if err != nil {
+		return repositories.TicketEscalationRule{}, err
+	}
+	if ruleDto.SetPriority == "" && !ruleDto.ReassignToAdmin && !ruleDto.NotifyAdmin {
+		return repositories.TicketEscalationRule{}, utils.NewHTTPError(http.StatusBadRequest, "escalation rule needs at least one action")
+	}
+
+	rule, err := s.queries.CreateEscalationRule(ctx, repositories.CreateEscalationRuleParams{
+		TenantID:        parsedTenantID,
+		Name:            ruleDto.Name,
+		ConditionType:   ruleDto.ConditionType,
+		MatchPriority:   makePriority(ruleDto.MatchPriority),
+		Threshold:       ruleDto.Threshold,
+		SetPriority:     makePriority(ruleDto.SetPriority),
+		ReassignToAdmin: ruleDto.ReassignToAdmin,
+		NotifyAdmin:     ruleDto.NotifyAdmin,
+	})
+	if err != nil {
+		log.Printf("TenantService - Failed to create escalation rule: %v", err)
+		return repositories.TicketEscalationRule{}, fmt.Errorf("failed to create escalation rule: %w", err)
+	}
+	return rule, nil
+}
+
+func (s *TenantService) ListEscalationRules(ctx context.Context, userID, tenantID string) ([]repositories.TicketEscalationRule, error) {
+	parsedTenantID, err := requireTenantMember(ctx, s.queries, userID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := s.queries.ListEscalationRulesByTenantID(ctx, parsedTenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list escalation rules: %w", err)
+	}
+	return rules, nil
+}
+
+func (s *TenantService) SetEscalationRuleActive(ctx context.Context, userID, tenantID, ruleID string, isActive bool) (repositories.TicketEscalationRule, error) {
+	parsedTenantID, err := requireTenantMember(ctx, s.queries, userID, tenantID)
+	if err != nil {
+		return repositories.TicketEscalationRule{}, err
+	}
+	parsedRuleID, err := parseUUID(ruleID)
+	if err != nil {
+		return repositories.TicketEscalationRule{}, fmt.Errorf("invalid rule ID: %w", err)
+	}
+
+	rule, err := s.queries.SetEscalationRuleActive(ctx, repositories.SetEscalationRuleActiveParams{
+		ID:       parsedRuleID,
+		TenantID: parsedTenantID,
+		IsActive: isActive,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repositories.TicketEscalationRule{}, utils.NewHTTPError(http.StatusNotFound, "escalation rule not found")
+	}
+	if err != nil {
+		return repositories.TicketEscalationRule{}, fmt.Errorf("failed to update escalation rule: %w", err)
+	}
+	return rule, nil
+}
+
+func (s *TenantService) DeleteEscalationRule(ctx context.Context, userID, tenantID, ruleID string) error {
+	parsedTenantID, err := requireTenantMember(ctx, s.queries, userID, tenantID)
+	if err != nil {
+		return err
+	}
+	parsedRuleID, err := parseUUID(ruleID)
+	if err != nil {
+		return fmt.Errorf("invalid rule ID: %w", err)
+	}
+
+	err = s.queries.DeleteEscalationRule(ctx, repositories.DeleteEscalationRuleParams{
+		ID:       parsedRuleID,
+		TenantID: parsedTenantID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete escalation rule: %w", err)
+	}
+	return nil
 }
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"backend/internal/repositories"
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	TicketEventCreated       = "CREATED"
+	TicketEventUpdated       = "UPDATED"
+	TicketEventAssigned      = "ASSIGNED"
+	TicketEventStatusChanged = "STATUS_CHANGED"
+	TicketEventDeleted       = "DELETED"
+	TicketEventCommented     = "COMMENTED"
+	TicketEventEscalated     = "ESCALATED"
+)
+
+// ticketChange is a single field change recorded in the ticket history.
+type ticketChange struct {
+	EventType string `json:"event_type"`
+	Field     string `json:"field"`
+	OldValue  string `json:"old_value"`
+	NewValue  string `json:"new_value"`
+}
+
+// diffTickets lists the field changes between two versions of a ticket.
+func diffTickets(before, after repositories.TicketTicket) []ticketChange {
+	changes := []ticketChange{}
+	if before.Status != after.Status {
+		changes = append(changes, ticketChange{TicketEventStatusChanged, "status", before.Status, after.Status})
+	}
+	if before.AssignedTo != after.AssignedTo {
+		changes = append(changes, ticketChange{TicketEventAssigned, "assigned_to", before.AssignedTo.String(), after.AssignedTo.String()})
+	}
+	if before.Title != after.Title {
+		changes = append(changes, ticketChange{TicketEventUpdated, "title", before.Title, after.Title})
+	}
+	if before.Description != after.Description {
+		changes = append(changes, ticketChange{TicketEventUpdated, "description", before.Description.String, after.Description.String})
+	}
+	if before.Priority != after.Priority {
+		changes = append(changes, ticketChange{TicketEventUpdated, "priority", before.Priority, after.Priority})
+	}
+	return changes
+}
+
+// recordTicketEvents writes the changes to the ticket history on behalf of the
+// actor. An actor without an id (automated changes) is recorded as System.
+func recordTicketEvents(ctx context.Context, queries *repositories.Queries, ticketID, tenantID pgtype.UUID, actor Actor, changes ...ticketChange) error {
+	actorUUID, err := parseUUID(actor.ID)
+	if err != nil {
+		return fmt.Errorf("invalid actor id: %w", err)
+	}
+	role := actor.Role
+	if role == "" {
+		role = "System"
+	}
+
+	for _, change := range changes {
+		params := repositories.CreateTicketEventParams{
+			TicketID:  ticketID,
+			TenantID:  tenantID,
+			ActorID:   actorUUID,
+			ActorRole: role,
+			EventType: change.EventType,
+			Field:     makeText(change.Field),
+			OldValue:  makeText(change.OldValue),
+			NewValue:  makeText(change.NewValue),
+		}
+		if err := queries.CreateTicketEvent(ctx, params); err != nil {
+			return fmt.Errorf("failed to record ticket event: %w", err)
+		}
+	}
+	return nil
+}
@@ -3,20 +3,27 @@ package services
 import (
 	"backend/internal/dto"
 	"backend/internal/repositories"
+	"backend/internal/storage"
+	"backend/utils"
 	"context"
+	"errors"
 	"fmt"
-	"math/rand"
+	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type TicketService struct {
-	queries *repositories.Queries
+	queries  *repositories.Queries
+	notifier Notifier
+	presence PresenceTracker
 }
 
 func NewTicketService() *TicketService {
-	return &TicketService{queries: repositories.GetDB()}
+	return &TicketService{queries: repositories.GetDB(), notifier: notifier, presence: presenceTracker}
 }
 
 func makeText(s string) pgtype.Text {
@@ -26,7 +33,21 @@ func makeText(s string) pgtype.Text {
 	return pgtype.Text{String: s, Valid: true}
 }
 
-func (s *TicketService) CreateTicket(ctx context.Context, dto dto.CreateTicketDto) (pgtype.UUID, error) {
+func makeTimestamptz(t time.Time) pgtype.Timestamptz {
+	if t.IsZero() {
+		return pgtype.Timestamptz{}
+	}
+	return pgtype.Timestamptz{Time: t, Valid: true}
+}
+
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+func (s *TicketService) CreateTicket(ctx context.Context, actor Actor, dto dto.CreateTicketDto) (pgtype.UUID, error) {
 	tenantUUID, err := parseUUID(dto.TenantID)
 	if err != nil {
 		return pgtype.UUID{}, fmt.Errorf("invalid tenant_id: %w", err)
@@ -37,23 +58,28 @@ func (s *TicketService) CreateTicket(ctx context.Context, dto dto.CreateTicketDt
 		return pgtype.UUID{}, fmt.Errorf("invalid customer_id: %w", err)
 	}
 
-	if dto.AssignedTo == "" {
-		technicianIDs, err := s.queries.GetTechnicianIDsFromTenantID(ctx, tenantUUID)
-		if err != nil {
-			return pgtype.UUID{}, fmt.Errorf("failed to get technicians for tenant: %w", err)
-		}
-		if len(technicianIDs) > 0 {
-			rand.Seed(time.Now().UnixNano())
-			randomIndex := rand.Intn(len(technicianIDs))
-			dto.AssignedTo = technicianIDs[randomIndex].String()
-		}
-	}
-
 	assignedToUUID, err := parseUUID(dto.AssignedTo)
 	if err != nil {
 		return pgtype.UUID{}, fmt.Errorf("invalid assigned_to: %w", err)
 	}
 
+	assignment := Assignment{
+		TechnicianID: assignedToUUID,
+		Strategy:     AssignmentManual,
+		Reason:       "assigned_to given on creation",
+	}
+	if !assignedToUUID.Valid {
+		strategy, err := assignmentStrategyForTenant(ctx, s.queries, tenantUUID)
+		if err != nil {
+			return pgtype.UUID{}, err
+		}
+		assignment, err = strategy.Assign(ctx, AssignmentRequest{TenantID: tenantUUID, Category: dto.Category})
+		if err != nil {
+			return pgtype.UUID{}, fmt.Errorf("failed to assign technician: %w", err)
+		}
+		assignedToUUID = assignment.TechnicianID
+	}
+
 	params := repositories.CreateTicketParams{
 		TenantID:    tenantUUID,
 		CustomerID:  customerUUID,
@@ -62,142 +88,507 @@ func (s *TicketService) CreateTicket(ctx context.Context, dto dto.CreateTicketDt
 		Status:      "OPEN",
 		AssignedTo:  assignedToUUID,
 		Priority:    dto.Priority,
+		Category:    makeText(dto.Category),
 	}
 
-	ticket, err := s.queries.CreateTicket(ctx, params)
+	var ticket pgtype.UUID
+	err = withTx(ctx, s.queries, func(qtx *repositories.Queries) error {
+		ticket, err = qtx.CreateTicket(ctx, params)
+		if err != nil {
+			return fmt.Errorf("failed to create ticket: %w", err)
+		}
+
+		if err := recordAssignment(ctx, qtx, ticket, assignment); err != nil {
+			return err
+		}
+		if err := applySLAPolicy(ctx, qtx, ticket, tenantUUID, dto.Priority, time.Now()); err != nil {
+			return err
+		}
+
+		changes := []ticketChange{{EventType: TicketEventCreated, Field: "title", NewValue: dto.Title}}
+		if assignedToUUID.Valid {
+			changes = append(changes, ticketChange{EventType: TicketEventAssigned, Field: "assigned_to", NewValue: assignedToUUID.String()})
+		}
+		return recordTicketEvents(ctx, qtx, ticket, tenantUUID, actor, changes...)
+	})
 	if err != nil {
-		return pgtype.UUID{}, fmt.Errorf("failed to create ticket: %w", err)
+		return pgtype.UUID{}, err
 	}
+	notifyAssignee(s.notifier, ticket, assignedToUUID, dto.Title)
 	return ticket, nil
 }
-func (s *TicketService) UpdateTicket(ctx context.Context, ticketID pgtype.UUID, dto dto.UpdateTicketDto) (repositories.TicketTicket, error) {
+func (s *TicketService) UpdateTicket(ctx context.Context, ticketID pgtype.UUID, actor Actor, dto dto.UpdateTicketDto) (repositories.TicketTicket, error) {
+	current, err := s.getTicketForActor(ctx, ticketID, actor)
+	if err != nil {
+		return repositories.TicketTicket{}, err
+	}
+
 	assignedToUUID, err := parseUUID(dto.AssignedTo)
 	if err != nil {
 		return repositories.TicketTicket{}, fmt.Errorf("invalid assigned_to: %w", err)
 	}
 
+	if dto.Status != "" && dto.Status != current.Status {
+		if err := checkTicketTransition(current.Status, dto.Status, actor.Role); err != nil {
+			return repositories.TicketTicket{}, err
+		}
+	}
+
 	params := repositories.UpdateTicketParams{
 		ID:          ticketID,
 		AssignedTo:  assignedToUUID,
-		Title:       dto.Title,
+		Title:       orDefault(dto.Title, current.Title),
 		Description: makeText(dto.Description),
-		Status:      dto.Status,
-		Priority:    dto.Priority,
+		Priority:    orDefault(dto.Priority, current.Priority),
 	}
 
-	ticket, err := s.queries.UpdateTicket(ctx, params)
+	var ticket repositories.TicketTicket
+	err = withTx(ctx, s.queries, func(qtx *repositories.Queries) error {
+		ticket, err = qtx.UpdateTicket(ctx, params)
+		if err != nil {
+			return fmt.Errorf("failed to update ticket: %w", err)
+		}
+		// Status changes go through the same guarded update as
+		// TransitionTicket, so a concurrent transition is not overwritten.
+		if dto.Status != "" && dto.Status != current.Status {
+			ticket, err = qtx.TransitionTicketStatus(ctx, repositories.TransitionTicketStatusParams{
+				ToStatus:   dto.Status,
+				ID:         ticketID,
+				FromStatus: current.Status,
+			})
+			if errors.Is(err, pgx.ErrNoRows) {
+				return utils.NewHTTPError(http.StatusConflict, "ticket status was changed by another request, reload and try again")
+			}
+			if err != nil {
+				return fmt.Errorf("failed to update ticket status: %w", err)
+			}
+		}
+		if ticket.AssignedTo != current.AssignedTo {
+			assignment := Assignment{
+				TechnicianID: ticket.AssignedTo,
+				Strategy:     AssignmentManual,
+				Reason:       fmt.Sprintf("reassigned by %s %s", actor.Role, actor.Username),
+			}
+			if err := recordAssignment(ctx, qtx, ticket.ID, assignment); err != nil {
+				return err
+			}
+		}
+		if ticket.Priority != current.Priority {
+			if err := applySLAPolicy(ctx, qtx, ticket.ID, ticket.TenantID, ticket.Priority, ticket.CreatedAt.Time); err != nil {
+				return err
+			}
+			if ticket, err = qtx.GetTicketByID(ctx, ticket.ID); err != nil {
+				return fmt.Errorf("failed to get ticket: %w", err)
+			}
+		}
+		return recordTicketEvents(ctx, qtx, ticket.ID, ticket.TenantID, actor, diffTickets(current, ticket)...)
+	})
 	if err != nil {
-		return repositories.TicketTicket{}, fmt.Errorf("failed to update ticket: %w", err)
+		return repositories.TicketTicket{}, err
 	}
+	notifyTicketChange(s.notifier, current, ticket)
 	return ticket, nil
 }
 
-func (s *TicketService) DeleteTicket(ctx context.Context, ticketID pgtype.UUID) error {
-	if err := s.queries.DeleteTicket(ctx, ticketID); err != nil {
-		return fmt.Errorf("failed to delete ticket: %w", err)
+// TransitionTicket moves a ticket to a new status, enforcing the status state
+// machine for the actor's role.
+func (s *TicketService) TransitionTicket(ctx context.Context, ticketID pgtype.UUID, actor Actor, toStatus string) (repositories.TicketTicket, error) {
+	current, err := s.getTicketForActor(ctx, ticketID, actor)
+	if err != nil {
+		return repositories.TicketTicket{}, err
+	}
+
+	if err := checkTicketTransition(current.Status, toStatus, actor.Role); err != nil {
+		return repositories.TicketTicket{}, err
 	}
+
+	var ticket repositories.TicketTicket
+	err = withTx(ctx, s.queries, func(qtx *repositories.Queries) error {
+		ticket, err = qtx.TransitionTicketStatus(ctx, repositories.TransitionTicketStatusParams{
+			ToStatus:   toStatus,
+			ID:         ticketID,
+			FromStatus: current.Status,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return utils.NewHTTPError(http.StatusConflict, "ticket status was changed by another request, reload and try again")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to transition ticket: %w", err)
+		}
+		return recordTicketEvents(ctx, qtx, ticket.ID, ticket.TenantID, actor, diffTickets(current, ticket)...)
+	})
+	if err != nil {
+		return repositories.TicketTicket{}, err
+	}
+	notifyTicketChange(s.notifier, current, ticket)
+	return ticket, nil
+}
+
+// ListTicketTransitions returns the statuses the actor may move the ticket to.
+func (s *TicketService) ListTicketTransitions(ctx context.Context, ticketID pgtype.UUID, actor Actor) ([]string, error) {
+	ticket, err := s.getTicketForActor(ctx, ticketID, actor)
+	if err != nil {
+		return nil, err
+	}
+	return AllowedTicketTransitions(ticket.Status, actor.Role), nil
+}
+
+// AuthorizeTicketAccess checks that the actor may see the ticket, with the
+// same rules as the ticket endpoints.
+func (s *TicketService) AuthorizeTicketAccess(ctx context.Context, ticketID string, actor Actor) error {
+	ticketUUID, err := parseUUID(ticketID)
+	if err != nil || !ticketUUID.Valid {
+		return utils.NewHTTPError(http.StatusNotFound, "ticket not found")
+	}
+	_, err = s.getTicketForActor(ctx, ticketUUID, actor)
+	return err
+}
+
+func (s *TicketService) getTicketForActor(ctx context.Context, ticketID pgtype.UUID, actor Actor) (repositories.TicketTicket, error) {
+	return getTicketForActor(ctx, s.queries, ticketID, actor)
+}
+
+func (s *TicketService) DeleteTicket(ctx context.Context, ticketID pgtype.UUID, actor Actor) error {
+	ticket, err := s.getTicketForActor(ctx, ticketID, actor)
+	if err != nil {
+		return err
+	}
+
+	attachmentKeys, err := s.queries.ListAttachmentKeysByTicketID(ctx, ticketID)
+	if err != nil {
+		return fmt.Errorf("failed to list attachments: %w", err)
+	}
+
+	err = withTx(ctx, s.queries, func(qtx *repositories.Queries) error {
+		if err := qtx.DeleteTicket(ctx, ticketID); err != nil {
+			return fmt.Errorf("failed to delete ticket: %w", err)
+		}
+		return recordTicketEvents(ctx, qtx, ticket.ID, ticket.TenantID, actor, ticketChange{EventType: TicketEventDeleted, Field: "title", OldValue: ticket.Title})
+	})
+	if err != nil {
+		return err
+	}
+	deleteStoredFiles(ctx, storage.Store, attachmentKeys...)
 	return nil
 }
 
-func (s *TicketService) GetTicketByID(ctx context.Context, ticketID pgtype.UUID) (repositories.TicketTicket, error) {
-	ticket, err := s.queries.GetTicketByID(ctx, ticketID)
+// GetTicketPresence returns who is viewing the ticket on its socket.
+func (s *TicketService) GetTicketPresence(ctx context.Context, ticketID pgtype.UUID, actor Actor) ([]PresenceUser, error) {
+	if _, err := s.getTicketForActor(ctx, ticketID, actor); err != nil {
+		return nil, err
+	}
+	users, err := s.presence.TicketPresence(ctx, ticketID.String())
 	if err != nil {
-		return repositories.TicketTicket{}, fmt.Errorf("failed to get ticket: %w", err)
+		return nil, fmt.Errorf("failed to get ticket presence: %w", err)
 	}
-	return ticket, nil
+	return users, nil
 }
 
-func (s *TicketService) ListTicketsByTenantID(ctx context.Context, tenantID pgtype.UUID, page, size int32) ([]repositories.TicketTicket, error) {
-	params := repositories.ListTicketsByTenantIDParams{
-		TenantID: tenantID,
-		Limit:    size,
-		Offset:   page,
+// ListTicketAssignments returns how the ticket was assigned over time, with
+// the strategy and reason behind each assignment.
+func (s *TicketService) ListTicketAssignments(ctx context.Context, ticketID pgtype.UUID, actor Actor) ([]repositories.ListTicketAssignmentsByTicketIDRow, error) {
+	if _, err := s.getTicketForActor(ctx, ticketID, actor); err != nil {
+		return nil, err
 	}
-	tickets, err := s.queries.ListTicketsByTenantID(ctx, params)
+	assignments, err := s.queries.ListTicketAssignmentsByTicketID(ctx, ticketID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list tickets by tenant: %w", err)
+		return nil, fmt.Errorf("failed to list ticket assignments: %w", err)
 	}
-	return tickets, nil
+	return assignments, nil
 }
 
-func (s *TicketService) ListTicketsByCustomerID(ctx context.Context, customerID string, page, size int32) ([]repositories.ListTicketsByCustomerIDRow, error) {
-	customerUUID, err := parseUUID(customerID)
+// ListTicketHistory returns the audit trail of a ticket, newest first.
+func (s *TicketService) ListTicketHistory(ctx context.Context, ticketID pgtype.UUID, actor Actor, page PageRequest) ([]repositories.ListTicketEventsByTicketIDRow, utils.Page, error) {
+	if _, err := s.getTicketForActor(ctx, ticketID, actor); err != nil {
+		return nil, utils.Page{}, err
+	}
+
+	cursorCreatedAt, cursorID, err := cursorParams(page.Cursor)
 	if err != nil {
-		return nil, fmt.Errorf("invalid customer_id: %w", err)
+		return nil, utils.Page{}, err
 	}
-	fmt.Println(page, size)
-	params := repositories.ListTicketsByCustomerIDParams{
-		CustomerID: customerUUID,
-		Limit:      size,
-		Offset:     page,
+	params := repositories.ListTicketEventsByTicketIDParams{
+		TicketID:        ticketID,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		Size:            page.Size + 1,
 	}
-	tickets, err := s.queries.ListTicketsByCustomerID(ctx, params)
+	events, err := s.queries.ListTicketEventsByTicketID(ctx, params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list tickets by customer: %w", err)
+		return nil, utils.Page{}, fmt.Errorf("failed to list ticket history: %w", err)
 	}
-	return tickets, nil
+	events, pageInfo := pageOf(events, page.Size, func(e repositories.ListTicketEventsByTicketIDRow) utils.Cursor {
+		return rowCursor(e.CreatedAt, e.ID)
+	})
+
+	if page.IncludeTotal {
+		total, err := s.queries.CountTicketEventsByTicketID(ctx, ticketID)
+		if err != nil {
+			return nil, utils.Page{}, fmt.Errorf("failed to count ticket history: %w", err)
+		}
+		pageInfo.Total = &total
+	}
+	return events, pageInfo, nil
 }
 
-func (s *TicketService) ListTicketsByAssignedTo(ctx context.Context, assignedTo pgtype.UUID, page, size int32) ([]repositories.TicketTicket, error) {
-	params := repositories.ListTicketsByAssignedToParams{
-		AssignedTo: assignedTo,
-		Limit:      size,
-		Offset:     page,
+// SearchTickets runs a full-text search over ticket titles, descriptions and
+// comments, limited to the tickets the actor can see: their own tickets for
+// customers, the tickets of their tenants for everyone else. Snippets are
+// HTML: matches are wrapped in <mark> tags and the text around them is
+// escaped.
+func (s *TicketService) SearchTickets(ctx context.Context, actor Actor, searchDto dto.SearchTicketsDto, page PageRequest) ([]repositories.SearchTicketsRow, utils.Page, error) {
+	actorUUID, err := parseUUID(actor.ID)
+	if err != nil {
+		return nil, utils.Page{}, fmt.Errorf("invalid actor id: %w", err)
+	}
+	tenantUUID, err := parseUUID(searchDto.TenantID)
+	if err != nil {
+		return nil, utils.Page{}, fmt.Errorf("invalid tenant_id: %w", err)
 	}
-	tickets, err := s.queries.ListTicketsByAssignedTo(ctx, params)
+
+	cursorCreatedAt, cursorID, err := cursorParams(page.Cursor)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list tickets by assigned user: %w", err)
+		return nil, utils.Page{}, err
+	}
+	cursorRank := pgtype.Float4{}
+	if cursorID.Valid {
+		rank, err := strconv.ParseFloat(page.Cursor.Key, 32)
+		if err != nil {
+			return nil, utils.Page{}, utils.NewHTTPError(http.StatusBadRequest, "invalid cursor")
+		}
+		cursorRank = pgtype.Float4{Float32: float32(rank), Valid: true}
 	}
-	return tickets, nil
+
+	params := repositories.SearchTicketsParams{
+		Query:           searchDto.Query,
+		IsCustomer:      actor.IsCustomer(),
+		ActorID:         actorUUID,
+		TenantID:        tenantUUID,
+		CursorRank:      cursorRank,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		Size:            page.Size + 1,
+	}
+	results, err := s.queries.SearchTickets(ctx, params)
+	if err != nil {
+		return nil, utils.Page{}, fmt.Errorf("failed to search tickets: %w", err)
+	}
+	results, pageInfo := pageOf(results, page.Size, func(r repositories.SearchTicketsRow) utils.Cursor {
+		cursor := rowCursor(r.CreatedAt, r.ID)
+		cursor.Key = strconv.FormatFloat(float64(r.Rank), 'g', -1, 32)
+		return cursor
+	})
+	return results, pageInfo, nil
 }
 
-func (s *TicketService) ListTicketsByUserID(ctx context.Context, userID pgtype.UUID, page, size int32) ([]repositories.ListTicketsByUserIdRow, error) {
-	params := repositories.ListTicketsByUserIdParams{
-		UserID: userID,
-		Limit:  size,
-		Offset: page,
+// GetTicketByID returns a ticket the actor has access to.
+func (s *TicketService) GetTicketByID(ctx context.Context, actor Actor, ticketID pgtype.UUID) (repositories.TicketTicket, error) {
+	return getTicketForActor(ctx, s.queries, ticketID, actor)
+}
+
+// ListTickets lists a page of the tickets visible to the actor matching the
+// filter. Customers see their own tickets,
+// everyone else the tickets of their tenants.
+func (s *TicketService) ListTickets(ctx context.Context, actor Actor, filterDto dto.TicketFilterDto, page PageRequest) ([]repositories.FilteredTicketRow, utils.Page, error) {
+	actorUUID, err := parseUUID(actor.ID)
+	if err != nil {
+		return nil, utils.Page{}, fmt.Errorf("invalid actor id: %w", err)
+	}
+	assignedToUUID, err := parseUUID(filterDto.AssignedTo)
+	if err != nil {
+		return nil, utils.Page{}, fmt.Errorf("invalid assigned_to: %w", err)
+	}
+	tenantUUID, err := parseUUID(filterDto.TenantID)
+	if err != nil {
+		return nil, utils.Page{}, fmt.Errorf("invalid tenant_id: %w", err)
+	}
+
+	sort, order := filterDto.Sort, filterDto.Order
+	if sort == "" {
+		sort = repositories.TicketSortCreatedAt
+	}
+	if order == "" {
+		order = "desc"
 	}
-	tickets, err := s.queries.ListTicketsByUserId(ctx, params)
+	if err := checkCursorSort(page.Cursor, sort, order); err != nil {
+		return nil, utils.Page{}, err
+	}
+	cursorCreatedAt, cursorID, err := cursorParams(page.Cursor)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list tickets by user: %w", err)
+		return nil, utils.Page{}, err
+	}
+	if cursorID.Valid && !repositories.ValidTicketCursorKey(sort, page.Cursor.Key) {
+		return nil, utils.Page{}, utils.NewHTTPError(http.StatusBadRequest, "invalid cursor")
 	}
-	return tickets, nil
+
+	filter := repositories.TicketFilter{
+		Statuses:        filterDto.Status,
+		Priorities:      filterDto.Priority,
+		AssignedTo:      assignedToUUID,
+		TenantID:        tenantUUID,
+		Unassigned:      filterDto.Unassigned,
+		CreatedFrom:     makeTimestamptz(filterDto.CreatedFrom),
+		CreatedTo:       makeTimestamptz(filterDto.CreatedTo),
+		UpdatedFrom:     makeTimestamptz(filterDto.UpdatedFrom),
+		UpdatedTo:       makeTimestamptz(filterDto.UpdatedTo),
+		SortBy:          sort,
+		SortAsc:         order == "asc",
+		CursorKey:       page.Cursor.Key,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		Limit:           page.Size + 1,
+	}
+	if actor.IsCustomer() {
+		filter.CustomerID = actorUUID
+	} else {
+		filter.UserID = actorUUID
+	}
+
+	tickets, err := s.queries.ListTicketsFiltered(ctx, filter)
+	if err != nil {
+		return nil, utils.Page{}, fmt.Errorf("failed to list tickets: %w", err)
+	}
+	tickets, pageInfo := pageOf(tickets, page.Size, func(t repositories.FilteredTicketRow) utils.Cursor {
+		cursor := rowCursor(t.CreatedAt, t.ID)
+		cursor.Key = t.SortKey
+		cursor.Sort = sort
+		cursor.Order = order
+		return cursor
+	})
+
+	if page.IncludeTotal {
+		total, err := s.queries.CountTicketsFiltered(ctx, filter)
+		if err != nil {
+			return nil, utils.Page{}, fmt.Errorf("failed to count tickets: %w", err)
+		}
+		pageInfo.Total = &total
+	}
+	return tickets, pageInfo, nil
 }
 
-func (s *TicketService) ListCommentsByTicketID(ctx context.Context, ticketID pgtype.UUID, page, size int32) ([]repositories.ListCommentsByTicketIDRow, error) {
+// ListCommentsByTicketID returns the comments of a ticket, newest first.
+// Customers only see public comments.
+func (s *TicketService) ListCommentsByTicketID(ctx context.Context, ticketID pgtype.UUID, actor Actor, page PageRequest) ([]repositories.ListCommentsByTicketIDRow, utils.Page, error) {
+	if _, err := s.getTicketForActor(ctx, ticketID, actor); err != nil {
+		return nil, utils.Page{}, err
+	}
+
+	cursorCreatedAt, cursorID, err := cursorParams(page.Cursor)
+	if err != nil {
+		return nil, utils.Page{}, err
+	}
 	params := repositories.ListCommentsByTicketIDParams{
-		TicketID: ticketID,
-		Limit:    size,
-		Offset:   page,
+		TicketID:        ticketID,
+		IncludeInternal: !actor.IsCustomer(),
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		Size:            page.Size + 1,
 	}
 	comments, err := s.queries.ListCommentsByTicketID(ctx, params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list comments by ticket ID: %w", err)
+		return nil, utils.Page{}, fmt.Errorf("failed to list comments by ticket ID: %w", err)
+	}
+	comments, pageInfo := pageOf(comments, page.Size, func(c repositories.ListCommentsByTicketIDRow) utils.Cursor {
+		return rowCursor(c.CreatedAt, c.ID)
+	})
+
+	if page.IncludeTotal {
+		total, err := s.queries.CountCommentsByTicketID(ctx, repositories.CountCommentsByTicketIDParams{
+			TicketID:        ticketID,
+			IncludeInternal: !actor.IsCustomer(),
+		})
+		if err != nil {
+			return nil, utils.Page{}, fmt.Errorf("failed to count comments: %w", err)
+		}
+		pageInfo.Total = &total
 	}
-	return comments, nil
+	return comments, pageInfo, nil
 }
 
-func (s *TicketService) CreateComment(ctx context.Context, ticketIDStr, content, authorIDStr, authorType string) (pgtype.UUID, error) {
+// CreateComment stores a comment written by the actor, records it in the
+// ticket history and pushes it to the ticket room. Internal notes, which only
+// staff may write, are neither shown nor notified to the customer and do not
+// count as a first response.
+func (s *TicketService) CreateComment(ctx context.Context, ticketIDStr, content, visibility string, actor Actor) (pgtype.UUID, error) {
 	ticketUUID, err := parseUUID(ticketIDStr)
 	if err != nil {
 		return pgtype.UUID{}, fmt.Errorf("invalid ticket_id: %w", err)
 	}
 
-	authorUUID, err := parseUUID(authorIDStr)
+	authorUUID, err := parseUUID(actor.ID)
 	if err != nil {
 		return pgtype.UUID{}, fmt.Errorf("invalid author_id: %w", err)
 	}
 
+	visibility = orDefault(visibility, CommentPublic)
+	if visibility != CommentPublic && visibility != CommentInternal {
+		return pgtype.UUID{}, utils.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid comment visibility %q", visibility))
+	}
+	internal := visibility == CommentInternal
+	if internal && actor.IsCustomer() {
+		return pgtype.UUID{}, utils.NewHTTPError(http.StatusForbidden, "customers cannot write internal notes")
+	}
+
+	ticket, err := s.getTicketForActor(ctx, ticketUUID, actor)
+	if err != nil {
+		return pgtype.UUID{}, err
+	}
+
+	authorType := "USER"
+	if actor.IsCustomer() {
+		authorType = "CUSTOMER"
+	}
+
 	params := repositories.CreateCommentParams{
 		TicketID:   ticketUUID,
 		Comment:    content,
 		AuthorID:   authorUUID,
 		AuthorType: authorType,
+		Visibility: visibility,
 	}
 
-	commentID, err := s.queries.CreateComment(ctx, params)
+	change := ticketChange{EventType: TicketEventCommented, Field: "comment", NewValue: content}
+	if internal {
+		change.Field = "internal_note"
+	}
+	var commentID pgtype.UUID
+	err = withTx(ctx, s.queries, func(qtx *repositories.Queries) error {
+		commentID, err = qtx.CreateComment(ctx, params)
+		if err != nil {
+			return fmt.Errorf("failed to create comment: %w", err)
+		}
+		if !actor.IsCustomer() && !internal {
+			if err := qtx.MarkTicketFirstResponse(ctx, ticket.ID); err != nil {
+				return fmt.Errorf("failed to mark first response: %w", err)
+			}
+		}
+		return recordTicketEvents(ctx, qtx, ticket.ID, ticket.TenantID, actor, change)
+	})
 	if err != nil {
-		return pgtype.UUID{}, fmt.Errorf("failed to create comment: %w", err)
+		return pgtype.UUID{}, err
+	}
+
+	s.notifier.NotifyTicket(ticket.ID.String(), RealtimeComment, CommentEvent{
+		ID:         commentID.String(),
+		AuthorID:   actor.ID,
+		Username:   actor.Username,
+		Role:       actor.Role,
+		Content:    content,
+		Visibility: visibility,
+		CreatedAt:  time.Now(),
+	})
+	if actor.IsCustomer() && ticket.AssignedTo.Valid {
+		s.notifier.NotifyUser(ticket.AssignedTo.String(), NotificationCustomerReplied, TicketNotification{
+			TicketID: ticket.ID.String(),
+			Title:    ticket.Title,
+			Message:  fmt.Sprintf("%s replied to the ticket", actor.Username),
+		})
+	} else if !actor.IsCustomer() && !internal && ticket.CustomerID.Valid {
+		s.notifier.NotifyUser(ticket.CustomerID.String(), NotificationTicketReplied, TicketNotification{
+			TicketID: ticket.ID.String(),
+			Title:    ticket.Title,
+			Message:  fmt.Sprintf("%s replied to your ticket", actor.Username),
+		})
 	}
 	return commentID, nil
 }
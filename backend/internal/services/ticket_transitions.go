@@ -0,0 +1,59 @@
+package services
+
+import (
+	"backend/utils"
+	"fmt"
+	"net/http"
+	"slices"
+)
+
+// ticketTransitions is the ticket status state machine: for each current
+// status, the statuses it may move to and the roles allowed to make the move.
+var ticketTransitions = map[string]map[string][]string{
+	"OPEN": {
+		"IN_PROGRESS": {"Admin", "Technician"},
+		"RESOLVED":    {"Admin", "Technician"},
+		"CLOSED":      {"Admin", "Customer"},
+	},
+	"IN_PROGRESS": {
+		"OPEN":     {"Admin", "Technician"},
+		"RESOLVED": {"Admin", "Technician"},
+		"CLOSED":   {"Admin"},
+	},
+	"RESOLVED": {
+		"CLOSED":   {"Admin", "Customer"},
+		"REOPENED": {"Admin", "Technician", "Customer"},
+	},
+	"CLOSED": {
+		"REOPENED": {"Admin", "Customer"},
+	},
+	"REOPENED": {
+		"IN_PROGRESS": {"Admin", "Technician"},
+		"RESOLVED":    {"Admin", "Technician"},
+		"CLOSED":      {"Admin"},
+	},
+}
+
+// AllowedTicketTransitions returns the statuses the given role may move a
+// ticket to from its current status.
+func AllowedTicketTransitions(from, role string) []string {
+	allowed := []string{}
+	for to, roles := range ticketTransitions[from] {
+		if slices.Contains(roles, role) {
+			allowed = append(allowed, to)
+		}
+	}
+	slices.Sort(allowed)
+	return allowed
+}
+
+func checkTicketTransition(from, to, role string) error {
+	roles, ok := ticketTransitions[from][to]
+	if !ok {
+		return utils.NewHTTPError(http.StatusConflict, fmt.Sprintf("cannot move ticket from %s to %s", from, to))
+	}
+	if !slices.Contains(roles, role) {
+		return utils.NewHTTPError(http.StatusForbidden, fmt.Sprintf("role %s cannot move ticket from %s to %s", role, from, to))
+	}
+	return nil
+}
@@ -5,10 +5,13 @@ import (
 	"backend/internal/repositories"
 	"backend/utils"
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -147,3 +150,52 @@ func (s *UserService) DeleteUserByID(ctx context.Context, userID string) error {
 
 	return nil
 }
+
+// SetTechnicianSkills replaces the categories a technician is skilled in, used
+// by skill-match ticket assignment. The technician must belong to one of the
+// actor's tenants.
+func (s *UserService) SetTechnicianSkills(ctx context.Context, actor Actor, userID string, skills []string) ([]string, error) {
+	id, err := parseUUID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID: %w", err)
+	}
+	actorUUID, err := parseUUID(actor.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid actor id: %w", err)
+	}
+
+	user, err := s.queries.GetUserByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, utils.NewHTTPError(http.StatusNotFound, "technician not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.Role != repositories.UserRoleTechnician {
+		return nil, utils.NewHTTPError(http.StatusNotFound, "technician not found")
+	}
+	sharesTenant, err := s.queries.SharesTenant(ctx, repositories.SharesTenantParams{UserID: actorUUID, MemberID: id})
+	if err != nil {
+		return nil, fmt.Errorf("failed to check tenant membership: %w", err)
+	}
+	if !sharesTenant {
+		return nil, utils.NewHTTPError(http.StatusNotFound, "technician not found")
+	}
+
+	err = withTx(ctx, s.queries, func(qtx *repositories.Queries) error {
+		if err := qtx.DeleteTechnicianSkills(ctx, id); err != nil {
+			return fmt.Errorf("failed to clear technician skills: %w", err)
+		}
+		for _, skill := range skills {
+			if err := qtx.AddTechnicianSkill(ctx, repositories.AddTechnicianSkillParams{UserID: id, Category: skill}); err != nil {
+				return fmt.Errorf("failed to add technician skill: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.queries.ListTechnicianSkills(ctx, id)
+}
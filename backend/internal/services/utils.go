@@ -1,6 +1,10 @@
 package services
 
 import (
+	"backend/internal/repositories"
+	"context"
+	"fmt"
+
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 )
@@ -16,3 +20,21 @@ func parseUUID(s string) (pgtype.UUID, error) {
 	}
 	return pgtype.UUID{Bytes: id, Valid: true}, nil
 }
+
+// withTx runs fn with queries bound to a new transaction, committing if fn
+// succeeds and rolling back otherwise.
+func withTx(ctx context.Context, queries *repositories.Queries, fn func(qtx *repositories.Queries) error) error {
+	tx, err := repositories.BeginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(queries.WithTx(tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	s3Algorithm      = "AWS4-HMAC-SHA256"
+	s3UnsignedBody   = "UNSIGNED-PAYLOAD"
+	s3TimeFormat     = "20060102T150405Z"
+	s3DateFormat     = "20060102"
+	s3MaxPresignTime = 7 * 24 * time.Hour
+)
+
+type S3Config struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// PathStyle addresses the bucket as endpoint/bucket instead of
+	// bucket.endpoint, as MinIO expects by default.
+	PathStyle bool
+}
+
+// S3Storage stores files in an S3-compatible bucket. Requests are signed with
+// AWS Signature Version 4 and downloads use presigned GET URLs.
+type S3Storage struct {
+	config S3Config
+	base   *url.URL
+	client *http.Client
+}
+
+func NewS3Storage(config S3Config) (*S3Storage, error) {
+	if config.Endpoint == "" || config.Bucket == "" || config.AccessKey == "" || config.SecretKey == "" {
+		return nil, errors.New("S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY and S3_SECRET_KEY are required")
+	}
+	base, err := url.Parse(strings.TrimRight(config.Endpoint, "/"))
+	if err != nil || base.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", config.Endpoint)
+	}
+	if !config.PathStyle {
+		base.Host = config.Bucket + "." + base.Host
+	}
+	return &S3Storage{
+		config: config,
+		base:   base,
+		client: &http.Client{Timeout: 60 * time.Second},
+	}, nil
+}
+
+func (s *S3Storage) objectURL(key string) *url.URL {
+	object := *s.base
+	path := "/" + key
+	if s.config.PathStyle {
+		path = "/" + s.config.Bucket + path
+	}
+	object.Path = path
+	object.RawPath = s3EscapePath(path)
+	return &object
+}
+
+func (s *S3Storage) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key).String(), body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+	return s.do(req, http.StatusOK)
+}
+
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key).String(), nil)
+	if err != nil {
+		return err
+	}
+	return s.do(req, http.StatusNoContent, http.StatusOK)
+}
+
+func (s *S3Storage) do(req *http.Request, expected ...int) error {
+	s.signRequest(req, time.Now().UTC(), s3UnsignedBody)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("s3 %s request failed: %w", req.Method, err)
+	}
+	defer resp.Body.Close()
+	for _, status := range expected {
+		if resp.StatusCode == status {
+			return nil
+		}
+	}
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("s3 %s returned %s: %s", req.Method, resp.Status, message)
+}
+
+// SignedURL returns a presigned GET URL that asks S3 to serve the object as
+// an attachment named fileName.
+func (s *S3Storage) SignedURL(ctx context.Context, key, fileName string, expiry time.Duration) (string, error) {
+	if expiry > s3MaxPresignTime {
+		expiry = s3MaxPresignTime
+	}
+	query := url.Values{}
+	query.Set("response-content-disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+	return s.presign(http.MethodGet, key, query, time.Now().UTC(), expiry), nil
+}
+
+// presign returns a URL for method on the object signed in its query string,
+// with query added to the signed parameters.
+func (s *S3Storage) presign(method, key string, query url.Values, now time.Time, expiry time.Duration) string {
+	object := s.objectURL(key)
+	query.Set("X-Amz-Algorithm", s3Algorithm)
+	query.Set("X-Amz-Credential", s.config.AccessKey+"/"+s.scope(now))
+	query.Set("X-Amz-Date", now.Format(s3TimeFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expiry.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+	object.RawQuery = s3EncodeQuery(query)
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		object.EscapedPath(),
+		object.RawQuery,
+		"host:" + object.Host + "\n",
+		"host",
+		s3UnsignedBody,
+	}, "\n")
+	signature := s.signature(now, canonicalRequest)
+	object.RawQuery += "&X-Amz-Signature=" + signature
+	return object.String()
+}
+
+// signRequest adds the Authorization header to a request. payloadHash is the
+// hex SHA-256 of the body, or UNSIGNED-PAYLOAD so uploads can be streamed.
+func (s *S3Storage) signRequest(req *http.Request, now time.Time, payloadHash string) {
+	req.Header.Set("X-Amz-Date", now.Format(s3TimeFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		s3EncodeQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, s.config.AccessKey, s.scope(now), signedHeaders, s.signature(now, canonicalRequest)))
+}
+
+func (s *S3Storage) scope(now time.Time) string {
+	return now.Format(s3DateFormat) + "/" + s.config.Region + "/s3/aws4_request"
+}
+
+func (s *S3Storage) signature(now time.Time, canonicalRequest string) string {
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		s3Algorithm,
+		now.Format(s3TimeFormat),
+		s.scope(now),
+		hex.EncodeToString(hash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.config.SecretKey), now.Format(s3DateFormat))
+	key = hmacSHA256(key, s.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3EncodeQuery encodes query parameters sorted by key and then value, as the
+// canonical request requires.
+func s3EncodeQuery(values url.Values) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var pairs []string
+	for _, key := range keys {
+		vals := append([]string{}, values[key]...)
+		sort.Strings(vals)
+		for _, value := range vals {
+			pairs = append(pairs, s3URIEncode(key)+"="+s3URIEncode(value))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// s3EscapePath escapes each segment of an object path, keeping the slashes.
+func s3EscapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = s3URIEncode(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// s3URIEncode percent-encodes every byte of value except the RFC 3986
+// unreserved characters, as Signature Version 4 requires. url.PathEscape and
+// url.QueryEscape both leave some reserved characters as they are.
+func s3URIEncode(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestS3Integration runs Put, a presigned GET and Delete against a real
+// bucket, such as the minio service of docker-compose.yml:
+//
+//	S3_ENDPOINT=http://localhost:9000 S3_BUCKET=attachments \
+//	S3_ACCESS_KEY=minioadmin S3_SECRET_KEY=minioadmin \
+//	go test ./internal/storage -run TestS3Integration
+func TestS3Integration(t *testing.T) {
+	config := S3Config{
+		Endpoint:  os.Getenv("S3_ENDPOINT"),
+		Region:    getEnv("S3_REGION", "us-east-1"),
+		Bucket:    os.Getenv("S3_BUCKET"),
+		AccessKey: os.Getenv("S3_ACCESS_KEY"),
+		SecretKey: os.Getenv("S3_SECRET_KEY"),
+		PathStyle: getEnv("S3_PATH_STYLE", "true") == "true",
+	}
+	if config.Endpoint == "" || config.Bucket == "" || config.AccessKey == "" || config.SecretKey == "" {
+		t.Skip("S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY and S3_SECRET_KEY are not set")
+	}
+	s3, err := NewS3Storage(config)
+	if err != nil {
+		t.Fatalf("NewS3Storage: %v", err)
+	}
+
+	ctx := t.Context()
+	key := fmt.Sprintf("integration/%d/notes (v1)!=@:.txt", time.Now().UnixNano())
+	body := []byte("attachment body")
+
+	if err := s3.Put(ctx, key, bytes.NewReader(body), int64(len(body)), "text/plain"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	t.Cleanup(func() {
+		// t.Context is already cancelled when cleanups run.
+		s3.Delete(context.Background(), key)
+	})
+
+	signed, err := s3.SignedURL(ctx, key, "notes.txt", time.Minute)
+	if err != nil {
+		t.Fatalf("SignedURL: %v", err)
+	}
+	resp, err := http.Get(signed)
+	if err != nil {
+		t.Fatalf("GET signed URL: %v", err)
+	}
+	got, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET signed URL returned %s: %s", resp.Status, got)
+	}
+	if !bytes.Equal(got, body) {
+		t.Errorf("downloaded %q, want %q", got, body)
+	}
+	if disposition := resp.Header.Get("Content-Disposition"); disposition != "attachment; filename=notes.txt" {
+		t.Errorf("Content-Disposition = %q", disposition)
+	}
+
+	if err := s3.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	resp, err = http.Get(signed)
+	if err != nil {
+		t.Fatalf("GET signed URL after delete: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET after delete returned %s, want 404 Not Found", resp.Status)
+	}
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Storage keeps attachment files. Files are never served by key directly;
+// clients download them through short-lived signed URLs.
+type Storage interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a URL that downloads the file as fileName until it
+	// expires.
+	SignedURL(ctx context.Context, key, fileName string, expiry time.Duration) (string, error)
+}
+
+var (
+	Store Storage
+
+	ErrInvalidSignature = errors.New("invalid or expired signature")
+)
+
+// InitStorage configures Store from STORAGE_DRIVER: "local" (the default)
+// stores files under STORAGE_LOCAL_DIR, "s3" stores them in an S3-compatible
+// bucket such as MinIO. Local download URLs are signed with
+// STORAGE_SIGNING_KEY, or JWT_SECRET when it is not set; one of them is
+// required.
+func InitStorage() error {
+	switch driver := getEnv("STORAGE_DRIVER", "local"); driver {
+	case "local":
+		signingKey := os.Getenv("STORAGE_SIGNING_KEY")
+		if signingKey == "" {
+			signingKey = os.Getenv("JWT_SECRET")
+		}
+		if signingKey == "" {
+			return errors.New("STORAGE_SIGNING_KEY or JWT_SECRET must be set to sign download URLs")
+		}
+		Store = NewLocalStorage(
+			getEnv("STORAGE_LOCAL_DIR", "attachments"),
+			getEnv("STORAGE_PUBLIC_URL", "http://localhost:8080"),
+			signingKey,
+		)
+	case "s3":
+		s3, err := NewS3Storage(S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    getEnv("S3_REGION", "us-east-1"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			PathStyle: getEnv("S3_PATH_STYLE", "true") == "true",
+		})
+		if err != nil {
+			return err
+		}
+		Store = s3
+	default:
+		return fmt.Errorf("unknown storage driver %q", driver)
+	}
+	return nil
+}
+
+func getEnv(key, fallback string) string {
+	if value, exists := os.LookupEnv(key); exists {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,258 @@
+package jobs
+
+import (
+	"backend/internal/money"
+	"backend/internal/repositories"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jung-kurt/gofpdf"
+)
+
+const (
+	TypePDFRefund     = "pdf:refund"
+	TypePDFCreditNote = "pdf:credit_note"
+
+	// RefundPDFDir and CreditNotePDFDir are where generated refund and
+	// credit note PDFs are written.
+	RefundPDFDir     = "refunds"
+	CreditNotePDFDir = "credit_notes"
+)
+
+// RefundPayload identifies the stored refund to render.
+type RefundPayload struct {
+	RefundID string `json:"refund_id"`
+}
+
+// CreditNotePayload identifies the stored credit note to render.
+type CreditNotePayload struct {
+	CreditNoteID string `json:"credit_note_id"`
+}
+
+func (q *InvoicePDFQueue) EnqueueRefundPDF(ctx context.Context, refundID string) error {
+	return enqueueDocument(q.client, TypePDFRefund, "refund", refundID, RefundPayload{RefundID: refundID})
+}
+
+func (q *InvoicePDFQueue) EnqueueCreditNotePDF(ctx context.Context, creditNoteID string) error {
+	return enqueueDocument(q.client, TypePDFCreditNote, "credit note", creditNoteID, CreditNotePayload{CreditNoteID: creditNoteID})
+}
+
+func enqueueDocument(client *asynq.Client, taskType, kind, id string, payload interface{}) error {
+	if jobLogger != nil {
+		jobLogger.Printf("ENQUEUE: Enqueue PDF %s job for %s", kind, id)
+	}
+
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		if jobLogger != nil {
+			jobLogger.Printf("ENQUEUE_ERROR: marshal payload for %s %s: %v", kind, id, err)
+		}
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	task := asynq.NewTask(taskType, payloadBytes)
+	if _, err := client.Enqueue(task, asynq.MaxRetry(3)); err != nil {
+		if jobLogger != nil {
+			jobLogger.Printf("ENQUEUE_ERROR: enqueue task for %s %s: %v", kind, id, err)
+		}
+		return err
+	}
+
+	if jobLogger != nil {
+		jobLogger.Printf("ENQUEUE_SUCCESS: enqueued PDF %s job for %s", kind, id)
+	}
+	return nil
+}
+
+// HandleRefundPDFTask renders a stored refund to RefundPDFDir and records the
+// file, or the failure, on the refund.
+func HandleRefundPDFTask(ctx context.Context, t *asynq.Task) error {
+	var payload RefundPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w: %w", err, asynq.SkipRetry)
+	}
+
+	queries := repositories.GetDB()
+	return handleDocumentTask(ctx, TypePDFRefund, payload.RefundID,
+		func(id pgtype.UUID) (document, error) {
+			refund, err := queries.GetRefundDocument(ctx, id)
+			if err != nil {
+				return document{}, err
+			}
+			details := []string{fmt.Sprintf("Invoice: %s", refund.InvoiceID.String())}
+			if refund.PaymentMethod.Valid {
+				details = append(details, fmt.Sprintf("Refund of the %s payment of %s", strings.ToLower(strings.ReplaceAll(string(refund.PaymentMethod.InvoicePaymentMethod), "_", " ")), refund.PaymentDate.Time.Format("2006-01-02")))
+			}
+			return document{
+				Title:       fmt.Sprintf("Refund %s", refund.Number),
+				TenantName:  refund.TenantName,
+				TenantEmail: refund.TenantEmail,
+				Customer:    customerLine(refund.CustomerFirstName, refund.CustomerLastName, refund.CustomerEmail),
+				IssuedAt:    refund.CreatedAt.Time,
+				Details:     details,
+				Reason:      refund.Reason.String,
+				AmountLabel: "Amount refunded",
+				Amount:      money.Money{Amount: refund.Amount, Currency: money.Currency(refund.Currency)},
+				Path:        filepath.Join(RefundPDFDir, refund.ID.String()+".pdf"),
+			}, nil
+		},
+		func(params repositories.RecordInvoicePDFParams) error {
+			return queries.RecordRefundPDF(ctx, repositories.RecordRefundPDFParams(params))
+		})
+}
+
+// HandleCreditNotePDFTask renders a stored credit note to CreditNotePDFDir and
+// records the file, or the failure, on the credit note.
+func HandleCreditNotePDFTask(ctx context.Context, t *asynq.Task) error {
+	var payload CreditNotePayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w: %w", err, asynq.SkipRetry)
+	}
+
+	queries := repositories.GetDB()
+	return handleDocumentTask(ctx, TypePDFCreditNote, payload.CreditNoteID,
+		func(id pgtype.UUID) (document, error) {
+			note, err := queries.GetCreditNoteDocument(ctx, id)
+			if err != nil {
+				return document{}, err
+			}
+			return document{
+				Title:       fmt.Sprintf("Credit note %s", note.Number),
+				TenantName:  note.TenantName,
+				TenantEmail: note.TenantEmail,
+				Customer:    customerLine(note.CustomerFirstName, note.CustomerLastName, note.CustomerEmail),
+				IssuedAt:    note.CreatedAt.Time,
+				Details:     []string{fmt.Sprintf("Issued against invoice: %s", note.InvoiceID.String())},
+				Reason:      note.Reason.String,
+				AmountLabel: "Amount credited",
+				Amount:      money.Money{Amount: note.Amount, Currency: money.Currency(note.Currency)},
+				Path:        filepath.Join(CreditNotePDFDir, note.ID.String()+".pdf"),
+			}, nil
+		},
+		func(params repositories.RecordInvoicePDFParams) error {
+			return queries.RecordCreditNotePDF(ctx, repositories.RecordCreditNotePDFParams(params))
+		})
+}
+
+// document is what the refund and credit note PDFs show.
+type document struct {
+	Title       string
+	TenantName  string
+	TenantEmail string
+	Customer    string
+	IssuedAt    time.Time
+	Details     []string
+	Reason      string
+	AmountLabel string
+	Amount      money.Money
+	Path        string
+}
+
+// handleDocumentTask loads a document with load, writes its PDF and stores
+// the outcome with record, the same way HandlePDFTask does for invoices.
+func handleDocumentTask(ctx context.Context, taskType, id string, load func(pgtype.UUID) (document, error), record func(repositories.RecordInvoicePDFParams) error) error {
+	startTime := time.Now()
+	if jobLogger != nil {
+		jobLogger.Printf("TASK_START: %s at %s", taskType, startTime.Format(time.RFC3339))
+	}
+
+	var documentID pgtype.UUID
+	if err := documentID.Scan(id); err != nil {
+		return fmt.Errorf("invalid document ID %q: %w", id, asynq.SkipRetry)
+	}
+
+	doc, err := load(documentID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		if jobLogger != nil {
+			jobLogger.Printf("TASK_SKIPPED: %s %s no longer exists", taskType, id)
+		}
+		return fmt.Errorf("document %s not found: %w", id, asynq.SkipRetry)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get document: %w", err)
+	}
+
+	if err := writeDocumentPDF(doc); err != nil {
+		if jobLogger != nil {
+			jobLogger.Printf("TASK_ERROR: failed to generate %s PDF for %s after %v: %v", taskType, id, time.Since(startTime), err)
+		}
+		recordDocumentPDF(record, documentID, InvoicePDFFailed, "", err.Error())
+		return fmt.Errorf("failed to generate PDF: %w", err)
+	}
+	if err := recordDocumentPDF(record, documentID, InvoicePDFGenerated, doc.Path, ""); err != nil {
+		return err
+	}
+
+	if jobLogger != nil {
+		jobLogger.Printf("TASK_SUCCESS: generated %s in %v", doc.Path, time.Since(startTime))
+	}
+	return nil
+}
+
+func customerLine(firstName, lastName, email pgtype.Text) string {
+	name := strings.TrimSpace(firstName.String + " " + lastName.String)
+	return strings.TrimSpace(name + " " + email.String)
+}
+
+func writeDocumentPDF(doc document) error {
+	if err := os.MkdirAll(filepath.Dir(doc.Path), 0755); err != nil {
+		return fmt.Errorf("failed to create documents directory: %w", err)
+	}
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.AddPage()
+	pdf.SetFont("Arial", "B", 16)
+	pdf.CellFormat(0, 10, tr(doc.TenantName), "", 1, "L", false, 0, "")
+	pdf.SetFont("Arial", "", 10)
+	pdf.CellFormat(0, 6, tr(doc.TenantEmail), "", 1, "L", false, 0, "")
+	pdf.Ln(6)
+
+	pdf.SetFont("Arial", "B", 14)
+	pdf.CellFormat(0, 10, tr(doc.Title), "", 1, "L", false, 0, "")
+	pdf.SetFont("Arial", "", 12)
+	pdf.CellFormat(0, 8, fmt.Sprintf("Issued: %s", doc.IssuedAt.Format("2006-01-02")), "", 1, "L", false, 0, "")
+	if doc.Customer != "" {
+		pdf.CellFormat(0, 8, tr(fmt.Sprintf("Customer: %s", doc.Customer)), "", 1, "L", false, 0, "")
+	}
+	for _, detail := range doc.Details {
+		pdf.CellFormat(0, 8, tr(detail), "", 1, "L", false, 0, "")
+	}
+	if doc.Reason != "" {
+		pdf.Ln(2)
+		pdf.MultiCell(0, 6, tr(fmt.Sprintf("Reason: %s", doc.Reason)), "", "L", false)
+	}
+	pdf.Ln(6)
+
+	pdf.SetFont("Arial", "B", 12)
+	pdf.CellFormat(154, 8, fmt.Sprintf("%s (%s)", doc.AmountLabel, doc.Amount.Currency), "", 0, "R", false, 0, "")
+	pdf.CellFormat(26, 8, doc.Amount.Currency.Format(doc.Amount.Amount), "T", 1, "R", false, 0, "")
+
+	return pdf.OutputFileAndClose(doc.Path)
+}
+
+// recordDocumentPDF stores the outcome of a PDF generation with record.
+func recordDocumentPDF(record func(repositories.RecordInvoicePDFParams) error, id pgtype.UUID, status, filePath, message string) error {
+	params := repositories.RecordInvoicePDFParams{
+		PdfStatus: status,
+		PdfPath:   pgtype.Text{String: filePath, Valid: filePath != ""},
+		PdfError:  pgtype.Text{String: message, Valid: message != ""},
+		ID:        id,
+	}
+	if err := record(params); err != nil {
+		if jobLogger != nil {
+			jobLogger.Printf("TASK_ERROR: record PDF status of %s: %v", id.String(), err)
+		}
+		return fmt.Errorf("failed to record PDF: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package jobs
+
+import (
+	"backend/internal/mail"
+	"backend/internal/repositories"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	TypeSendEmail = "email:send"
+
+	// emailMaxRetry is how many times a failed email is retried, with
+	// asynq's exponential backoff, before its delivery is marked FAILED.
+	emailMaxRetry = 5
+
+	EmailStatusQueued = "QUEUED"
+	EmailStatusSent   = "SENT"
+	EmailStatusFailed = "FAILED"
+)
+
+// Email is an email to render from a template and send. TenantID selects the
+// sender address and TicketID links the delivery to a ticket; both are
+// optional.
+type Email struct {
+	TenantID string                 `json:"tenant_id,omitempty"`
+	TicketID string                 `json:"ticket_id,omitempty"`
+	To       string                 `json:"to"`
+	ToName   string                 `json:"to_name,omitempty"`
+	Template string                 `json:"template"`
+	Data     map[string]interface{} `json:"data,omitempty"`
+	// SendAt holds the email back until then when set, such as the end of
+	// the recipient's quiet hours.
+	SendAt time.Time `json:"-"`
+}
+
+type EmailPayload struct {
+	DeliveryID string `json:"delivery_id"`
+	Email
+}
+
+// EmailQueue records emails in the delivery log and enqueues them.
+type EmailQueue struct {
+	client  *asynq.Client
+	queries *repositories.Queries
+}
+
+func NewEmailQueue(client *asynq.Client) *EmailQueue {
+	return &EmailQueue{client: client, queries: repositories.GetDB()}
+}
+
+func (q *EmailQueue) EnqueueEmail(ctx context.Context, email Email) error {
+	if !mail.HasTemplate(email.Template) {
+		return fmt.Errorf("unknown email template %q", email.Template)
+	}
+	if _, err := mail.ParseAddress(email.To); err != nil {
+		return fmt.Errorf("invalid recipient %q: %w", email.To, err)
+	}
+	var tenantID, ticketID pgtype.UUID
+	if email.TenantID != "" {
+		if err := tenantID.Scan(email.TenantID); err != nil {
+			return fmt.Errorf("invalid tenant ID: %w", err)
+		}
+	}
+	if email.TicketID != "" {
+		if err := ticketID.Scan(email.TicketID); err != nil {
+			return fmt.Errorf("invalid ticket ID: %w", err)
+		}
+	}
+
+	delivery, err := q.queries.CreateEmailDelivery(ctx, repositories.CreateEmailDeliveryParams{
+		TenantID:  tenantID,
+		TicketID:  ticketID,
+		Recipient: email.To,
+		Template:  email.Template,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record email delivery: %w", err)
+	}
+
+	payloadBytes, err := json.Marshal(EmailPayload{DeliveryID: delivery.ID.String(), Email: email})
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	opts := []asynq.Option{asynq.MaxRetry(emailMaxRetry)}
+	if !email.SendAt.IsZero() {
+		opts = append(opts, asynq.ProcessAt(email.SendAt))
+	}
+	if _, err := q.client.Enqueue(asynq.NewTask(TypeSendEmail, payloadBytes), opts...); err != nil {
+		if jobLogger != nil {
+			jobLogger.Printf("ENQUEUE_ERROR: enqueue email %s to %s: %v", email.Template, email.To, err)
+		}
+		return err
+	}
+	if jobLogger != nil {
+		jobLogger.Printf("ENQUEUE_SUCCESS: enqueued email %s to %s as delivery %s", email.Template, email.To, delivery.ID.String())
+	}
+	return nil
+}
+
+// NewEmailHandler returns the handler that renders and sends queued emails,
+// recording every attempt in the delivery log. Rejections by the SMTP server
+// and rendering errors are not retried.
+func NewEmailHandler(sender mail.Sender) asynq.HandlerFunc {
+	return func(ctx context.Context, t *asynq.Task) error {
+		startTime := time.Now()
+		if jobLogger != nil {
+			jobLogger.Printf("TASK_START: %s at %s", TypeSendEmail, startTime.Format(time.RFC3339))
+		}
+
+		var payload EmailPayload
+		if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+			return fmt.Errorf("failed to unmarshal payload: %w: %w", err, asynq.SkipRetry)
+		}
+		var deliveryID pgtype.UUID
+		if err := deliveryID.Scan(payload.DeliveryID); err != nil {
+			return fmt.Errorf("invalid delivery ID: %w: %w", err, asynq.SkipRetry)
+		}
+
+		queries := repositories.GetDB()
+		from := emailSender(ctx, queries, payload.TenantID)
+		msg, err := renderEmail(payload, from)
+		// Rendering fails the same way every time.
+		permanent := err != nil
+		if err == nil {
+			err = sender.Send(ctx, msg)
+			permanent = mail.IsPermanent(err)
+		}
+
+		attempt := repositories.RecordEmailDeliveryAttemptParams{
+			ID:        deliveryID,
+			Status:    EmailStatusSent,
+			Sender:    from.String(),
+			Subject:   msg.Subject,
+			MessageID: msg.MessageID,
+		}
+		if err != nil {
+			retried, _ := asynq.GetRetryCount(ctx)
+			maxRetry, _ := asynq.GetMaxRetry(ctx)
+			attempt.Status = EmailStatusQueued
+			if permanent || retried >= maxRetry {
+				attempt.Status = EmailStatusFailed
+			}
+			attempt.LastError = pgtype.Text{String: err.Error(), Valid: true}
+		}
+		if _, recordErr := queries.RecordEmailDeliveryAttempt(ctx, attempt); recordErr != nil && jobLogger != nil {
+			jobLogger.Printf("TASK_ERROR: record attempt of email delivery %s: %v", payload.DeliveryID, recordErr)
+		}
+
+		if err != nil {
+			if jobLogger != nil {
+				jobLogger.Printf("TASK_ERROR: email delivery %s to %s after %v: %v", payload.DeliveryID, payload.To, time.Since(startTime), err)
+			}
+			if permanent {
+				return fmt.Errorf("failed to send email: %w: %w", err, asynq.SkipRetry)
+			}
+			return fmt.Errorf("failed to send email: %w", err)
+		}
+		if jobLogger != nil {
+			jobLogger.Printf("TASK_SUCCESS: sent email delivery %s to %s in %v", payload.DeliveryID, payload.To, time.Since(startTime))
+		}
+		return nil
+	}
+}
+
+// emailSender returns the tenant's configured sender, or the default sender
+// address under the tenant's name.
+func emailSender(ctx context.Context, queries *repositories.Queries, tenantID string) mail.Address {
+	var tenantUUID pgtype.UUID
+	if tenantID == "" || tenantUUID.Scan(tenantID) != nil {
+		return mail.DefaultFrom
+	}
+	tenant, err := queries.GetTenantEmailSender(ctx, tenantUUID)
+	if err != nil {
+		if jobLogger != nil {
+			jobLogger.Printf("TASK_ERROR: load email sender of tenant %s: %v", tenantID, err)
+		}
+		return mail.DefaultFrom
+	}
+	from := mail.Address{Name: tenant.TenantName, Address: mail.DefaultFrom.Address}
+	if tenant.EmailFromName.Valid && tenant.EmailFromName.String != "" {
+		from.Name = tenant.EmailFromName.String
+	}
+	if tenant.EmailFromAddress.Valid && tenant.EmailFromAddress.String != "" {
+		from.Address = tenant.EmailFromAddress.String
+	}
+	return from
+}
+
+func renderEmail(payload EmailPayload, from mail.Address) (mail.Message, error) {
+	data := make(map[string]interface{}, len(payload.Data)+2)
+	for key, value := range payload.Data {
+		data[key] = value
+	}
+	data["SenderName"] = from.Name
+	data["RecipientName"] = payload.ToName
+	if payload.ToName == "" {
+		data["RecipientName"] = "there"
+	}
+
+	msg := mail.Message{
+		From:      from,
+		To:        mail.Address{Name: payload.ToName, Address: payload.To},
+		MessageID: fmt.Sprintf("<%s@%s>", payload.DeliveryID, emailDomain(from.Address)),
+	}
+	rendered, err := mail.Render(payload.Template, data)
+	if err != nil {
+		return msg, err
+	}
+	msg.Subject, msg.Text, msg.HTML = rendered.Subject, rendered.Text, rendered.HTML
+	return msg, nil
+}
+
+func emailDomain(address string) string {
+	if _, domain, ok := strings.Cut(address, "@"); ok && domain != "" {
+		return domain
+	}
+	return "localhost"
+}
@@ -0,0 +1,39 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+)
+
+const TypeEscalationCheck = "escalation:check"
+
+// Escalator evaluates the tenants' escalation rules against their tickets.
+type Escalator interface {
+	RunEscalations(ctx context.Context) (int, error)
+}
+
+// NewEscalationHandler returns the handler of the periodic escalation check.
+func NewEscalationHandler(escalator Escalator) asynq.HandlerFunc {
+	return func(ctx context.Context, t *asynq.Task) error {
+		startTime := time.Now()
+		if jobLogger != nil {
+			jobLogger.Printf("TASK_START: %s at %s", TypeEscalationCheck, startTime.Format(time.RFC3339))
+		}
+
+		escalated, err := escalator.RunEscalations(ctx)
+		if err != nil {
+			if jobLogger != nil {
+				jobLogger.Printf("TASK_ERROR: escalation check after %d escalations: %v", escalated, err)
+			}
+			return fmt.Errorf("failed to run escalations: %w", err)
+		}
+
+		if jobLogger != nil {
+			jobLogger.Printf("TASK_SUCCESS: %d tickets escalated in %v", escalated, time.Since(startTime))
+		}
+		return nil
+	}
+}
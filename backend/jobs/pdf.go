@@ -1,23 +1,39 @@
 package jobs
 
 import (
+	"backend/internal/money"
+	"backend/internal/repositories"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/hibiken/asynq"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jung-kurt/gofpdf"
 )
 
-const TypePDFInvoice = "pdf:invoice"
+const (
+	TypePDFInvoice = "pdf:invoice"
 
+	// InvoicePDFDir is where generated invoice PDFs are written.
+	InvoicePDFDir = "invoices"
+
+	InvoicePDFPending   = "PENDING"
+	InvoicePDFGenerated = "GENERATED"
+	InvoicePDFFailed    = "FAILED"
+)
+
+// InvoicePayload identifies the stored invoice to render.
 type InvoicePayload struct {
-	TicketID string  `json:"ticket_id"`
-	Amount   float64 `json:"amount"`
+	InvoiceID string `json:"invoice_id"`
 }
 
 var (
@@ -48,20 +64,32 @@ func CloseLogger() error {
 	return nil
 }
 
-func EnqueueInvoice(client *asynq.Client, ticketID string, amount float64) error {
+// InvoicePDFQueue enqueues the PDF generation of stored invoices.
+type InvoicePDFQueue struct {
+	client *asynq.Client
+}
+
+func NewInvoicePDFQueue(client *asynq.Client) *InvoicePDFQueue {
+	return &InvoicePDFQueue{client: client}
+}
+
+func (q *InvoicePDFQueue) EnqueueInvoicePDF(ctx context.Context, invoiceID string) error {
+	return EnqueueInvoice(q.client, invoiceID)
+}
+
+func EnqueueInvoice(client *asynq.Client, invoiceID string) error {
 	payload := InvoicePayload{
-		TicketID: ticketID,
-		Amount:   amount,
+		InvoiceID: invoiceID,
 	}
 
 	if jobLogger != nil {
-		jobLogger.Printf("ENQUEUE: Enqueue PDF invoice job for ticket %s amount=%.2f", ticketID, amount)
+		jobLogger.Printf("ENQUEUE: Enqueue PDF invoice job for invoice %s", invoiceID)
 	}
 
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
 		if jobLogger != nil {
-			jobLogger.Printf("ENQUEUE_ERROR: marshal payload for ticket %s: %v", ticketID, err)
+			jobLogger.Printf("ENQUEUE_ERROR: marshal payload for invoice %s: %v", invoiceID, err)
 		}
 		return fmt.Errorf("failed to marshal payload: %w", err)
 	}
@@ -70,17 +98,19 @@ func EnqueueInvoice(client *asynq.Client, ticketID string, amount float64) error
 	_, err = client.Enqueue(task, asynq.MaxRetry(3))
 	if err != nil {
 		if jobLogger != nil {
-			jobLogger.Printf("ENQUEUE_ERROR: enqueue task for ticket %s: %v", ticketID, err)
+			jobLogger.Printf("ENQUEUE_ERROR: enqueue task for invoice %s: %v", invoiceID, err)
 		}
 		return err
 	}
 
 	if jobLogger != nil {
-		jobLogger.Printf("ENQUEUE_SUCCESS: enqueued PDF invoice job for ticket %s", ticketID)
+		jobLogger.Printf("ENQUEUE_SUCCESS: enqueued PDF invoice job for invoice %s", invoiceID)
 	}
 	return nil
 }
 
+// HandlePDFTask renders a stored invoice to InvoicePDFDir and records the
+// file, or the failure, on the invoice.
 func HandlePDFTask(ctx context.Context, t *asynq.Task) error {
 	startTime := time.Now()
 	if jobLogger != nil {
@@ -92,41 +122,188 @@ func HandlePDFTask(ctx context.Context, t *asynq.Task) error {
 		if jobLogger != nil {
 			jobLogger.Printf("TASK_ERROR: unmarshal payload: %v", err)
 		}
-		return fmt.Errorf("failed to unmarshal payload: %w", err)
+		return fmt.Errorf("failed to unmarshal payload: %w: %w", err, asynq.SkipRetry)
+	}
+	var invoiceID pgtype.UUID
+	if err := invoiceID.Scan(payload.InvoiceID); err != nil {
+		return fmt.Errorf("invalid invoice ID %q: %w", payload.InvoiceID, asynq.SkipRetry)
 	}
 
 	if jobLogger != nil {
-		jobLogger.Printf("TASK_PROCESSING: ticket=%s amount=%.2f", payload.TicketID, payload.Amount)
+		jobLogger.Printf("TASK_PROCESSING: invoice=%s", payload.InvoiceID)
 	}
 
-	// Ensure invoices directory exists
-	if err := os.MkdirAll("./invoices", 0755); err != nil {
+	queries := repositories.GetDB()
+	invoice, err := queries.GetInvoiceDocument(ctx, invoiceID)
+	if errors.Is(err, pgx.ErrNoRows) {
 		if jobLogger != nil {
-			jobLogger.Printf("TASK_ERROR: create invoices dir: %v", err)
+			jobLogger.Printf("TASK_SKIPPED: invoice %s no longer exists", payload.InvoiceID)
 		}
-		return fmt.Errorf("failed to create invoices directory: %w", err)
+		return fmt.Errorf("invoice %s not found: %w", payload.InvoiceID, asynq.SkipRetry)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get invoice: %w", err)
 	}
 
-	pdf := gofpdf.New("P", "mm", "A4", "")
-	pdf.AddPage()
-	pdf.SetFont("Arial", "B", 16)
-	pdf.CellFormat(0, 10, fmt.Sprintf("Invoice for Ticket %s", payload.TicketID), "", 1, "L", false, 0, "")
-	pdf.Ln(4)
-	pdf.SetFont("Arial", "", 12)
-	pdf.CellFormat(0, 8, fmt.Sprintf("Amount: $%.2f", payload.Amount), "", 1, "L", false, 0, "")
+	lines, err := queries.ListInvoiceLinesByInvoiceID(ctx, invoiceID)
+	if err != nil {
+		return fmt.Errorf("failed to list invoice lines: %w", err)
+	}
 
-	filePath := fmt.Sprintf("invoices/%s.pdf", payload.TicketID)
-	if err := pdf.OutputFileAndClose(filePath); err != nil {
+	filePath, err := writeInvoicePDF(invoice, lines)
+	if err != nil {
 		duration := time.Since(startTime)
 		if jobLogger != nil {
-			jobLogger.Printf("TASK_ERROR: failed to generate PDF for ticket %s after %v: %v", payload.TicketID, duration, err)
+			jobLogger.Printf("TASK_ERROR: failed to generate PDF for invoice %s after %v: %v", payload.InvoiceID, duration, err)
 		}
+		recordInvoicePDF(ctx, queries, invoiceID, InvoicePDFFailed, "", err.Error())
 		return fmt.Errorf("failed to generate PDF: %w", err)
 	}
+	if err := recordInvoicePDF(ctx, queries, invoiceID, InvoicePDFGenerated, filePath, ""); err != nil {
+		return err
+	}
 
 	duration := time.Since(startTime)
 	if jobLogger != nil {
-		jobLogger.Printf("TASK_SUCCESS: generated invoice %s for ticket %s in %v", filePath, payload.TicketID, duration)
+		jobLogger.Printf("TASK_SUCCESS: generated invoice %s for ticket %s in %v", filePath, invoice.TicketID.String(), duration)
+	}
+	return nil
+}
+
+// InvoicePDFPath is where the PDF of an invoice is written.
+func InvoicePDFPath(invoiceID string) string {
+	return filepath.Join(InvoicePDFDir, invoiceID+".pdf")
+}
+
+func writeInvoicePDF(invoice repositories.GetInvoiceDocumentRow, lines []repositories.InvoiceInvoiceLine) (string, error) {
+	if err := os.MkdirAll(InvoicePDFDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create invoices directory: %w", err)
+	}
+
+	customer := strings.TrimSpace(invoice.CustomerFirstName.String + " " + invoice.CustomerLastName.String)
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.AddPage()
+	pdf.SetFont("Arial", "B", 16)
+	pdf.CellFormat(0, 10, tr(invoice.TenantName), "", 1, "L", false, 0, "")
+	pdf.SetFont("Arial", "", 10)
+	pdf.CellFormat(0, 6, tr(invoice.TenantEmail), "", 1, "L", false, 0, "")
+	pdf.Ln(6)
+
+	pdf.SetFont("Arial", "B", 14)
+	pdf.CellFormat(0, 10, fmt.Sprintf("Invoice %s", invoice.ID.String()), "", 1, "L", false, 0, "")
+	pdf.SetFont("Arial", "", 12)
+	pdf.CellFormat(0, 8, fmt.Sprintf("Issued: %s", invoice.CreatedAt.Time.Format("2006-01-02")), "", 1, "L", false, 0, "")
+	if invoice.DueDate.Valid {
+		pdf.CellFormat(0, 8, fmt.Sprintf("Due: %s", invoice.DueDate.Time.Format("2006-01-02")), "", 1, "L", false, 0, "")
+	}
+	if customer != "" || invoice.CustomerEmail.Valid {
+		pdf.CellFormat(0, 8, tr(strings.TrimSpace(fmt.Sprintf("Bill to: %s %s", customer, invoice.CustomerEmail.String))), "", 1, "L", false, 0, "")
+	}
+	pdf.CellFormat(0, 8, tr(fmt.Sprintf("Ticket: %s", invoice.TicketTitle)), "", 1, "L", false, 0, "")
+	pdf.Ln(6)
+
+	writeInvoiceLines(pdf, tr, invoice, lines)
+
+	filePath := InvoicePDFPath(invoice.ID.String())
+	if err := pdf.OutputFileAndClose(filePath); err != nil {
+		return "", err
+	}
+	return filePath, nil
+}
+
+// invoiceColumns are the headings and widths, in mm, of the line table.
+var invoiceColumns = []struct {
+	heading string
+	width   float64
+}{
+	{"Description", 72},
+	{"Qty", 18},
+	{"Unit price", 25},
+	{"Discount", 22},
+	{"Tax %", 17},
+	{"Total", 26},
+}
+
+// writeInvoiceLines renders the line table and the totals under it.
+// Descriptions wrap within their column and the row grows to fit them.
+func writeInvoiceLines(pdf *gofpdf.Fpdf, tr func(string) string, invoice repositories.GetInvoiceDocumentRow, lines []repositories.InvoiceInvoiceLine) {
+	const lineHeight = 6
+
+	pdf.SetFont("Arial", "B", 10)
+	pdf.SetFillColor(230, 230, 230)
+	for n, column := range invoiceColumns {
+		align := "R"
+		if n == 0 {
+			align = "L"
+		}
+		pdf.CellFormat(column.width, 8, column.heading, "1", 0, align, true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	currency := money.Currency(invoice.Currency)
+	pdf.SetFont("Arial", "", 10)
+	for _, line := range lines {
+		description := tr(line.Description)
+		wrapped := pdf.SplitLines([]byte(description), invoiceColumns[0].width-2)
+		height := float64(lineHeight * len(wrapped))
+		if _, pageHeight := pdf.GetPageSize(); pdf.GetY()+height > pageHeight-20 {
+			pdf.AddPage()
+		}
+
+		x, y := pdf.GetXY()
+		pdf.MultiCell(invoiceColumns[0].width, lineHeight, description, "1", "L", false)
+		pdf.SetXY(x+invoiceColumns[0].width, y)
+		values := []string{
+			line.Quantity.String(),
+			currency.Format(line.UnitPrice),
+			currency.Format(line.Discount),
+			line.TaxRate.String(),
+			currency.Format(line.Total),
+		}
+		for n, value := range values {
+			pdf.CellFormat(invoiceColumns[n+1].width, height, value, "1", 0, "R", false, 0, "")
+		}
+		pdf.SetXY(x, y+height)
+	}
+	pdf.Ln(4)
+
+	labelWidth := 0.0
+	for _, column := range invoiceColumns[:len(invoiceColumns)-1] {
+		labelWidth += column.width
+	}
+	totalWidth := invoiceColumns[len(invoiceColumns)-1].width
+	totals := []struct {
+		label string
+		value money.Decimal
+	}{
+		{"Subtotal", invoice.Subtotal},
+		{"Discount", invoice.DiscountTotal},
+		{"Tax", invoice.TaxTotal},
+	}
+	for _, total := range totals {
+		pdf.CellFormat(labelWidth, lineHeight, total.label, "", 0, "R", false, 0, "")
+		pdf.CellFormat(totalWidth, lineHeight, currency.Format(total.value), "", 1, "R", false, 0, "")
+	}
+	pdf.SetFont("Arial", "B", 12)
+	pdf.CellFormat(labelWidth, 8, fmt.Sprintf("Total (%s)", currency), "", 0, "R", false, 0, "")
+	pdf.CellFormat(totalWidth, 8, currency.Format(invoice.Amount), "T", 1, "R", false, 0, "")
+}
+
+// recordInvoicePDF stores the outcome of a PDF generation on the invoice.
+func recordInvoicePDF(ctx context.Context, queries *repositories.Queries, invoiceID pgtype.UUID, status, filePath, message string) error {
+	params := repositories.RecordInvoicePDFParams{
+		PdfStatus: status,
+		PdfPath:   pgtype.Text{String: filePath, Valid: filePath != ""},
+		PdfError:  pgtype.Text{String: message, Valid: message != ""},
+		ID:        invoiceID,
+	}
+	if err := queries.RecordInvoicePDF(ctx, params); err != nil {
+		if jobLogger != nil {
+			jobLogger.Printf("TASK_ERROR: record PDF status of invoice %s: %v", invoiceID.String(), err)
+		}
+		return fmt.Errorf("failed to record invoice PDF: %w", err)
 	}
 	return nil
 }
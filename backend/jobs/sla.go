@@ -0,0 +1,166 @@
+package jobs
+
+import (
+	"backend/internal/repositories"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const TypeSLACheck = "sla:check"
+
+const (
+	// SLAOnTrack, SLAAtRisk and SLABreached are the SLA statuses stored on
+	// tickets.
+	SLAOnTrack  = "ON_TRACK"
+	SLAAtRisk   = "AT_RISK"
+	SLABreached = "BREACHED"
+
+	// EventSLAAtRisk and EventSLABreached are the notification types sent to
+	// the assignee when their ticket's SLA status changes.
+	EventSLAAtRisk   = "ticket.sla_at_risk"
+	EventSLABreached = "ticket.sla_breached"
+
+	// slaAtRiskRatio is the share of an SLA window after which a ticket is
+	// flagged as at risk.
+	slaAtRiskRatio = 0.8
+)
+
+// TicketNotifier pushes events to clients watching a ticket and to users'
+// personal streams.
+type TicketNotifier interface {
+	NotifyTicket(ticketID, eventType string, data interface{})
+	NotifyUser(userID, eventType string, data interface{})
+}
+
+// SLANotification matches the services' ticket notifications on a user's
+// personal stream.
+type SLANotification struct {
+	TicketID string `json:"ticket_id"`
+	Title    string `json:"title"`
+	Message  string `json:"message"`
+}
+
+type SLAEvent struct {
+	TicketID           string    `json:"ticket_id"`
+	SlaStatus          string    `json:"sla_status"`
+	PreviousStatus     string    `json:"previous_status"`
+	FirstResponseDueAt time.Time `json:"first_response_due_at"`
+	ResolutionDueAt    time.Time `json:"resolution_due_at"`
+}
+
+// NewSLACheckHandler returns the handler of the periodic SLA check. It flags
+// open tickets approaching or past their deadlines and notifies the ticket room
+// whenever a ticket's SLA status changes, and the assignee when it turns at
+// risk or breached.
+func NewSLACheckHandler(notifier TicketNotifier) asynq.HandlerFunc {
+	return func(ctx context.Context, t *asynq.Task) error {
+		startTime := time.Now()
+		if jobLogger != nil {
+			jobLogger.Printf("TASK_START: %s at %s", TypeSLACheck, startTime.Format(time.RFC3339))
+		}
+
+		queries := repositories.GetDB()
+		tickets, err := queries.ListTicketsForSLACheck(ctx)
+		if err != nil {
+			if jobLogger != nil {
+				jobLogger.Printf("TASK_ERROR: list tickets for sla check: %v", err)
+			}
+			return fmt.Errorf("failed to list tickets for sla check: %w", err)
+		}
+
+		changed := 0
+		for _, ticket := range tickets {
+			status := slaStatus(ticket, startTime)
+			if ticket.SlaStatus.Valid && ticket.SlaStatus.String == status {
+				continue
+			}
+
+			err := queries.UpdateTicketSLAStatus(ctx, repositories.UpdateTicketSLAStatusParams{
+				ID:        ticket.ID,
+				SlaStatus: pgtype.Text{String: status, Valid: true},
+			})
+			if err != nil {
+				if jobLogger != nil {
+					jobLogger.Printf("TASK_ERROR: update sla status of ticket %s: %v", ticket.ID.String(), err)
+				}
+				return fmt.Errorf("failed to update sla status: %w", err)
+			}
+			changed++
+
+			if jobLogger != nil {
+				jobLogger.Printf("TASK_PROCESSING: ticket=%s sla_status %s -> %s", ticket.ID.String(), ticket.SlaStatus.String, status)
+			}
+			if notifier != nil {
+				notifier.NotifyTicket(ticket.ID.String(), "ticket.sla", SLAEvent{
+					TicketID:           ticket.ID.String(),
+					SlaStatus:          status,
+					PreviousStatus:     ticket.SlaStatus.String,
+					FirstResponseDueAt: ticket.FirstResponseDueAt.Time,
+					ResolutionDueAt:    ticket.ResolutionDueAt.Time,
+				})
+				notifyAssigneeOfSLA(notifier, ticket, status)
+			}
+		}
+
+		if jobLogger != nil {
+			jobLogger.Printf("TASK_SUCCESS: checked %d tickets, %d sla changes in %v", len(tickets), changed, time.Since(startTime))
+		}
+		return nil
+	}
+}
+
+// notifyAssigneeOfSLA tells the assigned technician that their ticket is at
+// risk or breached.
+func notifyAssigneeOfSLA(notifier TicketNotifier, ticket repositories.ListTicketsForSLACheckRow, status string) {
+	if !ticket.AssignedTo.Valid {
+		return
+	}
+	var eventType, message string
+	switch status {
+	case SLAAtRisk:
+		eventType, message = EventSLAAtRisk, "The ticket is close to missing its SLA"
+	case SLABreached:
+		eventType, message = EventSLABreached, "The ticket missed its SLA"
+	default:
+		return
+	}
+	notifier.NotifyUser(ticket.AssignedTo.String(), eventType, SLANotification{
+		TicketID: ticket.ID.String(),
+		Title:    ticket.Title,
+		Message:  message,
+	})
+}
+
+// slaStatus classifies a ticket against its deadlines. A ticket breaches when
+// it is past its resolution deadline, or past its first response deadline
+// without a response.
+func slaStatus(ticket repositories.ListTicketsForSLACheckRow, now time.Time) string {
+	start := ticket.CreatedAt.Time
+	awaitingResponse := !ticket.FirstRespondedAt.Valid && ticket.FirstResponseDueAt.Valid
+
+	if now.After(ticket.ResolutionDueAt.Time) {
+		return SLABreached
+	}
+	if awaitingResponse && now.After(ticket.FirstResponseDueAt.Time) {
+		return SLABreached
+	}
+	if elapsedRatio(start, ticket.ResolutionDueAt.Time, now) >= slaAtRiskRatio {
+		return SLAAtRisk
+	}
+	if awaitingResponse && elapsedRatio(start, ticket.FirstResponseDueAt.Time, now) >= slaAtRiskRatio {
+		return SLAAtRisk
+	}
+	return SLAOnTrack
+}
+
+func elapsedRatio(start, due, now time.Time) float64 {
+	window := due.Sub(start)
+	if window <= 0 {
+		return 1
+	}
+	return float64(now.Sub(start)) / float64(window)
+}
@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Cursor is the position of the last row of a page in keyset pagination.
+// Lists ordered by something other than created_at also carry that row's sort
+// value in Key. Lists with a choice of order record it in Sort and Order, so
+// the cursor is only used to resume the same list.
+type Cursor struct {
+	CreatedAt time.Time `json:"c"`
+	ID        string    `json:"i"`
+	Key       string    `json:"k,omitempty"`
+	Sort      string    `json:"s,omitempty"`
+	Order     string    `json:"o,omitempty"`
+}
+
+func (c Cursor) IsZero() bool {
+	return c.ID == ""
+}
+
+// EncodeCursor returns the opaque form of the cursor handed to clients.
+func EncodeCursor(c Cursor) string {
+	payload, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(payload)
+}
+
+func DecodeCursor(s string) (Cursor, error) {
+	var c Cursor
+	payload, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("invalid cursor: %w", err)
+	}
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return Cursor{}, fmt.Errorf("invalid cursor: %w", err)
+	}
+	if c.ID == "" || c.CreatedAt.IsZero() {
+		return Cursor{}, fmt.Errorf("invalid cursor")
+	}
+	return c, nil
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"errors"
 	"regexp"
 
 	"github.com/jackc/pgx/v5/pgconn"
@@ -11,6 +12,11 @@ func ParseDBError(err error) (status int, payload interface{}) {
 		return 200, nil
 	}
 
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Status, map[string]string{"error": httpErr.Message}
+	}
+
 	if pgErr, ok := err.(*pgconn.PgError); ok {
 		switch pgErr.Code {
 		case "23505": 
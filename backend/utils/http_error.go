@@ -0,0 +1,16 @@
+package utils
+
+// HTTPError is returned by services when a request fails for a reason that
+// maps to a specific HTTP status rather than a database error.
+type HTTPError struct {
+	Status  int
+	Message string
+}
+
+func (e *HTTPError) Error() string {
+	return e.Message
+}
+
+func NewHTTPError(status int, message string) *HTTPError {
+	return &HTTPError{Status: status, Message: message}
+}
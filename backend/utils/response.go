@@ -15,3 +15,18 @@ func ErrorResponse(message string, errorstring interface{}) map[string]interface
 	}
 	return apiResponse
 }
+
+// Page describes where a page of a keyset paginated list ends. Total is only
+// set when the client asked for it.
+type Page struct {
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+	Total      *int64 `json:"total,omitempty"`
+}
+
+// PaginatedResponse is SuccessResponse with the pagination metadata of a list.
+func PaginatedResponse(message string, data interface{}, page Page) map[string]interface{} {
+	apiResponse := SuccessResponse(message, data)
+	apiResponse["pagination"] = page
+	return apiResponse
+}